@@ -0,0 +1,76 @@
+// alias.go - User defined command aliases
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Set the user defined command aliases: each alias maps a word to the
+// command line fragment it stands for (e.g. "co" to "checkout --quiet").
+// When the word appears where a command is expected, Interpret()
+// replaces it with the fragment. Like git aliases, an alias can't
+// override a command and the expansion isn't expanded again.
+func (spec *Spec) SetAliases(aliases map[string]string) error {
+	m := make(map[string][]string, len(aliases))
+	for nm, v := range aliases {
+		words, err := SplitPOSIX(v)
+		if err != nil {
+			return fmt.Errorf("Invalid alias %s: %s", nm, err)
+		}
+		if len(words) == 0 {
+			return fmt.Errorf("Invalid alias %s: empty expansion", nm)
+		}
+		m[nm] = words
+	}
+
+	spec.aliases = m
+	return nil
+}
+
+// Load the user defined command aliases (see SetAliases()) from the
+// file 'fn'. Each line of the file is of the form "alias = fragment";
+// blank lines and lines starting with '#' are ignored. A missing file
+// is not an error, so that tools can always load ~/.toolaliases.
+func (spec *Spec) LoadAliases(fn string) error {
+	fd, err := os.Open(fn)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer fd.Close()
+
+	aliases := make(map[string]string)
+	sc := bufio.NewScanner(fd)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		nm := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || len(nm) == 0 {
+			return fmt.Errorf("%s:%d: Invalid alias: %s", fn, n, line)
+		}
+		aliases[nm] = strings.TrimSpace(kv[1])
+	}
+	if err = sc.Err(); err != nil {
+		return err
+	}
+
+	return spec.SetAliases(aliases)
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,62 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAliases(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command>
+    --
+    quiet     -q,--quiet                  Quiet
+    --
+    --
+    checkout  checkout                    Check out
+    log       log                         Show the log
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := filepath.Join(t.TempDir(), "aliases")
+	err = os.WriteFile(fn, []byte(`
+# user aliases
+co   = -q checkout
+log  = checkout
+loop = loop
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = spec.LoadAliases(fn); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "co", "main"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Command != "checkout" || !opts.GetBool("quiet") || len(opts.Args) != 2 || opts.Args[1] != "main" {
+		t.Errorf("bad alias expansion: %s %v", opts.Command, opts.Args)
+	}
+
+	opts, err = spec.Interpret([]string{"tool", "log"}, []string{})
+	if err != nil || opts.Command != "log" {
+		t.Errorf("alias overrode a command: %v", err)
+	}
+
+	if _, err = spec.Interpret([]string{"tool", "loop"}, []string{}); err == nil {
+		t.Error("expected an error for a recursive alias")
+	}
+
+	if err = spec.LoadAliases(filepath.Join(t.TempDir(), "none")); err != nil {
+		t.Errorf("missing file: %s", err)
+	}
+	if err = spec.SetAliases(map[string]string{"x": ""}); err == nil {
+		t.Error("expected an error for an empty alias")
+	}
+}
@@ -0,0 +1,62 @@
+// argfile.go - Response files
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// The most response files a command line can read; this also stops
+// files that include each other.
+const maxArgFiles = 64
+
+// Expand an argument of the form "@file" into the words of that file,
+// as in "tool @build.args", so that long command lines don't run into
+// the limits of the shell. The words are split with SplitPOSIX() (so
+// they can be quoted) and lines starting with '#' are comments. A
+// response file can name further response files. Option values are
+// not expanded: "--out @x" sets "out" to "@x". This is off by default.
+func (spec *Spec) SetArgFiles(on bool) {
+	spec.arg_files = on
+}
+
+// Return true if 'arg' names a response file
+func (spec *Spec) isArgFile(arg string) bool {
+	return spec.arg_files && len(arg) > 1 && arg[0] == '@'
+}
+
+// Return the words of the response file named by 'arg' ("@file");
+// 'n' is the number of files read so far.
+func (spec *Spec) readArgFile(arg string, n int) ([]string, error) {
+	if n >= maxArgFiles {
+		return nil, spec.optError(MsgBadArgFile, "", arg, arg, fmt.Errorf("more than %d response files", maxArgFiles))
+	}
+
+	b, err := os.ReadFile(arg[1:])
+	if err != nil {
+		return nil, spec.optError(MsgBadArgFile, "", arg, arg, err)
+	}
+
+	lines := strings.Split(string(b), "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(strings.TrimLeft(l, " \t"), "#") {
+			lines[i] = ""
+		}
+	}
+
+	words, err := SplitPOSIX(strings.Join(lines, "\n"))
+	if err != nil {
+		return nil, spec.optError(MsgBadArgFile, "", arg, arg, err)
+	}
+	return words, nil
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,82 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArgFiles(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] files...
+    --
+    out=      -o,--out=                 Output file
+    verbose   -v,--verbose              Verbose output
+    define=   -D,--define=              Define a symbol
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	inner := filepath.Join(dir, "inner.args")
+	outer := filepath.Join(dir, "outer.args")
+	loop := filepath.Join(dir, "loop.args")
+
+	write := func(fn, s string) {
+		if err := os.WriteFile(fn, []byte(s), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(inner, "-D 'NAME=two words' \"c.txt\"\n")
+	write(outer, "# build flags\n  # indented comment\n-v --out=x.bin\n@"+inner+" b.txt\n")
+	write(loop, "@"+loop)
+
+	// off by default
+	opts, err := spec.Interpret([]string{"tool", "@" + outer}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.Args) != 1 || opts.Args[0] != "@"+outer {
+		t.Errorf("bad args: %v", opts.Args)
+	}
+
+	spec.SetArgFiles(true)
+	opts, err = spec.Interpret([]string{"tool", "a.txt", "@" + outer, "--out", "@y", "@"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.GetBool("verbose") {
+		t.Errorf("verbose not set")
+	}
+	if v, _ := opts.Get("define"); v != "NAME=two words" {
+		t.Errorf("bad define: %q", v)
+	}
+	if v := opts.GetMulti("out"); len(v) != 2 || v[1] != "@y" {
+		t.Errorf("bad out: %q", v)
+	}
+	if s := strings.Join(opts.Args, ","); s != "a.txt,c.txt,b.txt,@" {
+		t.Errorf("bad args: %s", s)
+	}
+
+	_, err = spec.Interpret([]string{"tool", "@" + loop}, []string{})
+	if err == nil || !strings.Contains(err.Error(), "response files") {
+		t.Errorf("expected recursion error, got %v", err)
+	}
+
+	_, err = spec.Interpret([]string{"tool", "@" + filepath.Join(dir, "none")}, []string{})
+	if err == nil || !strings.HasPrefix(err.Error(), "Invalid argument: @") {
+		t.Errorf("expected missing file error, got %v", err)
+	}
+
+	write(inner, "'unterminated")
+	_, err = spec.Interpret([]string{"tool", "@" + inner}, []string{})
+	if err == nil {
+		t.Errorf("expected quoting error")
+	}
+}
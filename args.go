@@ -0,0 +1,123 @@
+// args.go - Positional arguments
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A positional argument declared with SetArgs()
+type argspec struct {
+	name     string
+	optional bool
+	variadic bool
+}
+
+// Declare the positional arguments of the program with a pattern such
+// as "<src> <dst> [extra...]": "<name>" is a required argument,
+// "[name]" an optional one and a trailing "..." takes the rest of the
+// arguments ("<name...>" one or more, "[name...]" any number). Optional
+// arguments follow the required ones and only the last argument can be
+// variadic.
+//
+// Interpret() then checks the number of arguments when no command is
+// given and Options.Arg() returns them by name. Declaring arguments
+// implies that the spec accepts them, as with "*" in the commands
+// section.
+func (spec *Spec) SetArgs(pattern string) error {
+	var args []argspec
+
+	seen := make(map[string]bool)
+	for _, w := range strings.Fields(pattern) {
+		var a argspec
+
+		switch {
+		case len(w) > 2 && w[0] == '<' && w[len(w)-1] == '>':
+		case len(w) > 2 && w[0] == '[' && w[len(w)-1] == ']':
+			a.optional = true
+		default:
+			return fmt.Errorf("Invalid argument spec: %s", w)
+		}
+
+		a.name = w[1 : len(w)-1]
+		if strings.HasSuffix(a.name, "...") {
+			a.name = strings.TrimSuffix(a.name, "...")
+			a.variadic = true
+		}
+
+		switch {
+		case len(a.name) == 0 || seen[a.name]:
+			return fmt.Errorf("Invalid argument spec: %s", w)
+		case len(args) > 0 && args[len(args)-1].variadic:
+			return fmt.Errorf("Invalid argument spec: %s follows a variadic argument", w)
+		case len(args) > 0 && args[len(args)-1].optional && !a.optional:
+			return fmt.Errorf("Invalid argument spec: required %s follows an optional argument", w)
+		}
+
+		seen[a.name] = true
+		args = append(args, a)
+	}
+
+	spec.positional = args
+	spec.allow_unknown_args = true
+	return nil
+}
+
+// Assign the arguments in opts.Args to the declared positional
+// arguments and verify their number.
+func (spec *Spec) checkPositional(opts *Options) error {
+	if len(spec.positional) == 0 || len(opts.Command) > 0 {
+		return nil
+	}
+
+	named := make(map[string][]string, len(spec.positional))
+	rest := opts.Args
+	for _, a := range spec.positional {
+		if len(rest) == 0 {
+			if !a.optional {
+				return spec.errorf(MsgMissingArg, a.name)
+			}
+			continue
+		}
+
+		n := 1
+		if a.variadic {
+			n = len(rest)
+		}
+		named[a.name] = rest[:n:n]
+		rest = rest[n:]
+	}
+
+	if len(rest) > 0 {
+		return spec.errorf(MsgTooManyArgs, len(opts.Args), len(opts.Args)-len(rest))
+	}
+	opts.named = named
+	return nil
+}
+
+// Return the positional argument 'nm' declared with SetArgs(); the
+// first one for a variadic argument. If the argument wasn't given, the
+// bool retval will be False.
+func (opts *Options) Arg(nm string) (string, bool) {
+	if v := opts.named[nm]; len(v) > 0 {
+		return v[0], true
+	}
+	return "", false
+}
+
+// Return all the values of the variadic positional argument 'nm'
+// declared with SetArgs(). A nil slice implies the argument wasn't
+// given. The returned slice is shared with opts and must not be
+// modified.
+func (opts *Options) ArgMulti(nm string) []string {
+	return opts.named[nm]
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,92 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPositionalArgs(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <src> <dst> [extra...]
+    --
+    verbose   -v,--verbose                Verbose
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = spec.SetArgs("<src> <dst> [extra...]"); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "a", "-v", "b", "c", "--", "-d"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := opts.Arg("src"); !ok || v != "a" {
+		t.Errorf("bad src %q", v)
+	}
+	if v, ok := opts.Arg("dst"); !ok || v != "b" {
+		t.Errorf("bad dst %q", v)
+	}
+	if v := opts.ArgMulti("extra"); strings.Join(v, " ") != "c -d" {
+		t.Errorf("bad extra %q", v)
+	}
+	if _, ok := opts.Arg("nope"); ok {
+		t.Error("unknown argument found")
+	}
+
+	c := opts.Clone()
+	opts.named["src"][0] = "x"
+	if v, _ := c.Arg("src"); v != "a" {
+		t.Error("clone shares the arguments")
+	}
+
+	opts, err = spec.Interpret([]string{"tool", "a", "b"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := opts.ArgMulti("extra"); v != nil {
+		t.Errorf("unexpected extra %q", v)
+	}
+
+	_, err = spec.Interpret([]string{"tool", "a"}, []string{})
+	if err == nil || err.Error() != "Missing argument: dst" {
+		t.Errorf("expected a missing argument error, saw %v", err)
+	}
+
+	if err = spec.SetArgs("<src> [dst]"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = spec.Interpret([]string{"tool", "a", "b", "c"}, []string{})
+	if err == nil || !strings.HasPrefix(err.Error(), "Too many arguments: 3 (at most 2") {
+		t.Errorf("expected a too many arguments error, saw %v", err)
+	}
+}
+
+func TestBadArgsSpec(t *testing.T) {
+	spec, err := Parse("usage: tool\n--\n--\n--\n--\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bad := []string{
+		"src",
+		"<>",
+		"<a> <a>",
+		"[a...] <b>",
+		"[a] <b>",
+		"<a...> [b]",
+	}
+	for _, p := range bad {
+		if err := spec.SetArgs(p); err == nil {
+			t.Errorf("%q: expected an error", p)
+		}
+	}
+
+	if err := spec.SetArgs("<a> [b] [c...]"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,146 @@
+// argv.go - Build command lines from parsed options
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"strings"
+)
+
+// Build the argument vector for re-executing the program (or for a
+// child that shares its spec) from the options that were given on the
+// command line or in the environment; defaults are left out. Options
+// are written in spec order using their canonical spelling - the first
+// long form if there is one - as "--name=value" or "-n value", and a
+// flag that is off as "--no-name". If 'include' is non-empty only the
+// named options are written; options named in 'exclude' are always
+// left out. Environment-only entries and secret options (see
+// Redacted()) are skipped, so that credentials stay off the child's
+// command line. The program name, command and arguments are not
+// included.
+func (opts *Options) BuildArgv(include, exclude []string) []string {
+	if opts.spec == nil {
+		return nil
+	}
+
+	in := make(map[string]bool)
+	for _, nm := range include {
+		in[nm] = true
+	}
+	for _, nm := range exclude {
+		in[nm] = false
+	}
+
+	var argv []string
+	for _, o := range opts.spec.optlist {
+		want, named := in[o.name]
+		if (named && !want) || (len(include) > 0 && !want) {
+			continue
+		}
+
+		argv = append(argv, opts.optionArgs(o, false)...)
+	}
+	return argv
+}
+
+// Return the argument vector that reproduces the effective options,
+// e.g. to start a child process with the options of its parent. It is
+// the same as BuildArgv(nil, nil) but with 'withDefaults' set it also
+// writes the options that have only a default (from the spec, a profile
+// or InterpretWithDefaults()), so that the child doesn't depend on
+// defaults that may differ in its own spec. Options without a command
+// line spelling and secret options are skipped and the program name,
+// command and arguments are not included.
+func (opts *Options) ToArgs(withDefaults bool) []string {
+	if opts.spec == nil {
+		return nil
+	}
+
+	var argv []string
+	for _, o := range opts.spec.optlist {
+		argv = append(argv, opts.optionArgs(o, withDefaults)...)
+	}
+	return argv
+}
+
+// Return the argv tokens that set option 'o' to its values or, if it
+// has none and 'withDefault' is set, to its default. Secret options
+// are never written.
+func (opts *Options) optionArgs(o *optspec, withDefault bool) []string {
+	f := o.canonicalFlag()
+	if len(f) == 0 || opts.spec.isSecret(o.name) {
+		return nil
+	}
+
+	vals := opts.optionv[o.name]
+	if len(vals) == 0 && withDefault {
+		if v, ok := opts.defaults[o.name]; ok {
+			vals = []string{v}
+		}
+	}
+
+	var argv []string
+	for _, v := range vals {
+		argv = append(argv, opts.spec.flagArgs(o, f, v)...)
+	}
+	return argv
+}
+
+// Return the canonical command line spelling of 'o': its primary
+// spelling, its first long flag, or its first flag if it has no long
+// form.
+func (o *optspec) canonicalFlag() string {
+	if len(o.primary) > 0 {
+		return o.primary
+	}
+	for _, f := range o.flags {
+		if strings.HasPrefix(f, "--") {
+			return f
+		}
+	}
+	if len(o.flags) > 0 {
+		return o.flags[0]
+	}
+	return ""
+}
+
+// Return 'f' if it is a long spelling of 'o', else the first long
+// spelling of 'o', if any
+func (o *optspec) longFlag(f string) string {
+	if strings.HasPrefix(f, "--") {
+		return f
+	}
+	for _, s := range o.flags {
+		if strings.HasPrefix(s, "--") {
+			return s
+		}
+	}
+	return ""
+}
+
+// Return the argv tokens that set option 'o' to 'v' using flag 'f'; a
+// flag that is off is written as "--no-name" with its first long
+// spelling if 'f' is short, or left out if it has no long spelling.
+func (spec *Spec) flagArgs(o *optspec, f, v string) []string {
+	switch {
+	case spec.flags[o.name]:
+		if on, ok := parseBool(v); ok && !on {
+			if long := o.longFlag(f); len(long) > 0 {
+				return []string{"--no-" + long[2:]}
+			}
+			return nil
+		}
+		return []string{f}
+	case strings.HasPrefix(f, "--") || len(o.implicit) > 0:
+		return []string{f + "=" + v}
+	default:
+		return []string{f, v}
+	}
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,145 @@
+package options
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildArgv(t *testing.T) {
+	spec, err := Parse(`
+    usage: daemon [options]
+    --
+    root=/x   -r,--root=,DAEMON_ROOT      Data root
+    include=  -I=                         Include dirs
+    verbose   -v,--verbose                Show more info
+    detach    -d,--detach                 Run in the background
+    level=3   --level=                    Log level
+    --
+    HOME=     HOME                        Home dir
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := []string{"DAEMON_ROOT=/srv", "HOME=/home/me"}
+	opts, err := spec.Interpret([]string{"daemon", "-d", "-I", "a", "-v", "-I=b"}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		include, exclude []string
+		want             string
+	}{
+		{nil, nil, "[--root=/srv -I a -I b --verbose --detach]"},
+		{nil, []string{"detach"}, "[--root=/srv -I a -I b --verbose]"},
+		{[]string{"verbose", "level", "detach"}, []string{"detach"}, "[--verbose]"},
+	}
+
+	for i, tc := range tests {
+		got := fmt.Sprint(opts.BuildArgv(tc.include, tc.exclude))
+		if got != tc.want {
+			t.Errorf("%d: expected %s, saw %s", i, tc.want, got)
+		}
+	}
+}
+
+func TestToArgs(t *testing.T) {
+	spec, err := Parse(`
+    usage: daemon [options]
+    --
+    root=/x     -r,--root=,DAEMON_ROOT    Data root
+    verbose     -v,--verbose,DAEMON_DEBUG Show more info
+    color=true:bool --color               Colored output
+    quiet       -q                        Less output
+    level=3     -l,--level=               Log level
+    debug       *-d,--debug,DAEMON_TRACE  Debug
+    --
+    HOME=       HOME                      Home dir
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"daemon", "-l", "5", "--no-color", "-q"}, []string{"DAEMON_DEBUG=0", "DAEMON_TRACE=false", "HOME=/home/me"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		defaults bool
+		want     string
+	}{
+		{false, "[--no-verbose --no-color -q --level=5 --no-debug]"},
+		{true, "[--root=/x --no-verbose --no-color -q --level=5 --no-debug]"},
+	}
+	for _, tc := range tests {
+		argv := opts.ToArgs(tc.defaults)
+		if got := fmt.Sprint(argv); got != tc.want {
+			t.Errorf("defaults %v: expected %s, saw %s", tc.defaults, tc.want, got)
+		}
+
+		// the arguments reproduce the options
+		o2, err := spec.Interpret(append([]string{"daemon"}, argv...), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, nm := range []string{"root", "verbose", "color", "quiet", "level", "debug"} {
+			v1, _ := opts.GetBoolOpt(nm)
+			v2, _ := o2.GetBoolOpt(nm)
+			s1, _ := opts.Get(nm)
+			s2, _ := o2.Get(nm)
+			if v1 != v2 || (!spec.flags[nm] && s1 != s2) || (spec.flags[nm] && opts.IsSet(nm) != o2.IsSet(nm)) {
+				t.Errorf("%s: expected %q, saw %q", nm, s1, s2)
+			}
+		}
+	}
+}
+
+func TestArgsSkipSecrets(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "key")
+	if err := os.WriteFile(fn, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := Parse(`
+    usage: daemon [options]
+    --
+    key=:secret   --key=                  API key
+    ^token=       -t,--token=             Token
+    root=         -r,--root=              Data root
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	opts, err := spec.Interpret([]string{"daemon", "--key=file:" + fn, "-t", "abc", "-r", "/x"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("key"); v != "s3cret" {
+		t.Fatalf("key: expected the file contents, saw %q", v)
+	}
+
+	for _, argv := range [][]string{opts.ToArgs(true), opts.BuildArgv(nil, nil), opts.BuildArgv([]string{"key", "token"}, nil)} {
+		s := fmt.Sprint(argv)
+		if strings.Contains(s, "s3cret") || strings.Contains(s, "abc") || strings.Contains(s, "--key") {
+			t.Errorf("secret on the command line: %s", s)
+		}
+	}
+	if s := fmt.Sprint(opts.ToArgs(false)); s != "[--root=/x]" {
+		t.Errorf("expected [--root=/x], saw %s", s)
+	}
+}
@@ -0,0 +1,76 @@
+// arity.go - Number of times an option can be given
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Split the "(MIN..MAX)" suffix off the option name in 'option' (which
+// may be followed by the rest of the name column, e.g. "=default");
+// either bound can be left out and a missing MAX is 0 (no limit).
+func parseArity(option string) (string, int, int, error) {
+	i := strings.IndexByte(option, '(')
+	j := strings.IndexByte(option, ')')
+	eq := strings.IndexByte(option, '=')
+	if i <= 0 || j < i || (eq >= 0 && eq < i) {
+		return option, 0, 0, nil
+	}
+
+	bad := fmt.Errorf("Invalid option spec: %s has a bad count %s", option[:i], option[i:j+1])
+	lo, hi, ok := strings.Cut(option[i+1:j], "..")
+	if !ok {
+		return "", 0, 0, bad
+	}
+
+	var least, most int
+	var err error
+	if len(lo) > 0 {
+		if least, err = strconv.Atoi(lo); err != nil || least < 0 {
+			return "", 0, 0, bad
+		}
+	}
+	if len(hi) > 0 {
+		if most, err = strconv.Atoi(hi); err != nil || most < 1 || most < least {
+			return "", 0, 0, bad
+		}
+	}
+	return option[:i] + option[j+1:], least, most, nil
+}
+
+// Return the "(MIN..MAX)" suffix of option 'o' or ""
+func (o *optspec) arity() string {
+	if o.minCount == 0 && o.maxCount == 0 {
+		return ""
+	}
+
+	s := "(" + strconv.Itoa(o.minCount) + ".."
+	if o.maxCount > 0 {
+		s += strconv.Itoa(o.maxCount)
+	}
+	return s + ")"
+}
+
+// Verify that the options declared with a minimum count were given
+// often enough
+func (spec *Spec) checkCounts(opts *Options) error {
+	for _, o := range spec.optlist {
+		if n := len(opts.optionv[o.name]); n < o.minCount {
+			if o.minCount == 1 {
+				return spec.optError(MsgTooFewRepeats1, o.name, "", o.describe())
+			}
+			return spec.optError(MsgTooFewRepeats, o.name, "", o.describe(), o.minCount)
+		}
+	}
+	return nil
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,62 @@
+package options
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestArity(t *testing.T) {
+	spec, err := Parse(`
+    usage: cc [options] file...
+    --
+    include(0..3)=   -I=               Include dirs
+    define(1..)=     -D=               Defines
+    verbose(..2)     -v,--verbose      Verbose
+    output(..1)=     -o=               Output file
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"cc", "-D", "X", "-I", "a", "-I", "b", "-v", "-v", "x.c"}, ""},
+		{[]string{"cc", "-D", "X", "-I", "a", "-I", "b", "-I", "c", "-I", "d"}, "Invalid option: -I: given more than 3 times"},
+		{[]string{"cc", "-D", "X", "-vvv"}, "Invalid option: -v: given more than 2 times"},
+		{[]string{"cc", "-D", "X", "-o", "a", "-o", "b"}, "Invalid option: -o: may be given only once"},
+		{[]string{"cc", "-I", "a"}, "Missing option: define (-D) must be given at least once"},
+	}
+
+	for i, tc := range tests {
+		_, err := spec.Interpret(tc.args, []string{})
+		switch {
+		case len(tc.err) == 0 && err != nil:
+			t.Errorf("%d: unexpected error: %s", i, err)
+		case len(tc.err) > 0 && (err == nil || err.Error() != tc.err):
+			t.Errorf("%d: expected %q, saw %v", i, tc.err, err)
+		}
+	}
+
+	_, err = spec.Interpret([]string{"cc"}, []string{})
+	if !errors.Is(err, ErrMissingRequired) {
+		t.Errorf("expected a missing option, saw %v", err)
+	}
+
+	if s := spec.String(); !strings.Contains(s, "include(0..3)=") || !strings.Contains(s, "verbose(0..2)") {
+		t.Errorf("bad spec text:\n%s", s)
+	}
+
+	for _, bad := range []string{"x(3..1)=", "x(a..b)=", "x(2)="} {
+		if _, err := Parse("usage: x\n--\n" + bad + " -x= X\n--\n--\n--\n"); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
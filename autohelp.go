@@ -0,0 +1,122 @@
+// autohelp.go - Built-in help and version options
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The errors returned by Interpret() when the built-in help or
+// version option is given; see SetAutoHelp().
+var (
+	ErrHelp    = errors.New("help requested")
+	ErrVersion = errors.New("version requested")
+)
+
+// Handle "-h", "--help" and "--version" on behalf of the program:
+// Interpret() stops at the first of them and returns ErrHelp or
+// ErrVersion, before checking for required options, and
+// MustInterpret() prints the usage or VersionString() to STDOUT and
+// exits with 0. "-h" (or any short spelling of a declared help flag)
+// prints ShortUsage() and "--help" the full usage. "--help=NAME" asks
+// for the help on option NAME (see OptionHelp()) instead of the usage.
+// "--version" is only recognized if the spec has a version (see
+// Version()).
+//
+// A flag declared in the spec with the name "help" or "version" takes
+// the place of the built-in spellings, e.g. "help -?,--help  Show
+// help". Otherwise the spellings only apply if the spec doesn't use
+// them for something else.
+func (spec *Spec) SetAutoHelp(on bool) {
+	spec.auto_help = on
+}
+
+// The ErrHelp returned for "-h" and "--help=NAME"
+type helpRequest struct {
+	// the option to show the help of, if any
+	topic string
+
+	// set for the short usage
+	short bool
+}
+
+func (h *helpRequest) Error() string {
+	if len(h.topic) == 0 {
+		return ErrHelp.Error()
+	}
+	return ErrHelp.Error() + ": " + h.topic
+}
+
+func (h *helpRequest) Is(target error) bool {
+	return target == ErrHelp
+}
+
+// Return ErrHelp or ErrVersion if the command line option 'arg' asks
+// for help or the version; 'option' is its canonical name or empty if
+// the spec doesn't declare it.
+func (spec *Spec) autoHelp(arg, option string) error {
+	if !spec.auto_help {
+		return nil
+	}
+
+	arg, topic, hasval := strings.Cut(arg, "=")
+	if len(option) == 0 {
+		switch {
+		case arg == "-h" && spec.optinfo["help"] == nil:
+			option = "help"
+		case arg == "--help" && spec.optinfo["help"] == nil:
+			option = "help"
+		case arg == "--version" && spec.optinfo["version"] == nil && len(spec.Version()) > 0:
+			option = "version"
+		}
+	} else if !spec.flags[option] {
+		return nil
+	}
+
+	switch {
+	case option == "help" && hasval:
+		if spec.lookupOpt(topic) == nil {
+			return spec.optError(MsgUnknownOption, "", topic, topic)
+		}
+		return &helpRequest{topic: topic}
+	case hasval:
+	case option == "help" && !strings.HasPrefix(arg, "--"):
+		return &helpRequest{short: true}
+	case option == "help":
+		return ErrHelp
+	case option == "version":
+		return ErrVersion
+	}
+	return nil
+}
+
+// Print the output for ErrHelp or ErrVersion and exit; return false
+// for other errors, which are left to the caller.
+func (spec *Spec) handleAutoHelp(err error) bool {
+	var h *helpRequest
+	switch {
+	case errors.As(err, &h) && h.short:
+		spec.PrintShortUsage()
+	case errors.As(err, &h):
+		s, _ := spec.OptionHelp(h.topic)
+		fmt.Fprint(spec.outw(), s)
+	case errors.Is(err, ErrHelp):
+		spec.PrintUsage()
+	case errors.Is(err, ErrVersion):
+		fmt.Fprint(spec.outw(), spec.VersionString())
+	default:
+		return false
+	}
+	spec.terminate(spec.exit_policy.Help)
+	return true
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,148 @@
+package options
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAutoHelp(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    @version 1.2.3
+    --
+    !root=    -r,--root=                  Root dir
+    verbose   -v,--verbose                Verbose
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = spec.Interpret([]string{"tool", "--help"}, []string{}); err == nil {
+		t.Fatal("expected an error without auto help")
+	}
+
+	spec.SetAutoHelp(true)
+
+	tests := []struct {
+		args []string
+		want error
+	}{
+		{[]string{"tool", "-h"}, ErrHelp},
+		{[]string{"tool", "-v", "--help"}, ErrHelp},
+		{[]string{"tool", "--version"}, ErrVersion},
+		{[]string{"tool", "--version", "--help"}, ErrVersion},
+		{[]string{"tool", "--help=root"}, ErrHelp},
+		{[]string{"tool", "--help=nope"}, nil},
+		{[]string{"tool", "--version=1"}, nil},
+		{[]string{"tool", "-r", "--help"}, nil},
+	}
+
+	for _, tc := range tests {
+		_, err := spec.Interpret(tc.args, []string{})
+		if tc.want == nil {
+			if errors.Is(err, ErrHelp) || errors.Is(err, ErrVersion) {
+				t.Errorf("%v: unexpected %v", tc.args, err)
+			}
+			continue
+		}
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%v: expected %v, saw %v", tc.args, tc.want, err)
+		}
+	}
+}
+
+func TestAutoHelpDeclared(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    help      -?,--help                   Show help
+    host=     -h,--host=                  Host
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetAutoHelp(true)
+
+	if _, err = spec.Interpret([]string{"tool", "-?"}, []string{}); !errors.Is(err, ErrHelp) {
+		t.Errorf("-?: expected help, saw %v", err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-h", "example.com"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("host"); v != "example.com" {
+		t.Errorf("bad host %q", v)
+	}
+
+	// no version in the spec
+	if _, err = spec.Interpret([]string{"tool", "--version"}, []string{}); errors.Is(err, ErrVersion) {
+		t.Error("--version recognized without a version")
+	}
+}
+
+func TestAutoHelpExit(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    !root=    -r,--root=                  Root dir
+    verbose   -v,--verbose                Verbose
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetAutoHelp(true)
+	spec.SetVersion("2.0")
+
+	saved := exit
+	defer func() { exit = saved }()
+	exit = func(code int) { panic(&ExitError{code}) }
+
+	run := func(args ...string) (string, int) {
+		var out, errs strings.Builder
+		spec.SetOutput(&out, &errs)
+
+		code := -1
+		func() {
+			defer func() {
+				if e, ok := recover().(*ExitError); ok {
+					code = e.Code
+				}
+			}()
+			spec.MustInterpret(append([]string{"tool"}, args...), []string{})
+		}()
+		return out.String() + errs.String(), code
+	}
+
+	if out, code := run("--help"); code != 0 || !strings.HasPrefix(out, "usage: tool") {
+		t.Errorf("help: exit %d, output:\n%s", code, out)
+	}
+	if out, code := run("-h"); code != 0 || out != spec.ShortUsage()+"\n" || strings.Contains(out, "Verbose") {
+		t.Errorf("-h: exit %d, output:\n%s", code, out)
+	}
+	if out, code := run("--help"); code != 0 || !strings.Contains(out, "Verbose") {
+		t.Errorf("--help: exit %d, output:\n%s", code, out)
+	}
+	if out, code := run("--help=-r"); code != 0 || !strings.HasPrefix(out, "root\n  Flags:       -r, --root\n") {
+		t.Errorf("help on -r: exit %d, output:\n%s", code, out)
+	}
+	if out, code := run("--help=nope"); code != 1 || !strings.HasPrefix(out, "error: Invalid option: nope was not recognized") {
+		t.Errorf("help on nope: exit %d, output:\n%s", code, out)
+	}
+	if out, code := run("--version"); code != 0 || !strings.Contains(out, "version 2.0") {
+		t.Errorf("version: exit %d, output:\n%s", code, out)
+	}
+	if out, code := run(); code != 1 || !strings.HasPrefix(out, "error:") {
+		t.Errorf("missing root: exit %d, output:\n%s", code, out)
+	}
+}
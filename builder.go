@@ -0,0 +1,194 @@
+// builder.go - Programmatic spec construction
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Builds a spec from code rather than text, e.g. for options that
+// plugins provide at run time:
+//
+//	spec, err := options.NewSpec().
+//	    Usage("usage: tool [options] command").
+//	    Flag("verbose", "-v", "--verbose", "Show more info").
+//	    Option("root", "/var", "-r", "--root=DIR", "Data root").
+//	    Command("exec", "exec", "x", "Run a program").
+//	    Build()
+//
+// The builder writes the equivalent spec text (see String()) and
+// parses it, so the result (including its usage text) is the same as
+// that of Parse().
+type Builder struct {
+	usage    []string
+	opts     []specLine
+	envs     []specLine
+	cmds     []specLine
+	appendix []string
+	err      error
+
+	// the option group of the last entry added from a spec
+	group string
+}
+
+// A line of the options, environment or commands section: an entry
+// with its name, spellings and description, or a verbatim line such as
+// "[requires tls] cert".
+type specLine struct {
+	name      string
+	spellings string
+	help      string
+	raw       string
+}
+
+// Start building a spec
+func NewSpec() *Builder {
+	return &Builder{}
+}
+
+// Add lines to the usage section; the first is conventionally
+// "usage: prog ...".
+func (b *Builder) Usage(lines ...string) *Builder {
+	b.usage = append(b.usage, lines...)
+	return b
+}
+
+// Add flag 'name' with the spellings in 'args' followed by its
+// description, e.g. Flag("verbose", "-v", "--verbose", "Show more
+// info"). Spellings without a leading '-' are environment variables.
+// The name takes the same prefixes as in the spec text, e.g. "+name"
+// or "name@cmd".
+func (b *Builder) Flag(name string, args ...string) *Builder {
+	b.opts = b.entry(b.opts, name, args)
+	return b
+}
+
+// Add option 'name' with default 'def' (which may be empty and take a
+// ":type" suffix as in the spec text), the spellings in 'args' and its
+// description, e.g. Option("root", "/var", "-r", "--root=DIR", "Data
+// root"). The name takes the same prefixes as in the spec text, e.g.
+// "!name" for a required option.
+func (b *Builder) Option(name, def string, args ...string) *Builder {
+	b.opts = b.entry(b.opts, name+"="+def, args)
+	return b
+}
+
+// Add environment variable 'name' with default 'def', the variables in
+// 'args' and its description, as in the environment section of the
+// spec text.
+func (b *Builder) Env(name, def string, args ...string) *Builder {
+	b.envs = b.entry(b.envs, name+"="+def, args)
+	return b
+}
+
+// Add command 'name' with the aliases in 'args' followed by its
+// description; with no aliases the command is spelled as 'name'.
+func (b *Builder) Command(name string, args ...string) *Builder {
+	if len(args) == 1 {
+		args = []string{name, args[0]}
+	}
+	b.cmds = b.entry(b.cmds, name, args)
+	return b
+}
+
+// Add lines to the appendix
+func (b *Builder) Appendix(lines ...string) *Builder {
+	b.appendix = append(b.appendix, lines...)
+	return b
+}
+
+// Return the spec built so far or the first error in its construction
+func (b *Builder) Build() (*Spec, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return Parse(b.String())
+}
+
+// Return the spec text built so far
+func (b *Builder) String() string {
+	w0, w1 := 0, 0
+	for _, s := range [][]specLine{b.opts, b.envs, b.cmds} {
+		for _, e := range s {
+			w0 = max(w0, displayWidth(e.name))
+			w1 = max(w1, displayWidth(e.spellings))
+		}
+	}
+
+	// Parse() takes the indent of the first line as that of the spec
+	var s strings.Builder
+	first := true
+	for _, l := range b.usage {
+		if first && len(strings.TrimLeft(l, " \t")) > 0 {
+			l, first = strings.TrimLeft(l, " \t"), false
+		}
+		fmt.Fprintf(&s, "%s\n", l)
+	}
+	for _, sect := range [][]specLine{b.opts, b.envs, b.cmds} {
+		s.WriteString("--\n")
+		for _, e := range sect {
+			if len(e.raw) > 0 {
+				fmt.Fprintf(&s, "%s\n", e.raw)
+				continue
+			}
+
+			pad := strings.Repeat(" ", w0+w1+4)
+			for i, l := range strings.Split(e.help, "\n") {
+				switch {
+				case i == 0:
+					fmt.Fprintf(&s, "%s  %s  %s\n", padRight(e.name, w0), padRight(e.spellings, w1), l)
+				case len(l) == 0:
+					s.WriteString("\n")
+				default:
+					fmt.Fprintf(&s, "%s%s\n", pad, l)
+				}
+			}
+		}
+	}
+	s.WriteString("--\n")
+	for _, l := range b.appendix {
+		fmt.Fprintf(&s, "%s\n", l)
+	}
+	return s.String()
+}
+
+// Append the entry for 'name' with the spellings and description in
+// 'args' to 'list'; an undescribed entry is left out of the usage.
+func (b *Builder) entry(list []specLine, name string, args []string) []specLine {
+	if b.err != nil {
+		return list
+	}
+
+	if len(args) < 2 {
+		b.err = fmt.Errorf("Invalid spec: %s needs a spelling and a description", name)
+		return list
+	}
+
+	if strings.ContainsAny(name, " \t\n") {
+		b.err = fmt.Errorf("Invalid spec: bad name %q", name)
+		return list
+	}
+
+	words := args[:len(args)-1]
+	for _, w := range words {
+		if len(w) == 0 || strings.ContainsAny(w, " \t\n,") {
+			b.err = fmt.Errorf("Invalid spec: bad spelling %q of %s", w, name)
+			return list
+		}
+	}
+
+	help := strings.TrimSpace(args[len(args)-1])
+	if len(help) == 0 {
+		help = "-"
+	}
+	return append(list, specLine{name: name, spellings: strings.Join(words, ","), help: help})
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
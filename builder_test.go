@@ -0,0 +1,71 @@
+package options
+
+import (
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	spec, err := NewSpec().
+		Usage("usage: tool [options] command", "A tool.").
+		Flag("verbose", "-v", "--verbose", "Show more info").
+		Option("!root", "/var", "-r", "--root=DIR", "TOOL_ROOT", "Data root\nwhere everything lives").
+		Flag("force@exec", "-f", "--force", "Force it").
+		Env("home", "", "TOOL_HOME", "Home directory").
+		Command("exec", "exec", "x", "Run a program").
+		Command("list", "List things").
+		Appendix("See the manual.").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := Parse(`
+    usage: tool [options] command
+    A tool.
+    --
+    verbose     -v,--verbose             Show more info
+    !root=/var  -r,--root=DIR,TOOL_ROOT  Data root
+                                         where everything lives
+    force@exec  -f,--force               Force it
+    --
+    home=       TOOL_HOME                Home directory
+    --
+    exec        exec,x                   Run a program
+    list        list                     List things
+    --
+    See the manual.
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if spec.Usage() != text.Usage() {
+		t.Errorf("usage mismatch:\n%s\n--- expected ---\n%s", spec.Usage(), text.Usage())
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-r", "/x", "x", "-f"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Command != "exec" {
+		t.Errorf("bad command %q", opts.Command)
+	}
+	if v, _ := opts.Get("root"); v != "/x" {
+		t.Errorf("bad root %q", v)
+	}
+	if _, err = spec.Interpret([]string{"tool"}, []string{}); err == nil {
+		t.Error("expected a missing root error")
+	}
+
+	bad := []*Builder{
+		NewSpec().Flag("verbose", "Verbose"),
+		NewSpec().Flag("verbose", "-v --verbose", "Verbose"),
+		NewSpec().Option("my root", "", "--root=", "Root"),
+		NewSpec().Option("root", "", "--root=", "-r,", "Root"),
+	}
+	for i, b := range bad {
+		if _, err = b.Build(); err == nil {
+			t.Errorf("%d: expected an error", i)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// buildinfo.go - Version details from the Go build information
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Return the build information embedded in the binary; replaced by
+// the tests.
+var readBuildInfo = debug.ReadBuildInfo
+
+// Complete the version information with the build information the Go
+// toolchain embeds in the binary if 'on' is true: Version() falls back
+// to the version of the main module when the spec doesn't declare one,
+// and VersionString() adds the VCS revision and the commit time. This
+// saves every project the -ldflags plumbing for "--version".
+func (spec *Spec) SetBuildInfo(on bool) {
+	spec.build_info = on
+}
+
+// Return the version of the main module, if known
+func buildVersion() string {
+	bi, ok := readBuildInfo()
+	if !ok || bi.Main.Version == "(devel)" {
+		return ""
+	}
+	return bi.Main.Version
+}
+
+// Return the lines describing the VCS state of the build
+func buildLines() []string {
+	bi, ok := readBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	var rev, when, modified string
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value
+		case "vcs.time":
+			when = s.Value
+		case "vcs.modified":
+			if s.Value == "true" {
+				modified = " (modified)"
+			}
+		}
+	}
+
+	var rv []string
+	if len(rev) > 0 {
+		rv = append(rv, fmt.Sprintf("Revision: %s%s", rev, modified))
+	}
+	if len(when) > 0 {
+		rv = append(rv, "Built: "+when)
+	}
+	if len(bi.GoVersion) > 0 {
+		rv = append(rv, "Go: "+bi.GoVersion)
+	}
+	return rv
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,56 @@
+// main.go - Report the differences between two option specs
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+// specdiff compares two files holding option spec strings and prints
+// the changes from the old spec to the new one. It exits with status
+// 1 if any change is breaking, so it can guard releases in CI.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/opencoff/go-options"
+)
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprintf(os.Stderr, "Usage: %s OLD-SPEC NEW-SPEC\n", os.Args[0])
+		os.Exit(2)
+	}
+
+	old := load(os.Args[1])
+	spec := load(os.Args[2])
+
+	breaking := false
+	for _, c := range spec.Diff(old) {
+		fmt.Println(c)
+		breaking = breaking || c.Breaking
+	}
+
+	if breaking {
+		os.Exit(1)
+	}
+}
+
+func load(fn string) *options.Spec {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(2)
+	}
+
+	spec, err := options.Parse(string(b))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", fn, err)
+		os.Exit(2)
+	}
+	return spec
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,52 @@
+// cmdinfo.go - Command listing
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"strings"
+)
+
+// A command declared in the spec
+type CommandInfo struct {
+	// the canonical name, as in Options.Command
+	Name string
+
+	// the spellings accepted on the command line, e.g. ["sh", "shell"]
+	Aliases []string
+
+	// the description; empty for an undocumented ("-") command
+	Help string
+}
+
+// Return the commands of the spec in declaration order
+func (spec *Spec) Commands() []CommandInfo {
+	rv := make([]CommandInfo, 0, len(spec.cmdlist))
+	for _, c := range spec.cmdlist {
+		rv = append(rv, CommandInfo{
+			Name:    c.name,
+			Aliases: append([]string{}, c.aliases...),
+			Help:    c.help,
+		})
+	}
+	return rv
+}
+
+// Return the documented commands as an aligned block of lines, e.g.
+// "  sh, shell   Open a shell", for the unknown command error.
+func (spec *Spec) commandBlock() string {
+	f := &usageFormatter{width: spec.usageWidth()}
+	for _, c := range spec.cmdlist {
+		if len(c.usage) > 0 {
+			f.add(2, strings.Join(c.aliases, ", "), c.help)
+		}
+	}
+	return strings.Join(f.flush(), "\n")
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,215 @@
+// cmdline.go - Interpret command lines given as text
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Read command lines from 'r', one per line, and interpret each of
+// them against the spec and 'environ'. The words of a line are the
+// arguments that follow the program name and are split with the spec's
+// splitter; a line that can't be split is reported as a parse error.
+// Blank lines and lines starting with '#' are skipped.
+//
+// 'fn' is called for every line with its line number (starting at 1)
+// and the result of Interpret(); a parse error is passed to 'fn' rather
+// than stopping the loop. A non-nil error returned by 'fn' stops the
+// loop and is returned to the caller. This is useful for REPLs, batch
+// job files and chat-ops bots.
+func (spec *Spec) InterpretReader(r io.Reader, environ []string, fn func(line int, o *Options, err error) error) error {
+	sc := bufio.NewScanner(r)
+
+	n := 0
+	for sc.Scan() {
+		n++
+
+		line := strings.TrimSpace(sc.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		o, err := spec.InterpretString(line, environ)
+		if err = fn(n, o, err); err != nil {
+			return err
+		}
+	}
+
+	return sc.Err()
+}
+
+// A function that splits a command line into words
+type Splitter func(cmdline string) ([]string, error)
+
+// Set the function used by InterpretString() and InterpretReader() to
+// split command lines into words; the default is SplitPOSIX. Use
+// SplitWindows for command strings that originate on Windows.
+func (spec *Spec) SetSplitter(fn Splitter) {
+	spec.splitter = fn
+}
+
+// Split 'cmdline' into words using POSIX shell quoting rules (or the
+// splitter set by SetSplitter()) and interpret the words as the
+// arguments following the program name. This lets a spec be reused
+// for command strings embedded in config files. No expansion of
+// variables or globs is done.
+func (spec *Spec) InterpretString(cmdline string, environ []string) (*Options, error) {
+	split := spec.splitter
+	if split == nil {
+		split = SplitPOSIX
+	}
+
+	words, err := split(cmdline)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append([]string{spec.prog}, words...)
+	return spec.Interpret(args, environ)
+}
+
+// Split 'cmdline' into words the way a POSIX shell does: words are
+// separated by unquoted blanks; single quotes preserve everything up to
+// the closing quote; in double quotes a backslash escapes only '$', '`',
+// '"', '\' and newline; outside quotes a backslash escapes any
+// character.
+func SplitPOSIX(cmdline string) ([]string, error) {
+	var words []string
+	var w strings.Builder
+
+	inword := false
+	s := cmdline
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inword {
+				words = append(words, w.String())
+				w.Reset()
+				inword = false
+			}
+			continue
+
+		case c == '\\':
+			if i+1 >= len(s) {
+				return nil, fmt.Errorf("Invalid command line: trailing backslash")
+			}
+			i++
+			if s[i] == '\n' {
+				// a line continuation doesn't start a word
+				continue
+			}
+			w.WriteByte(s[i])
+
+		case c == '\'':
+			j := strings.IndexByte(s[i+1:], '\'')
+			if j < 0 {
+				return nil, fmt.Errorf("Invalid command line: unterminated single quote")
+			}
+			w.WriteString(s[i+1 : i+1+j])
+			i += j + 1
+
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					switch s[i+1] {
+					case '$', '`', '"', '\\':
+						i++
+					case '\n':
+						i++
+						continue
+					}
+				}
+				w.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("Invalid command line: unterminated double quote")
+			}
+
+		default:
+			w.WriteByte(c)
+		}
+		inword = true
+	}
+
+	if inword {
+		words = append(words, w.String())
+	}
+	return words, nil
+}
+
+// Split 'cmdline' into words following the rules of the Windows
+// CommandLineToArgvW function: words are separated by unquoted blanks;
+// double quotes group blanks into a word and a doubled quote inside a
+// quoted region is a literal quote; 2n backslashes followed by a quote
+// yield n backslashes and a quote delimiter, 2n+1 backslashes followed
+// by a quote yield n backslashes and a literal quote; backslashes not
+// followed by a quote are literal.
+func SplitWindows(cmdline string) ([]string, error) {
+	var words []string
+	var w strings.Builder
+
+	s := cmdline
+	for {
+		for len(s) > 0 && (s[0] == ' ' || s[0] == '\t' || s[0] == '\n' || s[0] == '\r') {
+			s = s[1:]
+		}
+		if len(s) == 0 {
+			return words, nil
+		}
+
+		inquote := false
+		nslash := 0
+	scan:
+		for ; len(s) > 0; s = s[1:] {
+			c := s[0]
+			switch c {
+			case ' ', '\t', '\n', '\r':
+				if !inquote {
+					break scan
+				}
+			case '\\':
+				nslash++
+				continue
+			case '"':
+				w.WriteString(strings.Repeat("\\", nslash/2))
+				if nslash%2 == 1 {
+					// escaped quote
+					nslash = 0
+					w.WriteByte('"')
+					continue
+				}
+				nslash = 0
+				if inquote && len(s) > 1 && s[1] == '"' {
+					// doubled quote in a quoted region
+					w.WriteByte('"')
+					s = s[1:]
+					continue
+				}
+				inquote = !inquote
+				continue
+			}
+
+			w.WriteString(strings.Repeat("\\", nslash))
+			nslash = 0
+			w.WriteByte(c)
+		}
+
+		w.WriteString(strings.Repeat("\\", nslash))
+		words = append(words, w.String())
+		w.Reset()
+	}
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,174 @@
+package options
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestInterpretReader(t *testing.T) {
+	spec, err := Parse(`
+    usage: bot [options] <command> <args>...
+    --
+    verbose   -v,--verbose                Show more info
+    root=     -r,--root=                  Root dir
+    --
+    --
+    deploy    deploy                      Deploy a service
+    status    status                      Show status
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := `
+# a comment
+-v deploy web
+  -r /tmp status
+--bogus status
+deploy db
+`
+
+	var cmds []string
+	var bad []int
+	err = spec.InterpretReader(strings.NewReader(in), []string{}, func(n int, o *Options, err error) error {
+		if err != nil {
+			bad = append(bad, n)
+			return nil
+		}
+		cmds = append(cmds, strings.Join(o.Args, " "))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(cmds, ",") != "deploy web,status,deploy db" {
+		t.Errorf("unexpected commands: %q", cmds)
+	}
+	if len(bad) != 1 || bad[0] != 5 {
+		t.Errorf("expected an error on line 5, saw %v", bad)
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err = spec.InterpretReader(strings.NewReader(in), []string{}, func(int, *Options, error) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Errorf("expected the callback error after one line; saw %v after %d", err, n)
+	}
+}
+
+func TestSplitPOSIX(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"  a  b\tc ", []string{"a", "b", "c"}},
+		{`'a b' "c d"`, []string{"a b", "c d"}},
+		{`a\ b`, []string{"a b"}},
+		{`"a\"b" 'c\d'`, []string{`a"b`, `c\d`}},
+		{`"\$x \y"`, []string{`$x \y`}},
+		{`x''y ""`, []string{"xy", ""}},
+		{"a\\\nb", []string{"ab"}},
+		{"a \\\n b", []string{"a", "b"}},
+	}
+
+	for _, tc := range tests {
+		got, err := SplitPOSIX(tc.in)
+		if err != nil {
+			t.Errorf("%q: %s", tc.in, err)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(tc.want, "|") || len(got) != len(tc.want) {
+			t.Errorf("%q: expected %q, saw %q", tc.in, tc.want, got)
+		}
+	}
+
+	for _, bad := range []string{`'abc`, `"abc`, `abc\`} {
+		if _, err := SplitPOSIX(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestInterpretString(t *testing.T) {
+	spec, err := Parse(`
+    usage: bot [options] <command> <args>...
+    --
+    root=     -r,--root=                  Root dir
+    --
+    --
+    deploy    deploy                      Deploy a service
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := spec.InterpretString(`-r "/my dir" deploy 'web app'`, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := o.Get("root"); v != "/my dir" {
+		t.Errorf("expected '/my dir', saw %q", v)
+	}
+	if len(o.Args) != 2 || o.Args[1] != "web app" {
+		t.Errorf("bad args: %q", o.Args)
+	}
+}
+
+func TestSplitWindows(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{`a b  c`, []string{"a", "b", "c"}},
+		{`"a b" c`, []string{"a b", "c"}},
+		{`C:\dir\file.txt`, []string{`C:\dir\file.txt`}},
+		{`a\\\"b`, []string{`a\"b`}},
+		{`"a\\" b`, []string{`a\`, "b"}},
+		{`a\\\\"b c"`, []string{`a\\b c`}},
+		{`"say ""hi"""`, []string{`say "hi"`}},
+		{`"" x`, []string{"", "x"}},
+		{`"open`, []string{"open"}},
+	}
+
+	for _, tc := range tests {
+		got, err := SplitWindows(tc.in)
+		if err != nil {
+			t.Errorf("%q: %s", tc.in, err)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(tc.want, "|") || len(got) != len(tc.want) {
+			t.Errorf("%q: expected %q, saw %q", tc.in, tc.want, got)
+		}
+	}
+
+	spec, err := Parse(`
+    usage: tool
+    --
+    root=     -r,--root=                  Root dir
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec.SetSplitter(SplitWindows)
+	o, err := spec.InterpretString(`-r "C:\Program Files\tool"`, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := o.Get("root"); v != `C:\Program Files\tool` {
+		t.Errorf("bad root: %q", v)
+	}
+}
@@ -0,0 +1,62 @@
+// color.go - Colored usage
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"os"
+)
+
+// When the usage is colored; see SetColor()
+type ColorMode int
+
+const (
+	ColorNever ColorMode = iota
+	ColorAuto
+	ColorAlways
+)
+
+// The ANSI (SGR) escape sequences of the colored usage
+const (
+	sgrBold     = "\x1b[1m"
+	sgrRequired = "\x1b[1;33m"
+	sgrDim      = "\x1b[2m"
+	sgrReset    = "\x1b[0m"
+)
+
+// Set when FormatUsage() colors the usage: the option and command
+// names are bold, the required options are highlighted and the
+// defaults are dimmed. ColorAuto colors it only if it is printed to a
+// terminal and $NO_COLOR isn't set: PrintUsageWithError() checks the
+// error output, the rest the output (see IsTerminal()). The default is
+// ColorNever. Coloring the usage implies SetFormatUsage().
+func (spec *Spec) SetColor(mode ColorMode) {
+	spec.color = mode
+}
+
+// Return true if the usage is to be colored; 'tty' tells whether it
+// is printed to a terminal.
+func (spec *Spec) useColor(tty bool) bool {
+	switch spec.color {
+	case ColorAlways:
+		return true
+	case ColorAuto:
+		return len(os.Getenv("NO_COLOR")) == 0 && tty
+	}
+	return false
+}
+
+// Return 's' in the style 'sgr' if 'color' is true
+func paint(color bool, sgr, s string) string {
+	if !color || len(s) == 0 {
+		return s
+	}
+	return sgr + s + sgrReset
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,77 @@
+package options
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestColor(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    verbose   -v,--verbose                Show more info
+    !root=    -r,--root=DIR               Root directory
+    out=a.out --out=FILE                  Output file; the name of the file
+                                          the results are written to
+    --
+    --
+    exec      exec                        Run a command
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetUsageWidth(60)
+	plain := spec.FormatUsage()
+
+	spec.SetColor(ColorAlways)
+	u := spec.FormatUsage()
+	for _, s := range []string{sgrBold + "-v, --verbose" + sgrReset, sgrRequired + "-r DIR, --root=DIR" + sgrReset, sgrBold + "exec" + sgrReset} {
+		if !strings.Contains(u, s) {
+			t.Errorf("expected %q in\n%s", s, u)
+		}
+	}
+	if !strings.Contains(u, sgrDim+"(default:") {
+		t.Errorf("default not dimmed:\n%s", u)
+	}
+
+	// the colors don't change the layout
+	re := regexp.MustCompile("\x1b\\[[0-9;]*m")
+	if s := re.ReplaceAllString(u, ""); s != plain {
+		t.Errorf("colored layout differs:\n%s\nvs\n%s", s, plain)
+	}
+	if spec.usageText(false) != u {
+		t.Errorf("the colored usage is not printed")
+	}
+
+	t.Setenv("NO_COLOR", "")
+	spec.SetColor(ColorAuto)
+	spec.SetTerminal(false, false)
+	if spec.FormatUsage() != plain {
+		t.Errorf("colored usage without a terminal")
+	}
+	spec.SetTerminal(false, true)
+	if spec.FormatUsage() != u {
+		t.Errorf("usage not colored on a terminal")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if spec.FormatUsage() != plain {
+		t.Errorf("colored usage with NO_COLOR")
+	}
+
+	// the error output is checked on its own
+	t.Setenv("NO_COLOR", "")
+	var stdout, stderr strings.Builder
+	spec.SetOutput(&stdout, &stderr)
+	spec.SetExit(func(int) {})
+	spec.PrintUsage()
+	spec.PrintUsageWithError(errors.New("oops"))
+	if stdout.String() != u+"\n" {
+		t.Errorf("usage not colored on a terminal:\n%s", stdout.String())
+	}
+	if re.MatchString(stderr.String()) {
+		t.Errorf("colored usage on a redirected error output:\n%s", stderr.String())
+	}
+}
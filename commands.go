@@ -0,0 +1,90 @@
+// commands.go - Required and default commands
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Make Interpret() use command 'cmd' (a name or alias) when the command
+// line has none, like a "*default=cmd" line in the commands section.
+// Its arguments are the ones that remain on the command line. An empty
+// 'cmd' removes the default.
+func (spec *Spec) SetDefaultCommand(cmd string) error {
+	if len(cmd) > 0 {
+		c := spec.findCommand(cmd)
+		if c == nil {
+			return fmt.Errorf("Invalid default command: %s is not a known command", cmd)
+		}
+		cmd = c.name
+	}
+	spec.default_cmd = cmd
+	return nil
+}
+
+// Make a command mandatory, like a "*required" line in the commands
+// section: Interpret() fails when the command line has none and there
+// is no default command.
+func (spec *Spec) SetCommandRequired(on bool) {
+	spec.cmd_required = on
+}
+
+// Parse a "*default=cmd" or "*required" line of the commands section;
+// return false if 'line' is neither.
+func (spec *Spec) parseCommandRule(line string) bool {
+	if line == "*required" {
+		spec.cmd_required = true
+		return true
+	}
+	if nm, ok := strings.CutPrefix(line, "*default="); ok && len(nm) > 0 {
+		spec.default_cmd = nm
+		return true
+	}
+	return false
+}
+
+// Verify the default command once all the commands are declared
+func (spec *Spec) checkDefaultCommand() error {
+	if len(spec.default_cmd) == 0 {
+		return nil
+	}
+	c := spec.findCommand(spec.default_cmd)
+	if c == nil {
+		return fmt.Errorf("Invalid default command: %s is not a known command", spec.default_cmd)
+	}
+	spec.default_cmd = c.name
+	return nil
+}
+
+// Supply the default command when the command line has none, or fail
+// if a command is required.
+func (spec *Spec) applyDefaultCommand(opts *Options) error {
+	if len(opts.Command) > 0 || len(spec.cmdlist) == 0 {
+		return nil
+	}
+
+	if len(spec.default_cmd) > 0 {
+		opts.Command = spec.default_cmd
+		opts.Args = append([]string{opts.Command}, opts.Args...)
+		spec.debug("default command", "command", opts.Command)
+		return nil
+	}
+
+	if spec.cmd_required {
+		names := make([]string, 0, len(spec.cmdlist))
+		for _, c := range spec.cmdlist {
+			names = append(names, c.name)
+		}
+		return spec.errorf(MsgMissingCommand, orList(names))
+	}
+	return nil
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
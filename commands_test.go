@@ -0,0 +1,113 @@
+package options
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDefaultCommand(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] [command]
+    --
+    verbose     -v,--verbose           Verbose
+    port@serve= -p,--port=             Listen port
+    --
+    --
+    serve       serve,s                Run the server
+    check       check                  Check the config
+    *
+    *default=s
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	opts, err := spec.Interpret([]string{"tool", "-v", "-p", "80", "a"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Command != "serve" {
+		t.Errorf("expected the default command, saw %q", opts.Command)
+	}
+	if s := strings.Join(opts.Args, ","); s != "serve,a" {
+		t.Errorf("bad args: %s", s)
+	}
+
+	opts, err = spec.Interpret([]string{"tool", "check", "x"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Command != "check" {
+		t.Errorf("bad command %q", opts.Command)
+	}
+
+	spec.SetUsageWidth(60)
+	if u := spec.FormatUsage(); !strings.Contains(u, "Run the server (default)") {
+		t.Errorf("default command not marked:\n%s", u)
+	}
+
+	if err = spec.SetDefaultCommand("nope"); err == nil {
+		t.Error("expected an error for an unknown default command")
+	}
+	if err = spec.SetDefaultCommand(""); err != nil {
+		t.Fatal(err)
+	}
+	opts, err = spec.Interpret([]string{"tool", "a"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.Command) > 0 {
+		t.Errorf("expected no command, saw %q", opts.Command)
+	}
+
+	spec.SetCommandRequired(true)
+	_, err = spec.Interpret([]string{"tool", "-v"}, []string{})
+	if err == nil || err.Error() != "Missing command: expected serve or check" {
+		t.Errorf("bad error: %v", err)
+	}
+	if !errors.Is(err, ErrMissingRequired) {
+		t.Errorf("expected a missing required error")
+	}
+
+	_, err = Parse(`
+    usage: tool
+    --
+    --
+    --
+    run   run     Run it
+    *default=walk
+    --
+    `)
+	if err == nil {
+		t.Error("expected an error for an unknown default command")
+	}
+}
+
+func TestRequiredCommand(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [command]
+    --
+    --
+    --
+    run   run     Run it
+    *required
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	if _, err = spec.Interpret([]string{"tool"}, []string{}); err == nil {
+		t.Error("expected a missing command error")
+	}
+	if _, err = spec.Interpret([]string{"tool", "run"}, []string{}); err != nil {
+		t.Error(err)
+	}
+	if strings.Contains(spec.Usage(), "*required") {
+		t.Errorf("rule shown in usage:\n%s", spec.Usage())
+	}
+}
@@ -0,0 +1,97 @@
+// compare.go - Compare parsed options
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A difference in the effective value of one option between two
+// Options. 'Old' and 'New' hold all the values of the option; nil
+// means the option had no value (it was neither set nor defaulted).
+type Change struct {
+	Name string
+	Old  []string
+	New  []string
+}
+
+func (c Change) String() string {
+	show := func(v []string) string {
+		if v == nil {
+			return "<unset>"
+		}
+		return strings.Join(v, ",")
+	}
+	return fmt.Sprintf("%s: %s -> %s", c.Name, show(c.Old), show(c.New))
+}
+
+// Return the options whose effective values differ between opts and
+// 'other', sorted by option name. Effective values include the spec
+// defaults. This is useful for config hot-reload diffs and in tests.
+func (opts *Options) Diff(other *Options) []Change {
+	var rv []Change
+
+	names := make(map[string]bool)
+	for _, o := range []*Options{opts, other} {
+		for k := range o.options {
+			names[k] = true
+		}
+		for k := range o.defaults {
+			names[k] = true
+		}
+	}
+
+	for nm := range names {
+		a, b := opts.values(nm), other.values(nm)
+		if !sameStrings(a, b) {
+			rv = append(rv, Change{nm, a, b})
+		}
+	}
+
+	sort.Slice(rv, func(i, j int) bool {
+		return rv[i].Name < rv[j].Name
+	})
+	return rv
+}
+
+// Return true if opts and 'other' have the same effective option
+// values, command and arguments.
+func (opts *Options) Equal(other *Options) bool {
+	if opts.Command != other.Command || !sameStrings(opts.Args, other.Args) {
+		return false
+	}
+	return len(opts.Diff(other)) == 0
+}
+
+// Return all the effective values of 'nm'
+func (opts *Options) values(nm string) []string {
+	if v, ok := opts.optionv[nm]; ok {
+		return v
+	}
+	if v, ok := opts.defaults[nm]; ok {
+		return []string{v}
+	}
+	return nil
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) || (a == nil) != (b == nil) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
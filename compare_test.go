@@ -0,0 +1,64 @@
+package options
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	spec, err := Parse(`
+    usage: haraway <flags>... <command> <args>...
+    --
+    root=XYZ  -r,--root=                  Path to the haraway data root
+    include=  -I=                         Include dirs
+    verbose   -v,--verbose                Show more info
+    --
+    --
+    exec      c,exec                      Execute a command
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := spec.Interpret([]string{"haraway", "-v", "-I", "a", "exec", "ls"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := spec.Interpret([]string{"haraway", "-r", "XYZ", "-v", "-I", "a", "exec", "ls"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !a.Equal(b) {
+		t.Errorf("explicit default must compare equal: %v", a.Diff(b))
+	}
+
+	c, err := spec.Interpret([]string{"haraway", "-r", "/x", "-I", "a", "-I", "b", "exec", "ls"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := a.Diff(c)
+	if len(d) != 3 {
+		t.Fatalf("expected 3 changes, saw %v", d)
+	}
+
+	want := []string{
+		"include: a -> a,b",
+		"root: XYZ -> /x",
+		"verbose: true -> <unset>",
+	}
+	for i := range want {
+		if d[i].String() != want[i] {
+			t.Errorf("change %d: expected %q, saw %q", i, want[i], d[i].String())
+		}
+	}
+
+	e, err := spec.Interpret([]string{"haraway", "-v", "-I", "a", "exec", "ps"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Equal(e) {
+		t.Error("different args must not compare equal")
+	}
+}
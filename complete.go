@@ -0,0 +1,170 @@
+// complete.go - Runtime shell completion
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The hidden command that the completion scripts use to call back
+// into the program. MustInterpret() answers it by printing the
+// candidates for the remaining words, one per line, and exiting.
+const CompleteCmd = "__complete"
+
+// A function that returns dynamic completion candidates for the
+// partial word 'prefix'
+type Completer func(prefix string) []string
+
+// Register a dynamic completer for the value of option 'nm' or, if
+// 'nm' names a command, for the arguments following that command.
+func (spec *Spec) SetCompleter(nm string, fn Completer) {
+	if spec.completers == nil {
+		spec.completers = make(map[string]Completer)
+	}
+	spec.completers[nm] = fn
+}
+
+// Return the completion candidates for the command line 'words' (the
+// arguments following the program name); the last word is the one
+// being completed and may be empty.
+func (spec *Spec) Complete(words []string) []string {
+	cur := ""
+	if n := len(words); n > 0 {
+		cur = words[n-1]
+		words = words[:n-1]
+	}
+
+	want := ""
+	for i, w := range words {
+		if len(want) > 0 {
+			want = ""
+			continue
+		}
+
+		if w == "--" {
+			return nil
+		}
+
+		if strings.HasPrefix(w, "-") && len(w) > 1 {
+			if strings.Contains(w, "=") {
+				continue
+			}
+			if nm, ok := spec.options[w]; ok && !spec.flags[nm] {
+				want = nm
+			}
+			continue
+		}
+
+		if cmd, ok := spec.commands[w]; ok {
+			if sub := spec.subspecs[cmd]; sub != nil {
+				return sub.Complete(append(words[i+1:len(words):len(words)], cur))
+			}
+			return spec.completeWith(cmd, cur, "")
+		}
+	}
+
+	if len(want) > 0 {
+		return spec.completeWith(want, cur, "")
+	}
+
+	if strings.HasPrefix(cur, "-") {
+		if i := strings.Index(cur, "="); i > 0 {
+			if nm, ok := spec.options[cur[:i]]; ok {
+				return spec.completeWith(nm, cur[i+1:], cur[:i+1])
+			}
+			return nil
+		}
+
+		var rv []string
+		for f := range spec.options {
+			if strings.HasPrefix(f, cur) {
+				rv = append(rv, f)
+			}
+		}
+		sort.Strings(rv)
+		return rv
+	}
+
+	var rv []string
+	for c := range spec.commands {
+		if strings.HasPrefix(c, cur) {
+			rv = append(rv, c)
+		}
+	}
+	sort.Strings(rv)
+	return rv
+}
+
+// Return the candidates from the completer registered for 'nm', each
+// prefixed with 'lead'.
+func (spec *Spec) completeWith(nm, cur, lead string) []string {
+	var cands []string
+	if fn, ok := spec.completers[nm]; ok {
+		cands = fn(cur)
+	} else if o := spec.optinfo[nm]; o != nil {
+		for _, c := range o.choices {
+			if strings.HasPrefix(c, cur) {
+				cands = append(cands, c)
+			}
+		}
+	}
+
+	var rv []string
+	for _, s := range cands {
+		rv = append(rv, lead+s)
+	}
+	return rv
+}
+
+// Return a script for 'shell' (bash, zsh or fish) that wires the
+// shell's completion for program 'prog' to the hidden __complete
+// command.
+func (spec *Spec) CompletionHook(shell, prog string) (string, error) {
+	fn := completeFunc(prog)
+
+	switch shell {
+	case "bash":
+		return fmt.Sprintf(`%[1]s() {
+    local IFS=$'\n'
+    COMPREPLY=( $("%[2]s" %[3]s "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null) )
+}
+complete -o default -F %[1]s %[2]s
+`, fn, prog, CompleteCmd), nil
+
+	case "zsh":
+		return fmt.Sprintf(`#compdef %[2]s
+%[1]s() {
+    local -a c
+    c=("${(@f)$("%[2]s" %[3]s "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    compadd -a c
+}
+compdef %[1]s %[2]s
+`, fn, prog, CompleteCmd), nil
+
+	case "fish":
+		return fmt.Sprintf("complete -c %[1]s -f -a '(%[1]s %[2]s (commandline -opc)[2..-1] (commandline -ct))'\n",
+			prog, CompleteCmd), nil
+	}
+
+	return "", fmt.Errorf("Unsupported shell: %s", shell)
+}
+
+// Return the name of the shell function that completes 'prog'
+func completeFunc(prog string) string {
+	return "_" + strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return '_'
+		}
+		return r
+	}, prog) + "_complete"
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,57 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	spec, err := Parse(`
+    usage: haraway <flags>... <command> <args>...
+    --
+    root=     -r,--root=,HARAWAY_ROOT     Path to the haraway data root
+    verbose   -v,--verbose                Show more info
+    --
+    --
+    exec      c,exec                      Execute a command within the haraway sanbox
+    shell     sh,shell                    Open a shell within the haraway sanbox
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec.SetCompleter("root", func(p string) []string {
+		return []string{p + "/a", p + "/b"}
+	})
+	spec.SetCompleter("exec", func(p string) []string {
+		return []string{"ls"}
+	})
+
+	tests := []struct {
+		words []string
+		want  string
+	}{
+		{[]string{"--v"}, "--verbose"},
+		{[]string{""}, "c exec sh shell"},
+		{[]string{"s"}, "sh shell"},
+		{[]string{"-r", "/x"}, "/x/a /x/b"},
+		{[]string{"--root=/y"}, "--root=/y/a --root=/y/b"},
+		{[]string{"-v", "exec", ""}, "ls"},
+		{[]string{"--", ""}, ""},
+	}
+
+	for _, tc := range tests {
+		got := strings.Join(spec.Complete(tc.words), " ")
+		if got != tc.want {
+			t.Errorf("complete %q: expected %q, saw %q", tc.words, tc.want, got)
+		}
+	}
+
+	if _, err := spec.CompletionHook("bash", "haraway"); err != nil {
+		t.Error(err)
+	}
+	if _, err := spec.CompletionHook("csh", "haraway"); err == nil {
+		t.Error("expected error for unsupported shell")
+	}
+}
@@ -0,0 +1,187 @@
+// completion.go - Static shell completion scripts
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Return a self-contained completion script for 'shell' (bash, zsh or
+// fish) generated from the options and commands of the spec. Unlike
+// CompletionHook(), the script doesn't call back into the program: it
+// completes the option spellings, the choices of the options restricted
+// with SetEnum(), file names for the other option values and the
+// command names. This replaces a hand-maintained completion file.
+func (spec *Spec) Completion(shell string) (string, error) {
+	var b strings.Builder
+
+	switch shell {
+	case "bash":
+		spec.bashCompletion(&b)
+	case "zsh":
+		spec.zshCompletion(&b)
+	case "fish":
+		spec.fishCompletion(&b)
+	default:
+		return "", fmt.Errorf("Unsupported shell: %s", shell)
+	}
+	return b.String(), nil
+}
+
+// Return the options that can be given on the command line
+func (spec *Spec) cliOpts() []*optspec {
+	var rv []*optspec
+	for _, o := range spec.optlist {
+		if !o.isenv && len(o.flags) > 0 {
+			rv = append(rv, o)
+		}
+	}
+	return rv
+}
+
+// Return the command names and aliases
+func (spec *Spec) cmdNames() []string {
+	var rv []string
+	for _, c := range spec.cmdlist {
+		rv = append(rv, c.aliases...)
+	}
+	return rv
+}
+
+func (spec *Spec) bashCompletion(b *strings.Builder) {
+	prog := spec.progname()
+
+	var flags []string
+	fmt.Fprintf(b, "%s() {\n", completeFunc(prog))
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("    case \"$prev\" in\n")
+	for _, o := range spec.cliOpts() {
+		flags = append(flags, o.flags...)
+		if spec.flags[o.name] || len(o.implicit) > 0 {
+			continue
+		}
+
+		words := "-f"
+		if len(o.choices) > 0 {
+			words = "-W \"" + strings.Join(o.choices, " ") + "\""
+		}
+		fmt.Fprintf(b, "        %s) COMPREPLY=( $(compgen %s -- \"$cur\") ); return;;\n",
+			strings.Join(o.flags, "|"), words)
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("    case \"$cur\" in\n")
+	fmt.Fprintf(b, "        -*) COMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") );;\n", strings.Join(flags, " "))
+	if cmds := spec.cmdNames(); len(cmds) > 0 {
+		fmt.Fprintf(b, "        *) COMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") );;\n", strings.Join(cmds, " "))
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("}\n")
+	fmt.Fprintf(b, "complete -o default -F %s %s\n", completeFunc(prog), prog)
+}
+
+func (spec *Spec) zshCompletion(b *strings.Builder) {
+	prog := spec.progname()
+
+	fmt.Fprintf(b, "#compdef %s\n\n", prog)
+	b.WriteString("_arguments -s")
+	for _, o := range spec.cliOpts() {
+		help := zshQuote(firstPara(o.help))
+
+		action := ""
+		if !spec.flags[o.name] {
+			action = ":" + o.name + ":_files"
+			if len(o.choices) > 0 {
+				action = ":" + o.name + ":(" + strings.Join(o.choices, " ") + ")"
+			}
+		}
+
+		excl := ""
+		if len(o.flags) > 1 {
+			excl = "(" + strings.Join(o.flags, " ") + ")"
+		}
+		for _, f := range o.flags {
+			suffix := ""
+			switch {
+			case spec.flags[o.name]:
+			case len(o.implicit) > 0:
+				suffix = "=-"
+			case strings.HasPrefix(f, "--"):
+				suffix = "="
+			default:
+				suffix = "+"
+			}
+			fmt.Fprintf(b, " \\\n    '%s%s%s[%s]%s'", excl, f, suffix, help, action)
+		}
+	}
+
+	if len(spec.cmdlist) > 0 {
+		var cmds []string
+		for _, c := range spec.cmdlist {
+			for _, a := range c.aliases {
+				cmds = append(cmds, strings.ReplaceAll(a, ":", "\\:")+"\\:"+zshQuote(`"`+firstPara(c.help)+`"`))
+			}
+		}
+		fmt.Fprintf(b, " \\\n    '1:command:((%s))'", strings.Join(cmds, " "))
+	}
+	b.WriteString(" \\\n    '*::arg:_files'\n")
+}
+
+func (spec *Spec) fishCompletion(b *strings.Builder) {
+	prog := spec.progname()
+
+	for _, o := range spec.cliOpts() {
+		fmt.Fprintf(b, "complete -c %s", prog)
+		for _, f := range o.flags {
+			if strings.HasPrefix(f, "--") {
+				fmt.Fprintf(b, " -l %s", f[2:])
+			} else if len(f) == 2 {
+				fmt.Fprintf(b, " -s %s", f[1:])
+			} else {
+				fmt.Fprintf(b, " -o %s", f[1:])
+			}
+		}
+		if !spec.flags[o.name] && len(o.implicit) == 0 {
+			b.WriteString(" -r")
+			if len(o.choices) > 0 {
+				fmt.Fprintf(b, " -f -a %s", shellQuote(strings.Join(o.choices, " ")))
+			}
+		}
+		if h := firstPara(o.help); len(h) > 0 {
+			fmt.Fprintf(b, " -d %s", shellQuote(h))
+		}
+		b.WriteString("\n")
+	}
+
+	for _, c := range spec.cmdlist {
+		for _, a := range c.aliases {
+			fmt.Fprintf(b, "complete -c %s -n __fish_use_subcommand -f -a %s", prog, shellQuote(a))
+			if h := firstPara(c.help); len(h) > 0 {
+				fmt.Fprintf(b, " -d %s", shellQuote(h))
+			}
+			b.WriteString("\n")
+		}
+	}
+}
+
+// Return the first paragraph of the description 'help'
+func firstPara(help string) string {
+	if i := strings.Index(help, "\n\n"); i >= 0 {
+		return help[:i]
+	}
+	return help
+}
+
+// Quote 's' for a single quoted zsh _arguments spec
+func zshQuote(s string) string {
+	r := strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`)
+	return r.Replace(s)
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,84 @@
+package options
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCompletion(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command>
+    --
+    verbose   -v,--verbose                Show more
+    root=     -r,--root=                  Data root [dir]
+    mode=     --mode=                     Mode: fast or safe
+    color[=auto] --color                  Colorize
+    --
+    HOME      HOME                        Home directory
+    --
+    exec      exec,x                      Run a command
+    shell     shell                       Don't wait
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = SetEnum(spec, "mode", map[string]int{"fast": 1, "safe": 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"bash", []string{
+			"_tool_complete() {",
+			`-r|--root) COMPREPLY=( $(compgen -f -- "$cur") ); return;;`,
+			`--mode) COMPREPLY=( $(compgen -W "fast safe" -- "$cur") ); return;;`,
+			`-*) COMPREPLY=( $(compgen -W "-v --verbose -r --root --mode --color" -- "$cur") );;`,
+			`*) COMPREPLY=( $(compgen -W "exec x shell" -- "$cur") );;`,
+			"complete -o default -F _tool_complete tool",
+		}},
+		{"zsh", []string{
+			"#compdef tool",
+			`'(-v --verbose)-v[Show more]'`,
+			`'(-r --root)-r+[Data root \[dir\]]:root:_files'`,
+			`'(-r --root)--root=[Data root \[dir\]]:root:_files'`,
+			`'--mode=[Mode\: fast or safe]:mode:(fast safe)'`,
+			`'--color=-[Colorize]:color:_files'`,
+			`'1:command:((exec\:"Run a command" x\:"Run a command" shell\:"Don'\''t wait"))'`,
+		}},
+		{"fish", []string{
+			"complete -c tool -s v -l verbose -d 'Show more'\n",
+			"complete -c tool -s r -l root -r -d 'Data root [dir]'\n",
+			"complete -c tool -l mode -r -f -a 'fast safe' -d 'Mode: fast or safe'\n",
+			"complete -c tool -l color -d 'Colorize'\n",
+			"complete -c tool -n __fish_use_subcommand -f -a 'x' -d 'Run a command'\n",
+		}},
+	}
+	for _, tc := range tests {
+		s, err := spec.Completion(tc.shell)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, w := range tc.want {
+			if !strings.Contains(s, w) {
+				t.Errorf("%s: missing %q in:\n%s", tc.shell, w, s)
+			}
+		}
+		if strings.Contains(s, "HOME") {
+			t.Errorf("%s: env var in the completions:\n%s", tc.shell, s)
+		}
+
+		if sh, err := exec.LookPath(tc.shell); err == nil && tc.shell != "fish" {
+			if out, err := exec.Command(sh, "-n", "-c", s).CombinedOutput(); err != nil {
+				t.Errorf("%s: bad syntax: %s\n%s", tc.shell, out, s)
+			}
+		}
+	}
+
+	if _, err = spec.Completion("csh"); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
@@ -0,0 +1,135 @@
+package options
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+)
+
+// Run with -race: Interpret() must not modify the spec or the process
+// environment
+func TestConcurrentInterpret(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] command
+    --
+    verbose        -v,--verbose,TOOL_VERBOSE   Verbose
+    !root=/var     -r,--root=,TOOL_ROOT        Data root
+    level=3:int    -l,--level=                 Log level
+    color[=auto]=never --color=                Colored output {auto,always,never}
+    ^token=        --token=                    API token
+    profile=       --profile=                  Defaults profile
+    [profile dev] level=9
+    [preset --fast] --level=1
+    --
+    home=          TOOL_HOME                   Home directory
+    --
+    exec           exec,x                      Run a program
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := Parse(`
+    usage: exec [options] prog
+    --
+    dry-run        -n,--dry-run                Don't run it
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = spec.SetCommandSpec("exec", sub); err != nil {
+		t.Fatal(err)
+	}
+	spec.SetIgnoreCase(true)
+	spec.SetOptionPrefixes(true)
+	spec.SetProfileOption("profile")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			root := fmt.Sprintf("/srv/%d", i)
+			args := []string{"tool", "-v", "--roo=" + root, "--fast", "--profile=dev", "--color", "x", "-n", "prog"}
+			env := []string{fmt.Sprintf("TOOL_HOME=/home/%d", i)}
+
+			for j := 0; j < 50; j++ {
+				opts, err := spec.Interpret(args, env)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if v, _ := opts.Get("root"); v != root {
+					t.Errorf("%d: expected root %s, saw %s", i, root, v)
+				}
+				if v, _ := opts.Get("home"); v != env[0][len("TOOL_HOME="):] {
+					t.Errorf("%d: bad home %s", i, v)
+				}
+				if opts.Command != "exec" || opts.Sub == nil || !opts.Sub.GetBool("dry-run") {
+					t.Errorf("%d: bad command %q", i, opts.Command)
+				}
+				if _, err := spec.Interpret([]string{"tool", "--bogus"}, env); err == nil {
+					t.Errorf("%d: expected an error", i)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if _, ok := os.LookupEnv("TOOL_ROOT"); ok {
+		t.Error("environment was modified")
+	}
+}
+
+// The goroutines share one argv that uses a command alias; Interpret()
+// must leave it alone.
+func TestConcurrentSharedArgs(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] command
+    --
+    verbose        -v,--verbose                Verbose
+    --
+    --
+    exec           exec,x                      Run a program
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	args := []string{"tool", "-v", "x", "prog"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 50; j++ {
+				opts, err := spec.Interpret(args, nil)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if opts.Command != "exec" || len(opts.Args) != 2 || opts.Args[0] != "exec" || opts.Args[1] != "prog" {
+					t.Errorf("%d: bad command %q %q", i, opts.Command, opts.Args)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if args[2] != "x" {
+		t.Errorf("the caller's args were modified: %q", args)
+	}
+}
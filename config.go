@@ -0,0 +1,344 @@
+// config.go - Config files
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+//go:build !tinygo
+
+package options
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Interpret 'args' and 'environ' like Interpret() with the option
+// values from the config file 'path' layered in between: the command
+// line takes precedence over the environment, which takes precedence
+// over the config file, which in turn takes precedence over the
+// defaults of the spec. A config file value counts as given, e.g. for
+// IsSet() and required options.
+//
+// The format is derived from the file extension: ".json", ".toml" or
+// ".yaml" (".yml"). The file is a flat table keyed by option names, as
+// written by SaveConfig(); values are strings, numbers, booleans or
+// lists of them for repeated options. TOML tables and nested YAML
+// mappings are not supported. A missing file is treated as empty so
+// that the config file can be optional.
+func (spec *Spec) InterpretWithConfig(args []string, environ []string, path string) (*Options, error) {
+	conf, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return spec.interpret(context.Background(), nil, args, environ, nil, conf)
+}
+
+// Read the option values from the config file 'path'
+func readConfig(path string) (map[string][]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var conf map[string][]string
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		conf, err = parseJSONConfig(b)
+	case ".toml":
+		conf, err = parseTextConfig(string(b), "=")
+	case ".yaml", ".yml":
+		conf, err = parseTextConfig(string(b), ":")
+	default:
+		return nil, fmt.Errorf("Unsupported config format: %s", path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid config: %s: %w", path, err)
+	}
+	return conf, nil
+}
+
+// Parse a JSON object of option values
+func parseJSONConfig(b []byte) (map[string][]string, error) {
+	var m map[string]any
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&m); err != nil {
+		return nil, err
+	}
+
+	conf := make(map[string][]string, len(m))
+	for k, v := range m {
+		items := []any{v}
+		if l, ok := v.([]any); ok {
+			items = l
+		}
+
+		vals := make([]string, 0, len(items))
+		for _, x := range items {
+			switch x := x.(type) {
+			case string:
+				vals = append(vals, x)
+			case bool, json.Number:
+				vals = append(vals, fmt.Sprint(x))
+			default:
+				return nil, fmt.Errorf("%s: unsupported value", k)
+			}
+		}
+		conf[k] = vals
+	}
+	return conf, nil
+}
+
+// Parse the flat TOML ("key = value") or YAML ("key: value") subset
+// of option values; 'sep' separates the keys and the values. Lists
+// are written as "[a, b]" in either format or, in YAML, as "- item"
+// lines following a key without a value.
+func parseTextConfig(s string, sep string) (map[string][]string, error) {
+	conf := make(map[string][]string)
+	yaml := sep == ":"
+	list := ""
+
+	for n, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(stripComment(line))
+		if len(line) == 0 || (yaml && line == "---") {
+			continue
+		}
+
+		if yaml && strings.HasPrefix(line, "- ") && len(list) > 0 {
+			v, err := configScalar(strings.TrimSpace(line[2:]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			conf[list] = append(conf[list], v)
+			continue
+		}
+		list = ""
+
+		if line[0] == '[' && !yaml {
+			return nil, fmt.Errorf("line %d: tables are not supported", n+1)
+		}
+
+		i := strings.Index(line, sep)
+		if i <= 0 {
+			return nil, fmt.Errorf("line %d: expected key%svalue", n+1, sep)
+		}
+
+		k, err := configScalar(strings.TrimSpace(line[:i]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+
+		v := strings.TrimSpace(line[i+1:])
+		switch {
+		case len(v) == 0 && yaml:
+			list = k
+			conf[k] = []string{}
+		case strings.HasPrefix(v, "["):
+			if conf[k], err = configList(v); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+		default:
+			if v, err = configScalar(v); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			conf[k] = []string{v}
+		}
+	}
+	return conf, nil
+}
+
+// Parse the inline list "[a, "b", 'c']"
+func configList(s string) ([]string, error) {
+	if !strings.HasSuffix(s, "]") {
+		return nil, fmt.Errorf("unterminated list %s", s)
+	}
+
+	var vals []string
+	for _, item := range splitQuoted(s[1:len(s)-1], ',') {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		v, err := configScalar(item)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+// Return the value of the scalar 's': a double quoted string with JSON
+// escapes, a single quoted literal string or a bare word.
+func configScalar(s string) (string, error) {
+	switch {
+	case len(s) == 0:
+		return s, nil
+	case s[0] == '"':
+		var v string
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return "", fmt.Errorf("invalid string %s", s)
+		}
+		return v, nil
+	case s[0] == '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return "", fmt.Errorf("invalid string %s", s)
+		}
+		return s[1 : len(s)-1], nil
+	}
+	return s, nil
+}
+
+// Remove a '#' comment that isn't inside a quoted string; the '#'
+// must start the line or follow a blank.
+func stripComment(line string) string {
+	for off := 0; ; {
+		i := indexUnquoted(line[off:], '#')
+		if i < 0 {
+			return line
+		}
+		i += off
+		if i == 0 || line[i-1] == ' ' || line[i-1] == '\t' {
+			return line[:i]
+		}
+		off = i + 1
+	}
+}
+
+// Split 's' at each 'sep' that isn't inside a quoted string
+func splitQuoted(s string, sep byte) []string {
+	var v []string
+	for {
+		i := indexUnquoted(s, sep)
+		if i < 0 {
+			return append(v, s)
+		}
+		v = append(v, s[:i])
+		s = s[i+1:]
+	}
+}
+
+// Return the index of the first 'c' in 's' outside quotes, or -1
+func indexUnquoted(s string, c byte) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == '\\' && quote == '"' {
+				i++
+			} else if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == c:
+			return i
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		}
+	}
+	return -1
+}
+
+// Write the option values that were explicitly given (i.e., not the
+// defaults) to the config file 'path' in 'format': "json" or "toml".
+// An empty 'format' is derived from the file extension. Options are
+// written in declaration order keyed by their names; flags are
+// booleans and repeated options are lists. Secret options (see
+// Redacted()) are left out so that their values don't end up in the
+// file. The file is replaced atomically. This supports "mytool config
+// set" style workflows.
+func (opts *Options) SaveConfig(path, format string) error {
+	if len(format) == 0 {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	var b strings.Builder
+	switch strings.ToLower(format) {
+	case "json":
+		opts.writeConfig(&b, "{\n", "  %s: %s", ",\n", "\n}\n")
+	case "toml":
+		opts.writeConfig(&b, "", "%s = %s", "\n", "\n")
+	default:
+		return fmt.Errorf("Unsupported config format: %s", format)
+	}
+
+	tmp := fmt.Sprintf("%s.tmp%d", path, os.Getpid())
+	if err := os.WriteFile(tmp, []byte(b.String()), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Write the given options as "key: value" items with 'kv' in between
+// 'start' and 'end', separated by 'sep'. JSON strings are valid TOML
+// basic strings, so both formats share the encoding.
+func (opts *Options) writeConfig(b *strings.Builder, start, kv, sep, end string) {
+	var items []string
+
+	if opts.spec != nil {
+		for _, o := range opts.spec.optlist {
+			v, ok := opts.optionv[o.name]
+			if !ok || opts.spec.isSecret(o.name) {
+				continue
+			}
+			items = append(items, fmt.Sprintf(kv, jsonString(o.name), opts.configValue(o.name, v)))
+		}
+	}
+
+	if len(items) == 0 {
+		if start == "{\n" {
+			b.WriteString("{}\n")
+		}
+		return
+	}
+
+	b.WriteString(start)
+	b.WriteString(strings.Join(items, sep))
+	b.WriteString(end)
+}
+
+// Return the config file encoding of the values 'v' of option 'nm'
+func (opts *Options) configValue(nm string, v []string) string {
+	enc := jsonString
+	if opts.spec.flags[nm] {
+		enc = func(s string) string {
+			b, _ := parseBool(s)
+			return fmt.Sprintf("%v", b)
+		}
+	}
+
+	if len(v) == 1 {
+		return enc(v[0])
+	}
+
+	w := make([]string, 0, len(v))
+	for _, s := range v {
+		w = append(w, enc(s))
+	}
+	return "[" + strings.Join(w, ", ") + "]"
+}
+
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,236 @@
+//go:build !tinygo
+
+package options
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveConfig(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=/x   -r,--root=                  Data root
+    tag=      -t,--tag=                   Tags
+    debug     -d,--debug                  Debug
+    quiet     -q,--quiet                  Quiet
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-t", "a", "-t", `b"c`, "-d"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+
+	fn := filepath.Join(dir, "tool.json")
+	if err = opts.SaveConfig(fn, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v map[string]any
+	if err = json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("invalid json: %s\n%s", err, b)
+	}
+	if len(v) != 2 || v["debug"] != true || len(v["tag"].([]any)) != 2 {
+		t.Errorf("bad config:\n%s", b)
+	}
+
+	fn = filepath.Join(dir, "tool.conf")
+	if err = opts.SaveConfig(fn, "toml"); err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ = os.ReadFile(fn)
+	want := "\"tag\" = [\"a\", \"b\\\"c\"]\n\"debug\" = true\n"
+	if string(b) != want {
+		t.Errorf("expected\n%s\nsaw\n%s", want, b)
+	}
+
+	if err = opts.SaveConfig(fn, "xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestSaveConfigSecrets(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "key")
+	if err := os.WriteFile(key, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    key=:secret   --key=                  API key
+    ^token=       --token=                Token
+    root=         --root=                 Data root
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "--key=file:" + key, "--token=abc", "--root=/x"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := filepath.Join(dir, "tool.toml")
+	if err = opts.SaveConfig(fn, ""); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := os.ReadFile(fn)
+	if want := "\"root\" = \"/x\"\n"; string(b) != want {
+		t.Errorf("expected\n%s\nsaw\n%s", want, b)
+	}
+}
+
+func TestInterpretWithConfig(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    !root=    -r,--root=,TOOL_ROOT       Data root
+    tag=      -t,--tag=                  Tags
+    level=1   -l,--level=,TOOL_LEVEL     Level
+    debug     -d,--debug                 Debug
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"tool.json": `{"root": "/conf", "tag": ["a", "b"], "level": 3, "debug": true}`,
+		"tool.toml": "# tool config\nroot = \"/conf\"\ntag = ['a', \"b\"]  # tags\nlevel = 3\ndebug = true\n",
+		"tool.yaml": "---\nroot: /conf\ntag:\n  - a\n  - \"b\"\nlevel: 3 # three\ndebug: true\n",
+	}
+
+	for name, text := range files {
+		fn := filepath.Join(dir, name)
+		if err = os.WriteFile(fn, []byte(text), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		opts, err := spec.InterpretWithConfig([]string{"tool"}, []string{}, fn)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if v, _ := opts.Get("root"); v != "/conf" || !opts.IsSet("root") {
+			t.Errorf("%s: bad root %q", name, v)
+		}
+		if v := opts.GetMulti("tag"); len(v) != 2 || v[0] != "a" || v[1] != "b" {
+			t.Errorf("%s: bad tags %v", name, v)
+		}
+		if v, _ := opts.GetInt("level"); v != 3 {
+			t.Errorf("%s: bad level %d", name, v)
+		}
+		if !opts.GetBool("debug") {
+			t.Errorf("%s: expected debug", name)
+		}
+		if s := opts.Source("root"); s != SourceConfig {
+			t.Errorf("%s: bad root source %s", name, s)
+		}
+
+		// the environment and the command line take precedence
+		opts, err = spec.InterpretWithConfig([]string{"tool", "-t", "c", "-l", "5"}, []string{"TOOL_ROOT=/env", "TOOL_LEVEL=4"}, fn)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if v, _ := opts.Get("root"); v != "/env" {
+			t.Errorf("%s: expected the env root, saw %q", name, v)
+		}
+		if v := opts.GetMulti("tag"); len(v) != 1 || v[0] != "c" {
+			t.Errorf("%s: expected the cli tags, saw %v", name, v)
+		}
+		if v, _ := opts.GetInt("level"); v != 5 {
+			t.Errorf("%s: expected the cli level, saw %d", name, v)
+		}
+	}
+
+	// a missing config file is empty
+	_, err = spec.InterpretWithConfig([]string{"tool"}, []string{}, filepath.Join(dir, "none.toml"))
+	if err == nil || !strings.Contains(err.Error(), "root") {
+		t.Errorf("expected a missing root error, saw %v", err)
+	}
+
+	bad := map[string]string{
+		"unknown.toml": "color = \"red\"\n",
+		"table.toml":   "[server]\nroot = \"/x\"\n",
+		"nested.json":  `{"root": {"dir": "/x"}}`,
+		"syntax.yaml":  "root /x\n",
+		"tool.ini":     "root=/x\n",
+	}
+	for name, text := range bad {
+		fn := filepath.Join(dir, name)
+		if err = os.WriteFile(fn, []byte(text), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = spec.InterpretWithConfig([]string{"tool", "-r", "/x"}, []string{}, fn); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	_, err = spec.InterpretWithConfig([]string{"tool"}, []string{}, filepath.Join(dir, "unknown.toml"))
+	var e *Error
+	if !errors.As(err, &e) || e.Key != MsgUnknownConfig || e.Source != SourceConfig {
+		t.Errorf("expected an unknown config error, saw %#v", err)
+	}
+}
+
+func TestSaveConfigRoundTrip(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    tag=      -t,--tag=                  Tags
+    debug     -d,--debug                 Debug
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-t", "a#1", "-t", `b"c`, "-d"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"tool.json", "tool.toml"} {
+		fn := filepath.Join(t.TempDir(), name)
+		if err = opts.SaveConfig(fn, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		o, err := spec.InterpretWithConfig([]string{"tool"}, []string{}, fn)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if v := o.GetMulti("tag"); len(v) != 2 || v[0] != "a#1" || v[1] != `b"c` || !o.GetBool("debug") {
+			t.Errorf("%s: bad round trip %v", name, o.GetMulti("tag"))
+		}
+	}
+}
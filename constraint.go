@@ -0,0 +1,141 @@
+// constraint.go - Range and pattern constraints on option values
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A constraint on the values of an option: a numeric range such as
+// "{1..65535}" or a regular expression such as "{re:^[a-z]+$}"
+type constraint struct {
+	// as written in the spec, without the braces
+	text string
+
+	// the bounds of a range; an open end is infinite
+	min, max float64
+
+	// the pattern the values must match
+	re *regexp.Regexp
+}
+
+// Split the constraint off the name column 'option' of an option, as
+// in "port=8080{1..65535}"; return nil if there is none.
+func splitConstraint(option string) (string, *constraint, error) {
+	if !strings.HasSuffix(option, "}") {
+		return option, nil, nil
+	}
+
+	// a default that merely ends in braces, e.g. "{{.Name}}", is left
+	// alone
+	i := strings.Index(option, "{re:")
+	if i < 0 {
+		i = strings.LastIndexByte(option, '{')
+		if i > 0 && !strings.Contains(option[i:], "..") {
+			return option, nil, nil
+		}
+	}
+	if i <= 0 {
+		return option, nil, nil
+	}
+
+	c, err := parseConstraint(option[i+1 : len(option)-1])
+	if err != nil {
+		return option, nil, fmt.Errorf("Invalid option spec: %s: %s", option, err)
+	}
+	return option[:i], c, nil
+}
+
+// Parse the constraint 's': "re:PATTERN", "MIN..MAX", "MIN.." or
+// "..MAX"
+func parseConstraint(s string) (*constraint, error) {
+	c := &constraint{text: s, min: math.Inf(-1), max: math.Inf(1)}
+	if pat, ok := strings.CutPrefix(s, "re:"); ok {
+		re, err := regexp.Compile(pat)
+		if err != nil {
+			return nil, err
+		}
+		c.re = re
+		return c, nil
+	}
+
+	lo, hi, ok := strings.Cut(s, "..")
+	if !ok || (len(lo) == 0 && len(hi) == 0) {
+		return nil, fmt.Errorf("{%s} is not a range or a pattern", s)
+	}
+
+	var err error
+	if len(lo) > 0 {
+		if c.min, err = parseNumber(lo); err != nil {
+			return nil, fmt.Errorf("{%s} has a bad minimum %s", s, lo)
+		}
+	}
+	if len(hi) > 0 {
+		if c.max, err = parseNumber(hi); err != nil {
+			return nil, fmt.Errorf("{%s} has a bad maximum %s", s, hi)
+		}
+	}
+	if c.min > c.max {
+		return nil, fmt.Errorf("{%s} is an empty range", s)
+	}
+	return c, nil
+}
+
+// Parse the number 's': an integer in any base that Go accepts or a
+// decimal
+func parseNumber(s string) (float64, error) {
+	if i, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return float64(i), nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err == nil && math.IsNaN(f) {
+		err = strconv.ErrSyntax
+	}
+	return f, err
+}
+
+// Return true if 'v' satisfies the constraint
+func (c *constraint) match(v string) bool {
+	if c.re != nil {
+		return c.re.MatchString(v)
+	}
+
+	f, err := parseNumber(v)
+	return err == nil && f >= c.min && f <= c.max
+}
+
+// Verify that the default and implicit values of option 'o' satisfy
+// its constraint
+func (spec *Spec) checkDefaultConstraint(o *optspec) error {
+	if o.constraint == nil {
+		return nil
+	}
+
+	for _, v := range []string{spec.defaults[o.name], o.implicit} {
+		if len(v) > 0 && !o.constraint.match(v) {
+			return fmt.Errorf("Invalid option spec: %s of %s does not satisfy {%s}", v, o.name, o.constraint.text)
+		}
+	}
+	return nil
+}
+
+// Verify that 'value' of option 'o' (supplied by 'arg') satisfies its
+// constraint
+func (spec *Spec) checkConstraint(o *optspec, arg, value string) error {
+	if o.constraint == nil || o.constraint.match(value) {
+		return nil
+	}
+	return spec.optError(MsgBadConstraint, o.name, arg, arg, spec.redact(o.name, value), o.constraint.text)
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,98 @@
+package options
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConstraints(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    port=8080{1..65535}              -p,--port=,TOOL_PORT  Port to listen on
+    ratio=0.5{0..1}                  --ratio=        Sample ratio
+    workers={1..}                    -w,--workers=   Number of workers
+    name={re:^[a-z][a-z0-9-]*$}      -n,--name=      Instance name
+    tmpl={{.Name}}                   --tmpl=         Output template
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-p", "443", "--ratio=0.25", "-w", "64", "-n", "web-1"}, []string{"TOOL_PORT=80"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("tmpl"); v != "{{.Name}}" {
+		t.Errorf("bad default %q", v)
+	}
+
+	bad := []struct {
+		args []string
+		env  []string
+		want string
+	}{
+		{[]string{"-p", "0"}, nil, "Invalid option: -p: 0 does not satisfy {1..65535}"},
+		{[]string{"--port=http"}, nil, "Invalid option: --port=http: http does not satisfy {1..65535}"},
+		{[]string{"--ratio=1.5"}, nil, "Invalid option: --ratio=1.5: 1.5 does not satisfy {0..1}"},
+		{[]string{"-w", "0"}, nil, "Invalid option: -w: 0 does not satisfy {1..}"},
+		{[]string{"-n", "Web"}, nil, "Invalid option: -n: Web does not satisfy {re:^[a-z][a-z0-9-]*$}"},
+		{nil, []string{"TOOL_PORT=70000"}, "Invalid option: TOOL_PORT: 70000 does not satisfy {1..65535}"},
+	}
+	for _, tc := range bad {
+		_, err := spec.Interpret(append([]string{"tool"}, tc.args...), tc.env)
+		var e *Error
+		if !errors.As(err, &e) || e.Key != MsgBadConstraint || !errors.Is(err, ErrBadValue) {
+			t.Errorf("%q: expected a constraint error, saw %v", tc.args, err)
+			continue
+		}
+		if err.Error() != tc.want {
+			t.Errorf("%q: expected %q, saw %q", tc.args, tc.want, err)
+		}
+	}
+
+	// the constraints are documented
+	spec.SetUsageWidth(120)
+	u := spec.FormatUsage()
+	for _, s := range []string{"(must be {1..65535})", "(must be {re:^[a-z][a-z0-9-]*$})"} {
+		if !strings.Contains(u, s) {
+			t.Errorf("expected %q in\n%s", s, u)
+		}
+	}
+	if h, _ := spec.OptionHelp("port"); !strings.Contains(h, "Constraint:  {1..65535}\n") {
+		t.Errorf("bad option help:\n%s", h)
+	}
+
+	// and kept in the canonical form
+	s2, err := Parse(spec.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2.String() != spec.String() {
+		t.Errorf("constraints lost:\n%s", spec.String())
+	}
+	if _, err := s2.Interpret([]string{"tool", "-n", "Web"}, nil); err == nil {
+		t.Error("constraint lost in the canonical form")
+	}
+}
+
+func TestConstraintErrors(t *testing.T) {
+	bad := []string{
+		"port=0{1..65535}   -p,--port=   Port",
+		"port={9..1}        -p,--port=   Port",
+		"port={a..b}        -p,--port=   Port",
+		"port={..}          -p,--port=   Port",
+		"name={re:[a-}      -n,--name=   Name",
+		"name=X{re:^[a-z]+$} -n,--name=  Name",
+		"verbose{1..2}      -v           Verbose",
+	}
+	for _, line := range bad {
+		if _, err := Parse("usage\n--\n" + line + "\n--\n--\n--\n"); err == nil {
+			t.Errorf("%s: expected an error", line)
+		}
+	}
+}
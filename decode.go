@@ -0,0 +1,164 @@
+// decode.go - Binding options to struct fields
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	durationType  = reflect.TypeOf(time.Duration(0))
+	unmarshalType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Store the option values in the fields of the struct 'v' points to.
+// A field is bound to an option with a tag naming it:
+//
+//	type Config struct {
+//		Root    string        `opt:"root"`
+//		Jobs    int           `opt:"jobs"`
+//		Timeout time.Duration `opt:"timeout"`
+//		Include []string      `opt:"include"`
+//	}
+//
+// Fields can be strings, bools, integers, floats, durations, types
+// that implement encoding.TextUnmarshaler and slices of these; a slice
+// gets every value of a repeated option (see GetMulti()) and an integer
+// bound to a flag gets the number of times it was given. Fields of
+// options that are not set and have no default are left as is, as are
+// untagged fields; embedded structs are decoded as well. An error names
+// the first option that can't be stored.
+func (opts *Options) Decode(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Decode: %T is not a pointer to a struct", v)
+	}
+	if opts.spec == nil {
+		return fmt.Errorf("Decode: no options were interpreted")
+	}
+	return opts.decodeStruct(rv.Elem())
+}
+
+// Decode the tagged fields of the struct 'sv'
+func (opts *Options) decodeStruct(sv reflect.Value) error {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		fv := sv.Field(i)
+
+		nm, ok := f.Tag.Lookup("opt")
+		if !ok || nm == "-" {
+			if f.Anonymous && fv.Kind() == reflect.Struct {
+				if err := opts.decodeStruct(fv); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !f.IsExported() {
+			return fmt.Errorf("Decode: field %s of option %s is not exported", f.Name, nm)
+		}
+
+		if err := opts.decodeField(nm, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Store the value(s) of option 'nm' in 'fv'
+func (opts *Options) decodeField(nm string, fv reflect.Value) error {
+	if _, ok := opts.spec.flags[nm]; !ok {
+		return fmt.Errorf("Unknown option: %s", nm)
+	}
+
+	vals := opts.GetMulti(nm)
+	if len(vals) == 0 {
+		v, ok := opts.Get(nm)
+		if !ok {
+			return nil
+		}
+		vals = []string{v}
+	}
+
+	if opts.spec.flags[nm] && isInt(fv.Kind()) && fv.Type() != durationType {
+		return opts.decodeValue(nm, strconv.Itoa(opts.GetCount(nm)), fv)
+	}
+
+	if fv.Kind() == reflect.Slice && !fv.Addr().Type().Implements(unmarshalType) {
+		s := reflect.MakeSlice(fv.Type(), len(vals), len(vals))
+		for i, v := range vals {
+			if err := opts.decodeValue(nm, v, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		fv.Set(s)
+		return nil
+	}
+
+	// a single valued field takes the first value like Get()
+	return opts.decodeValue(nm, vals[0], fv)
+}
+
+// Convert the value 's' of option 'nm' to the type of 'fv' and store it
+func (opts *Options) decodeValue(nm, s string, fv reflect.Value) error {
+	var err error
+
+	if u, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		err = u.UnmarshalText([]byte(s))
+	} else if fv.Type() == durationType {
+		var d time.Duration
+		if d, err = time.ParseDuration(s); err == nil {
+			fv.SetInt(int64(d))
+		}
+	} else {
+		switch k := fv.Kind(); {
+		case k == reflect.String:
+			fv.SetString(s)
+		case k == reflect.Bool:
+			b, ok := parseBool(s)
+			if !ok {
+				err = fmt.Errorf("%s is not a boolean", s)
+			}
+			fv.SetBool(b)
+		case isInt(k):
+			var i int64
+			if i, err = strconv.ParseInt(opts.numfmt.normalize(s), 0, fv.Type().Bits()); err == nil {
+				fv.SetInt(i)
+			}
+		case k >= reflect.Uint && k <= reflect.Uintptr:
+			var u uint64
+			if u, err = strconv.ParseUint(opts.numfmt.normalize(s), 0, fv.Type().Bits()); err == nil {
+				fv.SetUint(u)
+			}
+		case k == reflect.Float32 || k == reflect.Float64:
+			var f float64
+			if f, err = strconv.ParseFloat(opts.numfmt.normalize(s), fv.Type().Bits()); err == nil {
+				fv.SetFloat(f)
+			}
+		default:
+			return fmt.Errorf("Decode: option %s can't be stored in a %s", nm, fv.Type())
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("Invalid option: %s: %w", nm, err)
+	}
+	return nil
+}
+
+func isInt(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,109 @@
+package options
+
+import (
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type decodeBase struct {
+	Verbose int `opt:"verbose"`
+}
+
+type decodeConfig struct {
+	decodeBase
+
+	Root    string        `opt:"root"`
+	Jobs    int           `opt:"jobs"`
+	Size    uint16        `opt:"size"`
+	Ratio   float64       `opt:"ratio"`
+	Force   bool          `opt:"force"`
+	Timeout time.Duration `opt:"timeout"`
+	Include []string      `opt:"include"`
+	Ports   []int         `opt:"port"`
+	Listen  netip.Addr    `opt:"listen"`
+	Keep    string        `opt:"keep"`
+	Other   string
+	Ignored string `opt:"-"`
+}
+
+func TestDecode(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    verbose   -v                          Verbose
+    root=/    -r,--root=                  Data root
+    jobs=4    -j,--jobs=                  Jobs
+    size=     --size=                     Size
+    ratio=    --ratio=                    Ratio
+    force     -f,--force                  Force
+    timeout=  --timeout=                  Timeout
+    include=  -I=                         Include dirs
+    port=     -p=                         Ports
+    listen=   --listen=                   Listen address
+    keep=     --keep=                     Not given
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	opts, err := spec.Interpret([]string{"tool", "-v", "-v", "--size=0x10", "--ratio=0.5", "-f",
+		"--timeout=1m30s", "-I", "a", "-I", "b", "-p", "80", "-p", "443", "--listen=::1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := decodeConfig{Keep: "kept", Other: "other"}
+	if err = opts.Decode(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	want := decodeConfig{
+		decodeBase: decodeBase{Verbose: 2},
+		Root:       "/",
+		Jobs:       4,
+		Size:       16,
+		Ratio:      0.5,
+		Force:      true,
+		Timeout:    90 * time.Second,
+		Include:    []string{"a", "b"},
+		Ports:      []int{80, 443},
+		Listen:     netip.IPv6Loopback(),
+		Keep:       "kept",
+		Other:      "other",
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("expected %+v, saw %+v", want, c)
+	}
+
+	tests := []struct {
+		args []string
+		v    any
+		err  string
+	}{
+		{[]string{"tool", "--size=70000"}, &decodeConfig{}, "Invalid option: size: "},
+		{[]string{"tool", "--timeout=soon"}, &decodeConfig{}, "Invalid option: timeout: "},
+		{[]string{"tool"}, decodeConfig{}, "Decode: "},
+		{[]string{"tool"}, &struct {
+			X string `opt:"nope"`
+		}{}, "Unknown option: nope"},
+		{[]string{"tool"}, &struct {
+			X chan int `opt:"root"`
+		}{}, "Decode: option root can't be stored"},
+	}
+	for _, tc := range tests {
+		opts, err := spec.Interpret(tc.args, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = opts.Decode(tc.v); err == nil || !strings.HasPrefix(err.Error(), tc.err) {
+			t.Errorf("%v: expected %q, saw %v", tc.args, tc.err, err)
+		}
+	}
+}
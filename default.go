@@ -0,0 +1,94 @@
+// default.go - Package level convenience API
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"errors"
+	"os"
+)
+
+// The default Spec used by the package level functions below; the
+// program sets it before calling ParseArgs(), e.g.
+//
+//	options.CommandLine = options.MustParse(spec)
+//	options.ParseArgs()
+var CommandLine *Spec
+
+// The options from the last call to ParseArgs()
+var parsed *Options
+
+// Interpret os.Args and os.Environ() according to the default spec
+// and remember the result for the package level getters. Like
+// MustInterpret(), this exits with the usage string if parsing fails;
+// the error is returned only if CommandLine isn't set.
+func ParseArgs() (*Options, error) {
+	if CommandLine == nil {
+		return nil, errors.New("options: ParseArgs called without a default spec")
+	}
+
+	parsed = CommandLine.MustInterpret(os.Args, os.Environ())
+	return parsed, nil
+}
+
+// Interpret os.Args and os.Environ() according to 'spec'; this is
+// Interpret() with the arguments every program passes it.
+func (spec *Spec) InterpretOS() (*Options, error) {
+	return spec.Interpret(os.Args, os.Environ())
+}
+
+// Return the options parsed by ParseArgs(); before ParseArgs() is
+// called, this is an empty set of options.
+func defaultOptions() *Options {
+	if parsed == nil {
+		return &Options{}
+	}
+	return parsed
+}
+
+// Return the command from the last call to ParseArgs()
+func Command() string {
+	return defaultOptions().Command
+}
+
+// Return the arguments from the last call to ParseArgs()
+func Args() []string {
+	return defaultOptions().Args
+}
+
+// Get() on the options parsed by ParseArgs()
+func Get(nm string) (string, bool) {
+	return defaultOptions().Get(nm)
+}
+
+// GetMulti() on the options parsed by ParseArgs()
+func GetMulti(nm string) []string {
+	return defaultOptions().GetMulti(nm)
+}
+
+// GetBool() on the options parsed by ParseArgs()
+func GetBool(nm string) bool {
+	return defaultOptions().GetBool(nm)
+}
+
+// GetInt() on the options parsed by ParseArgs()
+func GetInt(nm string) (int64, bool) {
+	return defaultOptions().GetInt(nm)
+}
+
+// GetUint() on the options parsed by ParseArgs()
+func GetUint(nm string) (uint64, bool) {
+	return defaultOptions().GetUint(nm)
+}
+
+// IsSet() on the options parsed by ParseArgs()
+func IsSet(nm string) bool {
+	return defaultOptions().IsSet(nm)
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,52 @@
+package options
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDefaultSpec(t *testing.T) {
+	saved, savedArgs := CommandLine, os.Args
+	defer func() {
+		CommandLine, os.Args, parsed = saved, savedArgs, nil
+	}()
+
+	CommandLine = nil
+	if _, err := ParseArgs(); err == nil {
+		t.Error("expected an error without a default spec")
+	}
+
+	spec := MustParse(`
+    usage: tiny [options] [args...]
+    --
+    num=3     -n,--num=                   Number of things
+    verbose   -v,--verbose                Show more info
+    --
+    --
+    *
+    --
+    `)
+	if CommandLine != nil {
+		t.Fatal("MustParse must not set the default spec")
+	}
+	CommandLine = spec
+
+	if _, ok := Get("num"); ok {
+		t.Error("getters before ParseArgs must return nothing")
+	}
+
+	os.Args = []string{"tiny", "-v", "a", "b"}
+	if _, err := ParseArgs(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !GetBool("verbose") {
+		t.Error("expected verbose")
+	}
+	if n, ok := GetInt("num"); !ok || n != 3 {
+		t.Errorf("expected num 3, saw %d", n)
+	}
+	if a := Args(); len(a) != 2 || a[0] != "a" {
+		t.Errorf("bad args: %q", a)
+	}
+}
@@ -0,0 +1,80 @@
+// defaultfn.go - Defaults computed at Interpret time
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"maps"
+)
+
+// Compute the default of option 'nm' with 'fn' every time the command
+// line is interpreted, for defaults that depend on the machine or the
+// time of the run:
+//
+//	spec.DefaultFunc("workers", func() string {
+//	    return strconv.Itoa(runtime.NumCPU())
+//	})
+//
+// The result replaces the default in the spec; an empty result means
+// there is no default. Defaults given to InterpretWithDefaults() take
+// precedence. 'fn' is called by every Interpret() and must be safe
+// for concurrent use if Interpret() is. (Defaults that only refer to
+// environment variables, like "cache=$XDG_CACHE_HOME/tool:path", can
+// be written in the spec; see the "path" value type.)
+func (spec *Spec) DefaultFunc(nm string, fn func() string) error {
+	if _, ok := spec.flags[nm]; !ok {
+		return fmt.Errorf("Unknown option: %s", nm)
+	}
+
+	if spec.default_funcs == nil {
+		spec.default_funcs = make(map[string]func() string)
+	}
+	spec.default_funcs[nm] = fn
+	return nil
+}
+
+// Return the default of option 'nm', computing it if it is dynamic
+func (spec *Spec) defaultValue(nm string) (string, bool) {
+	if fn, ok := spec.default_funcs[nm]; ok {
+		v := fn()
+		return v, len(v) > 0
+	}
+	v, ok := spec.defaults[nm]
+	return v, ok
+}
+
+// Compute the dynamic defaults of opts other than those in 'defs' (see
+// InterpretWithDefaults()); the defaults are copied so that the spec's
+// own map is left alone.
+func (spec *Spec) computeDefaults(opts *Options, defs map[string]string) error {
+	if len(spec.default_funcs) == 0 {
+		return nil
+	}
+
+	d := maps.Clone(opts.defaults)
+	for _, nm := range sortedKeys(spec.default_funcs) {
+		if _, ok := defs[nm]; ok {
+			continue
+		}
+
+		v := spec.default_funcs[nm]()
+		if len(v) == 0 {
+			delete(d, nm)
+			continue
+		}
+		if err := spec.checkType(nm, nm, spec.normalize(nm, v)); err != nil {
+			return err
+		}
+		d[nm] = v
+	}
+	opts.defaults = d
+	return nil
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,81 @@
+package options
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDefaultFunc(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    workers=1    -w,--workers=N       Number of workers
+    mode=        -m,--mode=M          Mode {fast,slow}
+    cache=       --cache=DIR          Cache directory
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	if err := spec.DefaultFunc("workers", func() string {
+		n++
+		return strconv.Itoa(n * 4)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.DefaultFunc("cache", func() string { return "" }); err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.DefaultFunc("bogus", func() string { return "" }); err == nil {
+		t.Error("expected an error for an unknown option")
+	}
+
+	for _, want := range []string{"4", "8"} {
+		opts, err := spec.Interpret([]string{"tool"}, []string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := opts.Get("workers"); v != want {
+			t.Errorf("workers: expected %s, saw %s", want, v)
+		}
+		if v, _ := opts.Get("cache"); v != "" {
+			t.Errorf("cache: expected no default, saw %s", v)
+		}
+	}
+
+	// the command line and InterpretWithDefaults() win
+	opts, err := spec.Interpret([]string{"tool", "-w", "2"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("workers"); v != "2" {
+		t.Errorf("workers: expected 2, saw %s", v)
+	}
+	opts, err = spec.InterpretWithDefaults([]string{"tool"}, []string{}, map[string]string{"workers": "9"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("workers"); v != "9" {
+		t.Errorf("workers: expected 9, saw %s", v)
+	}
+	if v := spec.defaults["workers"]; v != "1" {
+		t.Errorf("spec default changed to %s", v)
+	}
+
+	// the usage shows the computed default
+	spec.SetUsageWidth(80)
+	if u := spec.FormatUsage(); !strings.Contains(u, "(default: ") {
+		t.Errorf("no default in usage:\n%s", u)
+	}
+
+	// a computed default must be valid
+	spec.DefaultFunc("mode", func() string { return "medium" })
+	if _, err := spec.Interpret([]string{"tool"}, []string{}); err == nil {
+		t.Error("expected an error for an invalid computed default")
+	}
+}
@@ -0,0 +1,167 @@
+// deprecate.go - Deprecated options
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A deprecated option or spelling
+type deprecation struct {
+	msg string
+
+	// the version that removes it, if known
+	removed string
+}
+
+// Parse a "[deprecated NAME,... [VERSION]] message" line. A NAME that is
+// an option name deprecates every spelling of the option; a flag or env
+// var deprecates just that spelling. The names are verified by
+// checkDeprecated() once all the options are declared.
+func (spec *Spec) parseDeprecated(line string) error {
+	i := strings.IndexByte(line, ']')
+	if i < 0 {
+		return fmt.Errorf("Invalid deprecation spec: %s", line)
+	}
+
+	head := strings.Fields(line[len("[deprecated "):i])
+	if len(head) == 0 || len(head) > 2 {
+		return fmt.Errorf("Invalid deprecation spec: %s", line)
+	}
+
+	d := deprecation{msg: strings.TrimSpace(line[i+1:])}
+	if len(head) == 2 {
+		d.removed = strings.TrimPrefix(head[1], "v")
+		if _, ok := parseVersion(d.removed); !ok {
+			return fmt.Errorf("Invalid deprecation spec: %s: %s is not a version", line, head[1])
+		}
+	}
+	if len(d.msg) == 0 {
+		if len(d.removed) > 0 {
+			d.msg = "it will be removed in version " + d.removed
+		} else {
+			d.msg = "it will be removed in a future version"
+		}
+	}
+
+	if spec.deprecated == nil {
+		spec.deprecated = make(map[string]deprecation)
+	}
+	for _, nm := range strings.Split(head[0], ",") {
+		if len(nm) == 0 {
+			return fmt.Errorf("Invalid deprecation spec: %s", line)
+		}
+		spec.deprecated[nm] = d
+	}
+	return nil
+}
+
+// Verify that the deprecations name declared options
+func (spec *Spec) checkDeprecated() error {
+	for nm := range spec.deprecated {
+		if spec.lookupOpt(nm) == nil {
+			return fmt.Errorf("Invalid deprecation spec: %s is not a known option", nm)
+		}
+	}
+	return nil
+}
+
+// Record a warning in opts if option 'nm' or its spelling 'tok' is
+// deprecated; return an error if the version of the program is past
+// its removal.
+func (spec *Spec) noteDeprecated(opts *Options, nm, tok string) error {
+	if len(spec.deprecated) == 0 {
+		return nil
+	}
+
+	d, ok := spec.deprecated[tok]
+	if !ok {
+		if d, ok = spec.deprecated[nm]; !ok {
+			return nil
+		}
+	}
+
+	if len(d.removed) > 0 && compareVersions(spec.Version(), d.removed) >= 0 {
+		return spec.optError(MsgRemovedOption, nm, tok, tok, d.removed)
+	}
+
+	w := spec.optError(MsgDeprecated, nm, tok, tok, d.msg)
+	opts.warnings = append(opts.warnings, w)
+
+	spec.debug("deprecated option", "option", nm, "token", spec.redactArg(nm, tok))
+	if fn := spec.metrics.Deprecated; fn != nil {
+		fn(nm, tok)
+	}
+	return nil
+}
+
+// Return the warnings about the input to Interpret(), such as uses of
+// deprecated options, as *Error values. The program decides whether to
+// print them.
+func (opts *Options) Warnings() []error {
+	return opts.warnings
+}
+
+// Split the version 'v' ("1.2", "v2.0.1", "1.3-rc1") into its numeric
+// components; the suffix after a '-' or '+' is ignored.
+func parseVersion(v string) ([]int, bool) {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	if len(v) == 0 {
+		return nil, false
+	}
+
+	var rv []int
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		rv = append(rv, n)
+	}
+	return rv, true
+}
+
+// Compare the versions 'a' and 'b' component by component and return
+// -1, 0 or +1. A version that can't be parsed (e.g. an unset one) sorts
+// before every other version.
+func compareVersions(a, b string) int {
+	x, okx := parseVersion(a)
+	y, oky := parseVersion(b)
+	switch {
+	case !okx && !oky:
+		return 0
+	case !okx:
+		return -1
+	case !oky:
+		return 1
+	}
+
+	for len(x) < len(y) {
+		x = append(x, 0)
+	}
+	for len(y) < len(x) {
+		y = append(y, 0)
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			if x[i] < y[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,126 @@
+package options
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDeprecated(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=     -r,--root=,--dir=,TOOL_DIR  Data root
+    fast      --fast                      Go fast
+    [deprecated --dir,TOOL_DIR] use --root instead
+    [deprecated fast]
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	var uses []string
+	spec.SetMetrics(Metrics{
+		Deprecated: func(nm, tok string) {
+			uses = append(uses, nm+":"+tok)
+		},
+	})
+
+	opts, err := spec.Interpret([]string{"tool", "--root=/a", "--fast"}, []string{"TOOL_DIR=/b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := opts.Warnings()
+	want := []string{
+		"Deprecated option: TOOL_DIR: use --root instead",
+		"Deprecated option: --fast: it will be removed in a future version",
+	}
+	if len(w) != len(want) {
+		t.Fatalf("expected %d warnings, saw %v", len(want), w)
+	}
+	for i := range want {
+		if w[i].Error() != want[i] {
+			t.Errorf("expected %q, saw %q", want[i], w[i])
+		}
+	}
+	if len(uses) != 2 || uses[0] != "root:TOOL_DIR" || uses[1] != "fast:--fast" {
+		t.Errorf("bad metrics: %v", uses)
+	}
+
+	opts, err = spec.Interpret([]string{"tool", "-r", "/a"}, nil)
+	if err != nil || len(opts.Warnings()) != 0 {
+		t.Errorf("unexpected warnings: %v %v", err, opts.Warnings())
+	}
+
+	if _, err = Parse("usage: x\n--\na -a A\n[deprecated -b]\n--\n--\n--\n"); err == nil {
+		t.Error("expected an error for an unknown deprecated option")
+	}
+}
+
+func TestDeprecatedRemoval(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    @version 1.4
+    --
+    root=     -r,--root=,--dir=               Data root
+    fast      --fast                          Go fast
+    [deprecated --dir v2.0] use --root instead
+    [deprecated fast 1.4.0]
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	_, err = spec.Interpret([]string{"tool", "--fast"}, nil)
+	var e *Error
+	if !errors.As(err, &e) || e.Key != MsgRemovedOption || e.Option != "fast" {
+		t.Fatalf("expected a removed option error, saw %v", err)
+	}
+	if want := "Invalid option: --fast was removed in version 1.4.0"; err.Error() != want {
+		t.Errorf("expected %q, saw %q", want, err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "--dir=/a"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := opts.Warnings(); len(w) != 1 || w[0].Error() != "Deprecated option: --dir: use --root instead" {
+		t.Errorf("bad warnings: %v", w)
+	}
+
+	spec.SetVersion("v2.0.0-rc1")
+	if _, err = spec.Interpret([]string{"tool", "--dir=/a"}, nil); err == nil {
+		t.Error("expected an error for a removed option")
+	}
+	if spec.Version() != "v2.0.0-rc1" {
+		t.Errorf("bad version %q", spec.Version())
+	}
+
+	tests := []struct {
+		a, b string
+		cmp  int
+	}{
+		{"1.2", "1.10", -1},
+		{"v2.0", "2", 0},
+		{"2.0.1", "2.0", 1},
+		{"", "0.1", -1},
+		{"dev", "1.0", -1},
+	}
+	for _, tc := range tests {
+		if c := compareVersions(tc.a, tc.b); c != tc.cmp {
+			t.Errorf("compare %q %q: expected %d, saw %d", tc.a, tc.b, tc.cmp, c)
+		}
+	}
+
+	if _, err = Parse("usage: x\n--\na -a\n[deprecated -a next]\n--\n--\n--\n"); err == nil {
+		t.Error("expected an error for a bad removal version")
+	}
+}
@@ -0,0 +1,100 @@
+// dialect.go - Windows style and case insensitive options
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"strings"
+)
+
+// Accept Windows style options: "/name" for the option spelled
+// "--name" or "-name" and "/name:value" for "--name=value", so that
+// "/v", "/verbose" and "/out:x.txt" work as "-v", "--verbose" and
+// "--out=x.txt". With the built-in help (see SetAutoHelp()), "/?" asks
+// for help. An argument like "/usr/bin" that doesn't name an option is
+// left alone.
+func (spec *Spec) SetWindowsSyntax(on bool) {
+	spec.win_syntax = on
+}
+
+// Match long options ("--Verbose", "--NO-VERBOSE") and commands without
+// regard to case; single letter options stay case sensitive so that
+// "-v" and "-V" can differ.
+func (spec *Spec) SetIgnoreCase(on bool) {
+	spec.fold_case = on
+}
+
+// Return the option that the Windows style argument 'arg' stands for
+func (spec *Spec) windowsArg(arg string) (string, bool) {
+	if !spec.win_syntax || len(arg) < 2 || arg[0] != '/' {
+		return "", false
+	}
+	if arg == "/?" && spec.auto_help {
+		return "--help", true
+	}
+
+	name, value, ok := strings.Cut(arg[1:], ":")
+	for _, p := range []string{"--", "-"} {
+		s := spec.foldOption(p + name)
+		if _, known := spec.options[s]; !known {
+			if _, known = spec.negatedFlag(s); !known {
+				continue
+			}
+		}
+		if ok {
+			s += "=" + value
+		}
+		return s, true
+	}
+	return "", false
+}
+
+// Return the declared spelling of the long option 'nm' if options are
+// matched without regard to case, else 'nm'.
+func (spec *Spec) foldOption(nm string) string {
+	if !spec.fold_case || !strings.HasPrefix(nm, "--") {
+		return nm
+	}
+	if _, ok := spec.options[nm]; ok {
+		return nm
+	}
+
+	for s := range spec.options {
+		if strings.HasPrefix(s, "--") && strings.EqualFold(s, nm) {
+			return s
+		}
+	}
+
+	// "--NO-VERBOSE"
+	if len(nm) > len("--no-") && strings.EqualFold(nm[:len("--no-")], "--no-") {
+		if s := spec.foldOption("--" + nm[len("--no-"):]); s != "--"+nm[len("--no-"):] {
+			return "--no-" + s[2:]
+		}
+	}
+	return nm
+}
+
+// Return the declared spelling of command 'nm' if commands are matched
+// without regard to case, else 'nm'.
+func (spec *Spec) foldCommand(nm string) string {
+	if !spec.fold_case {
+		return nm
+	}
+	if _, ok := spec.commands[nm]; ok {
+		return nm
+	}
+
+	for s := range spec.commands {
+		if strings.EqualFold(s, nm) {
+			return s
+		}
+	}
+	return nm
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,98 @@
+package options
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWindowsSyntax(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] files...
+    --
+    verbose   -v,--verbose            Verbose
+    out=      -o,--out=               Output file
+    quiet     --quiet                 Quiet
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	args := []string{"tool", "/v", "/out:c:\\x.txt", "/no-quiet", "/usr/bin", "/nope"}
+	opts, err := spec.Interpret(args, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.Args) != 5 || opts.Args[0] != "/v" {
+		t.Errorf("expected no translation by default: %v", opts.Args)
+	}
+
+	spec.SetWindowsSyntax(true)
+	opts, err = spec.Interpret(args, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.GetBool("verbose") || opts.GetTristate("quiet") != False {
+		t.Errorf("bad flags")
+	}
+	if v, _ := opts.Get("out"); v != "c:\\x.txt" {
+		t.Errorf("bad out %q", v)
+	}
+	if s := strings.Join(opts.Args, ","); s != "/usr/bin,/nope" {
+		t.Errorf("bad args: %s", s)
+	}
+
+	spec.SetAutoHelp(true)
+	spec.SetOutput(&strings.Builder{}, &strings.Builder{})
+	if _, err = spec.Interpret([]string{"tool", "/?"}, []string{}); !errors.Is(err, ErrHelp) {
+		t.Errorf("expected help, saw %v", err)
+	}
+}
+
+func TestIgnoreCase(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] command
+    --
+    verbose   -v,--verbose            Verbose
+    version   -V,--version            Version
+    root=     --root=                 Root
+    --
+    --
+    build     build,b                 Build it
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	args := []string{"tool", "--VERBOSE", "--Root=/x", "Build"}
+	if _, err = spec.Interpret(args, []string{}); err == nil {
+		t.Fatal("expected an error without SetIgnoreCase")
+	}
+
+	spec.SetIgnoreCase(true)
+	opts, err := spec.Interpret(args, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.GetBool("verbose") || opts.Command != "build" {
+		t.Errorf("bad parse: verbose %v, command %q", opts.GetBool("verbose"), opts.Command)
+	}
+	if v, _ := opts.Get("root"); v != "/x" {
+		t.Errorf("bad root %q", v)
+	}
+
+	opts, err = spec.Interpret([]string{"tool", "-V", "--No-Verbose", "B"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.GetBool("version") || opts.GetTristate("verbose") != False || opts.Command != "build" {
+		t.Errorf("bad parse: %v", opts.Order())
+	}
+}
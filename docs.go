@@ -0,0 +1,245 @@
+// docs.go - Markdown documentation generator
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Generate Markdown documentation for the command tree described by
+// the spec: an index page for the program and one page per command,
+// recursing into the sub-specs attached to commands. The pages are
+// cross-linked and returned as a map of file name to content. The
+// index page is named after the program ("prog.md") and command pages
+// are named "prog_command.md", "prog_command_subcommand.md" etc.
+func (spec *Spec) MarkdownPages() map[string]string {
+	pages := make(map[string]string)
+	base := spec.title()
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", base)
+	spec.mdBody(&b, base)
+	for _, m := range spec.meta {
+		fmt.Fprintf(&b, "* %s: %s\n", metaTitle(m.key), m.value)
+	}
+	pages[base+".md"] = b.String()
+
+	spec.mdCommandPages(pages, base, base)
+	return pages
+}
+
+// Generate a single Markdown page for the spec, e.g. for a README: the
+// usage section, tables of the options, environment variables and
+// commands, the appendix and the metadata. Options scoped to a command
+// are listed in a table of their own after the commands. See
+// MarkdownPages() for a set of cross-linked pages instead.
+func (spec *Spec) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", spec.title())
+	spec.mdAbout(&b)
+	spec.mdOptions(&b, "", "##")
+
+	if len(spec.cmdlist) > 0 {
+		b.WriteString("## Commands\n\n")
+		b.WriteString("| Command | Aliases | Description |\n")
+		b.WriteString("|---------|---------|-------------|\n")
+		for _, c := range spec.cmdlist {
+			help := strings.ReplaceAll(c.help, "|", "\\|")
+			help = strings.ReplaceAll(help, "\n\n", "<br><br>")
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", mdCode(c.name), mdCodeList(c.aliases), help)
+		}
+		b.WriteString("\n")
+
+		for _, c := range spec.cmdlist {
+			var sub strings.Builder
+			spec.mdOptions(&sub, c.name, "####")
+			if sub.Len() > 0 {
+				fmt.Fprintf(&b, "### %s\n\n", c.name)
+				b.WriteString(sub.String())
+			}
+		}
+	}
+
+	spec.mdAppendix(&b)
+	for _, m := range spec.meta {
+		fmt.Fprintf(&b, "* %s: %s\n", metaTitle(m.key), m.value)
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// Write the pages for the commands of spec; 'title' is the command
+// path so far and 'base' the name of the page that lists them.
+func (spec *Spec) mdCommandPages(pages map[string]string, title, base string) {
+	var b strings.Builder
+
+	for _, c := range spec.cmdlist {
+		name := base + "_" + c.name
+
+		b.Reset()
+		fmt.Fprintf(&b, "# %s %s\n\n", title, c.name)
+		if syn, err := spec.CommandSynopsis(c.name); err == nil {
+			syn = strings.Replace(syn, spec.progname(), title, 1)
+			fmt.Fprintf(&b, "```\n%s\n```\n\n", syn)
+		}
+		if len(c.help) > 0 {
+			fmt.Fprintf(&b, "%s\n\n", c.help)
+		}
+		if len(c.aliases) > 1 {
+			fmt.Fprintf(&b, "Aliases: %s\n\n", mdCodeList(c.aliases))
+		}
+		spec.mdOptions(&b, c.name, "##")
+
+		if sub := spec.subspecs[c.name]; sub != nil {
+			sub.mdBody(&b, name)
+			sub.mdCommandPages(pages, title+" "+c.name, name)
+		}
+
+		if epi := trimBlank(c.epilog); len(epi) > 0 {
+			b.WriteString(strings.Join(epi, "\n"))
+			b.WriteString("\n\n")
+		}
+
+		fmt.Fprintf(&b, "See also: [%s](%s.md)\n", title, base)
+		pages[name+".md"] = b.String()
+	}
+}
+
+// Write the usage text, global options, commands (linked to the pages
+// named 'base'_command.md) and appendix of spec
+func (spec *Spec) mdBody(b *strings.Builder, base string) {
+	spec.mdAbout(b)
+	spec.mdOptions(b, "", "##")
+
+	if len(spec.cmdlist) > 0 {
+		fmt.Fprintf(b, "## Commands\n\n")
+		for _, c := range spec.cmdlist {
+			fmt.Fprintf(b, "* [%s](%s_%s.md)", c.name, base, c.name)
+			if len(c.help) > 0 {
+				fmt.Fprintf(b, " - %s", c.help)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	spec.mdAppendix(b)
+}
+
+// Write the pages generated by MarkdownPages() into the directory 'dir'.
+// The directory is created if needed.
+func (spec *Spec) WriteMarkdownDocs(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for nm, body := range spec.MarkdownPages() {
+		if err := os.WriteFile(filepath.Join(dir, nm), []byte(body), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write the usage section as a code block
+func (spec *Spec) mdAbout(b *strings.Builder) {
+	if len(spec.about) == 0 {
+		return
+	}
+
+	b.WriteString("```\n")
+	for _, l := range spec.about {
+		fmt.Fprintf(b, "%s\n", l)
+	}
+	b.WriteString("```\n\n")
+}
+
+// Write the options and environment sections as tables under headings
+// of level 'h' ("##"). An empty 'cmd' selects the global options,
+// otherwise the options scoped to 'cmd'.
+func (spec *Spec) mdOptions(b *strings.Builder, cmd, h string) {
+	var opts, envs []*optspec
+
+	for _, o := range spec.optlist {
+		if !o.scopedTo(cmd) {
+			continue
+		}
+
+		if o.isenv {
+			envs = append(envs, o)
+		} else {
+			opts = append(opts, o)
+		}
+	}
+
+	if len(opts) > 0 {
+		fmt.Fprintf(b, "%s Options\n\n", h)
+		b.WriteString("| Option | Environment | Default | Description |\n")
+		b.WriteString("|--------|-------------|---------|-------------|\n")
+		for _, o := range opts {
+			fmt.Fprintf(b, "| %s | %s | %s | %s |\n", mdCodeList(o.flagTexts()),
+				mdCodeList(o.env), mdCode(spec.redact(o.name, spec.defaults[o.name])), spec.mdHelp(o))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(envs) > 0 {
+		fmt.Fprintf(b, "%s Environment\n\n", h)
+		b.WriteString("| Variable | Default | Description |\n")
+		b.WriteString("|----------|---------|-------------|\n")
+		for _, o := range envs {
+			fmt.Fprintf(b, "| %s | %s | %s |\n", mdCodeList(o.env),
+				mdCode(spec.redact(o.name, spec.defaults[o.name])), spec.mdHelp(o))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// Write the appendix as free-form text
+func (spec *Spec) mdAppendix(b *strings.Builder) {
+	if len(spec.appendix) == 0 {
+		return
+	}
+
+	for _, l := range spec.appendix {
+		fmt.Fprintf(b, "%s\n", l)
+	}
+	b.WriteString("\n")
+}
+
+// Return the table cell text describing option 'o'
+func (spec *Spec) mdHelp(o *optspec) string {
+	help := strings.ReplaceAll(o.help, "|", "\\|")
+	help = strings.ReplaceAll(help, "\n\n", "<br><br>")
+	if spec.required[o.name] {
+		help = strings.TrimSpace(help + " (required)")
+	}
+	return help
+}
+
+func mdCode(s string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return "`" + s + "`"
+}
+
+func mdCodeList(v []string) string {
+	w := make([]string, 0, len(v))
+	for _, s := range v {
+		w = append(w, mdCode(s))
+	}
+	return strings.Join(w, ", ")
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
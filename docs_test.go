@@ -0,0 +1,149 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownPages(t *testing.T) {
+	spec, err := Parse(`
+    usage: haraway <flags>... <command> <args>...
+    --
+    root=XYZ  -r,--root=,HARAWAY_ROOT     Path to the haraway data root
+    verbose   -v,--verbose                Show more info
+                                          on stdout
+    --
+    --
+    exec      c,exec                      Execute a command within the haraway sanbox
+    shell     sh,shell                    Open a shell within the haraway sanbox
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pages := spec.MarkdownPages()
+	if len(pages) != 3 {
+		t.Fatalf("expected 3 pages, saw %d", len(pages))
+	}
+
+	index, ok := pages["haraway.md"]
+	if !ok {
+		t.Fatalf("missing index page; saw %v", pages)
+	}
+
+	for _, want := range []string{"`--root`", "`HARAWAY_ROOT`", "`XYZ`", "Show more info on stdout", "(haraway_exec.md)"} {
+		if !strings.Contains(index, want) {
+			t.Errorf("index page is missing %q:\n%s", want, index)
+		}
+	}
+
+	exec := pages["haraway_exec.md"]
+	if !strings.Contains(exec, "`c`, `exec`") || !strings.Contains(exec, "(haraway.md)") {
+		t.Errorf("malformed command page:\n%s", exec)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command>
+    @version 1.0
+    --
+    !root=XYZ  -r,--root=DIR,TOOL_ROOT   Data root
+    force@rm   -f,--force                Remove even if busy
+    --
+    home=      TOOL_HOME=                Home directory
+    --
+    rm         rm,del                    Remove | delete entries
+    --
+    See the manual.
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "# tool\n\n" +
+		"```\nusage: tool [options] <command>\n```\n\n" +
+		"## Options\n\n" +
+		"| Option | Environment | Default | Description |\n" +
+		"|--------|-------------|---------|-------------|\n" +
+		"| `-r DIR`, `--root=DIR` | `TOOL_ROOT` | `XYZ` | Data root (required) |\n\n" +
+		"## Environment\n\n" +
+		"| Variable | Default | Description |\n" +
+		"|----------|---------|-------------|\n" +
+		"| `TOOL_HOME` |  | Home directory |\n\n" +
+		"## Commands\n\n" +
+		"| Command | Aliases | Description |\n" +
+		"|---------|---------|-------------|\n" +
+		"| `rm` | `rm`, `del` | Remove \\| delete entries |\n\n" +
+		"### rm\n\n" +
+		"#### Options\n\n" +
+		"| Option | Environment | Default | Description |\n" +
+		"|--------|-------------|---------|-------------|\n" +
+		"| `-f`, `--force` |  |  | Remove even if busy |\n\n" +
+		"See the manual.\n\n" +
+		"* Version: 1.0\n"
+
+	if got := spec.Markdown(); got != want {
+		t.Errorf("expected\n%s\nsaw\n%s", want, got)
+	}
+}
+
+func TestReST(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=XYZ  -r,--root=,TOOL_ROOT      Data root
+
+                                        More about it
+    --
+    --
+    rm        rm                        Remove entries
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `tool
+====
+
+::
+
+    usage: tool [options]
+
+Options
+-------
+
+.. list-table::
+   :header-rows: 1
+
+   * - Option
+     - Environment
+     - Default
+     - Description
+   * - ` + "``-r``, ``--root``" + `
+     - ` + "``TOOL_ROOT``" + `
+     - ` + "``XYZ``" + `
+     - Data root
+
+       More about it
+
+Commands
+--------
+
+.. list-table::
+   :header-rows: 1
+
+   * - Command
+     - Aliases
+     - Description
+   * - ` + "``rm``" + `
+     - ` + "``rm``" + `
+     - Remove entries
+`
+
+	if got := spec.ReST(); got != want {
+		t.Errorf("expected\n%s\nsaw\n%s", want, got)
+	}
+}
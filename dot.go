@@ -0,0 +1,94 @@
+// dot.go - Graphviz export of the command tree
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Write a Graphviz DOT graph of the command tree to 'w': the program
+// and its commands (recursing into the sub-specs) are boxes, the
+// options are ellipses attached to the program or to the commands they
+// are scoped to. Dashed edges show the options an option requires and
+// dotted edges the options it conflicts with. Render it with e.g.
+// "dot -Tsvg".
+func (spec *Spec) WriteDot(w io.Writer) error {
+	var b strings.Builder
+
+	title := spec.title()
+	fmt.Fprintf(&b, "digraph %s {\n", strconv.Quote(title))
+	b.WriteString("    rankdir=LR;\n")
+	b.WriteString("    node [shape=box];\n")
+	spec.dotBody(&b, title)
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Write the nodes and edges of spec; 'id' is the node of the program
+// or command that spec describes and prefixes the ids of its children.
+func (spec *Spec) dotBody(b *strings.Builder, id string) {
+	fmt.Fprintf(b, "    %s [label=%s];\n", strconv.Quote(id), strconv.Quote(id[strings.LastIndexByte(id, ' ')+1:]))
+
+	optid := func(nm string) string {
+		return strconv.Quote(id + " opt:" + nm)
+	}
+
+	for _, o := range spec.optlist {
+		if o.isenv {
+			continue
+		}
+
+		label := o.flagText()
+		if len(label) == 0 {
+			label = o.name
+		}
+		if spec.required[o.name] {
+			label += " (required)"
+		}
+		fmt.Fprintf(b, "    %s [shape=ellipse, label=%s];\n", optid(o.name), strconv.Quote(label))
+
+		if len(o.cmds) == 0 {
+			fmt.Fprintf(b, "    %s -> %s;\n", strconv.Quote(id), optid(o.name))
+		}
+		for _, c := range o.cmds {
+			fmt.Fprintf(b, "    %s -> %s;\n", strconv.Quote(id+" "+c), optid(o.name))
+		}
+	}
+
+	// each conflict is recorded both ways; draw it once
+	seen := make(map[string]bool)
+	for _, o := range spec.optlist {
+		for _, r := range spec.requires[o.name] {
+			fmt.Fprintf(b, "    %s -> %s [style=dashed, label=\"requires\"];\n", optid(o.name), optid(r))
+		}
+		for _, c := range spec.conflictsOf(o.name) {
+			if !seen[c] {
+				fmt.Fprintf(b, "    %s -> %s [style=dotted, dir=none, label=\"conflicts\"];\n", optid(o.name), optid(c))
+			}
+		}
+		seen[o.name] = true
+	}
+
+	for _, c := range spec.cmdlist {
+		cid := id + " " + c.name
+		if sub := spec.subspecs[c.name]; sub != nil {
+			sub.dotBody(b, cid)
+		} else {
+			fmt.Fprintf(b, "    %s [label=%s];\n", strconv.Quote(cid), strconv.Quote(c.name))
+		}
+		fmt.Fprintf(b, "    %s -> %s;\n", strconv.Quote(id), strconv.Quote(cid))
+	}
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,73 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteDot(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command> <args>...
+    --
+    verbose   -v,--verbose                Show more info
+    quiet     -q,--quiet                  Show less info
+    tls       --tls                       Use TLS
+    cert=     --cert=FILE                 Certificate
+    force@rm  -f,--force                  Don't ask
+    [requires tls] cert
+    [conflicts verbose] quiet
+    --
+    --
+    exec      exec                        Run a command
+    rm        rm                          Remove things
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := Parse(`
+    usage: exec [options] <command>
+    --
+    env=      -e,--env=                   Environment
+    --
+    --
+    run       run                         Run it
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = spec.SetCommandSpec("exec", sub); err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err = spec.WriteDot(&b); err != nil {
+		t.Fatal(err)
+	}
+	dot := b.String()
+
+	for _, want := range []string{
+		`digraph "tool" {`,
+		`"tool" -> "tool exec";`,
+		`"tool exec" -> "tool exec run";`,
+		`"tool" -> "tool opt:verbose";`,
+		`"tool rm" -> "tool opt:force";`,
+		`"tool exec" -> "tool exec opt:env";`,
+		`"tool opt:cert" [shape=ellipse, label="--cert=FILE"];`,
+		`"tool opt:tls" -> "tool opt:cert" [style=dashed, label="requires"];`,
+		`"tool opt:verbose" -> "tool opt:quiet" [style=dotted, dir=none, label="conflicts"];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("missing %q in:\n%s", want, dot)
+		}
+	}
+
+	if strings.Contains(dot, `"tool opt:quiet" -> "tool opt:verbose"`) {
+		t.Errorf("conflict drawn twice:\n%s", dot)
+	}
+	if !strings.HasSuffix(dot, "}\n") {
+		t.Errorf("unterminated graph:\n%s", dot)
+	}
+}
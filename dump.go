@@ -0,0 +1,95 @@
+// dump.go - Effective settings for debugging
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+//go:build !tinygo
+
+package options
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Write every option of the spec in declaration order with its
+// effective value (see GetMulti()), whether that is the default and
+// where it came from (see Source()) to 'w' in 'format': "text" (or
+// ""), "json" or "yaml". This answers "what settings am I actually
+// running with?". The values of secret options are redacted (see
+// Redacted()); options without a value are shown as null in JSON and
+// YAML.
+func (opts *Options) Dump(w io.Writer, format string) error {
+	var b strings.Builder
+
+	switch strings.ToLower(format) {
+	case "", "text":
+		opts.dumpText(&b)
+	case "json":
+		opts.dumpItems(&b, "[\n", "  {\"name\": %s, \"value\": %s, \"default\": %v, \"source\": %s}", ",\n", "\n]\n")
+	case "yaml":
+		opts.dumpItems(&b, "", "- name: %s\n  value: %s\n  default: %v\n  source: %s", "\n", "\n")
+	default:
+		return fmt.Errorf("Unsupported dump format: %s", format)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Write the options as aligned columns under a header
+func (opts *Options) dumpText(b *strings.Builder) {
+	rows := [][]string{{"NAME", "VALUE", "SOURCE"}}
+	for _, o := range opts.dumpList() {
+		v := opts.redactedValues(o.name)
+		rows = append(rows, []string{o.name, strings.Join(v, ", "), opts.Source(o.name).String()})
+	}
+
+	var w [2]int
+	for _, r := range rows {
+		w[0] = max(w[0], displayWidth(r[0]))
+		w[1] = max(w[1], displayWidth(r[1]))
+	}
+	for _, r := range rows {
+		fmt.Fprintf(b, "%s  %s  %s\n", padRight(r[0], w[0]), padRight(r[1], w[1]), r[2])
+	}
+}
+
+// Write the options as 'item' in between 'start' and 'end', separated
+// by 'sep', or an empty list if there are none. The values are JSON
+// encoded, which is also valid YAML.
+func (opts *Options) dumpItems(b *strings.Builder, start, item, sep, end string) {
+	list := opts.dumpList()
+	if len(list) == 0 {
+		b.WriteString("[]\n")
+		return
+	}
+
+	items := make([]string, 0, len(list))
+	for _, o := range list {
+		val := "null"
+		if v := opts.redactedValues(o.name); len(v) > 0 {
+			val = opts.configValue(o.name, v)
+		}
+		src := opts.Source(o.name)
+		items = append(items, fmt.Sprintf(item, jsonString(o.name), val, src == SourceDefault, jsonString(src.String())))
+	}
+
+	b.WriteString(start)
+	b.WriteString(strings.Join(items, sep))
+	b.WriteString(end)
+}
+
+// Return the options of the spec that produced opts
+func (opts *Options) dumpList() []*optspec {
+	if opts.spec == nil {
+		return nil
+	}
+	return opts.spec.optlist
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,76 @@
+//go:build !tinygo
+
+package options
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    verbose        -v,--verbose        Verbose
+    root=/var      -r,--root=,ROOT     Data root
+    include=       -I=                 Include dirs
+    ^token=        --token=            API token
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	opts, err := spec.Interpret([]string{"tool", "-v", "-I", "a", "-I", "b", "--token=xyz"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err := opts.Dump(&b, "text"); err != nil {
+		t.Fatal(err)
+	}
+	want := `NAME     VALUE       SOURCE
+verbose  true        cli
+root     /var        default
+include  a, b        cli
+token    <redacted>  cli
+`
+	if b.String() != want {
+		t.Errorf("bad text dump:\nexpected:\n%s\nsaw:\n%s", want, b.String())
+	}
+
+	b.Reset()
+	if err := opts.Dump(&b, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var items []struct {
+		Name    string
+		Value   any
+		Default bool
+		Source  string
+	}
+	if err := json.Unmarshal([]byte(b.String()), &items); err != nil {
+		t.Fatalf("bad json: %s: %s", err, b.String())
+	}
+	if len(items) != 4 || items[0].Value != true || items[1].Value != "/var" || !items[1].Default ||
+		items[1].Source != "default" || items[3].Value != redactedValue {
+		t.Errorf("bad json dump: %s", b.String())
+	}
+
+	b.Reset()
+	if err := opts.Dump(&b, "yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "- name: \"include\"\n  value: [\"a\", \"b\"]\n  default: false\n  source: \"cli\"\n") {
+		t.Errorf("bad yaml dump: %s", b.String())
+	}
+
+	if err := opts.Dump(&b, "xml"); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,43 @@
+// each.go - Options in declaration order
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+// Return the canonical names of the options (and environment section
+// entries) that have a value, as returned by Get(), in the order they
+// are declared in the spec. Unlike ranging over Redacted(), the order
+// is the same on every run, e.g. to write a config file that diffs
+// cleanly.
+func (opts *Options) Names() []string {
+	if opts.spec == nil {
+		return nil
+	}
+
+	var rv []string
+	for _, o := range opts.spec.optlist {
+		if _, ok := opts.Get(o.name); ok {
+			rv = append(rv, o.name)
+		}
+	}
+	return rv
+}
+
+// Call 'fn' with the name and value of each option in Names(); the
+// value is that returned by Get() and 'fromDefault' is true if it is
+// the default (of the spec, a profile or InterpretWithDefaults())
+// rather than a value that was given. Values are not redacted; see
+// Redacted().
+func (opts *Options) Each(fn func(name, value string, fromDefault bool)) {
+	for _, nm := range opts.Names() {
+		v, _ := opts.Get(nm)
+		_, given := opts.options[nm]
+		fn(nm, v, !given)
+	}
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
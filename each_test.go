@@ -0,0 +1,51 @@
+package options
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEach(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    workers=4  -w,--workers=              Number of workers
+    verbose    -v,--verbose               Show more info
+    root=      -r,--root=                 Data root
+    level=info -l,--level=                Log level
+    out=       -o,--out=                  Output file
+    --
+    HOME=      HOME                       Home directory
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		opts, err := spec.Interpret([]string{"tool", "-r", "/data", "-v", "--out="}, []string{"HOME=/home/u"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{"workers", "verbose", "root", "level", "out", "HOME"}
+		if n := opts.Names(); !slices.Equal(n, want) {
+			t.Fatalf("expected %q, saw %q", want, n)
+		}
+
+		var seen []string
+		opts.Each(func(name, value string, fromDefault bool) {
+			s := name + "=" + value
+			if fromDefault {
+				s += "*"
+			}
+			seen = append(seen, s)
+		})
+		w := "workers=4* verbose=true root=/data level=info* out= HOME=/home/u"
+		if s := strings.Join(seen, " "); s != w {
+			t.Errorf("expected %q, saw %q", w, s)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// enum.go - Options that take one of a set of values
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Restrict option 'nm' to the keys of 'mapping': Interpret() rejects
+// any other value, and the keys are offered for completion unless a
+// Completer is registered. GetEnum() returns the constant the value
+// maps to, e.g.
+//
+//	modes := map[string]Mode{"fast": Fast, "safe": Safe}
+//	options.SetEnum(spec, "mode", modes)
+//	...
+//	mode, _ := options.GetEnum(opts, "mode", modes)
+func SetEnum[T any](spec *Spec, nm string, mapping map[string]T) error {
+	o := spec.optinfo[nm]
+	if o == nil || o.isenv || spec.flags[nm] {
+		return fmt.Errorf("Unknown option: %s", nm)
+	}
+
+	choices := make([]string, 0, len(mapping))
+	for k := range mapping {
+		choices = append(choices, k)
+	}
+	sort.Strings(choices)
+
+	if v, ok := spec.defaults[nm]; ok {
+		if _, ok := mapping[v]; !ok {
+			return fmt.Errorf("Invalid default: %s is not a valid %s", v, nm)
+		}
+	}
+
+	o.choices = choices
+	return nil
+}
+
+// Return the constant that the value of option 'nm' maps to in
+// 'mapping'. The second retval will be false if the value isn't in
+// the mapping or the key is not found.
+func GetEnum[T any](opts *Options, nm string, mapping map[string]T) (T, bool) {
+	var zero T
+
+	v, ok := opts.Get(nm)
+	if !ok {
+		return zero, false
+	}
+	t, ok := mapping[v]
+	return t, ok
+}
+
+// Return the choices listed at the end of the description 'desc' of
+// an option, as in "Output format {json,yaml,text}", or nil if there
+// are none.
+func parseChoices(desc string) []string {
+	desc = strings.TrimRight(desc, " \t")
+	if !strings.HasSuffix(desc, "}") {
+		return nil
+	}
+
+	i := strings.LastIndexByte(desc, '{')
+	if i < 0 || (i > 0 && desc[i-1] != ' ' && desc[i-1] != '\t') {
+		return nil
+	}
+
+	choices := strings.Split(desc[i+1:len(desc)-1], ",")
+	if len(choices) < 2 {
+		return nil
+	}
+	for k, c := range choices {
+		c = strings.TrimSpace(c)
+		if len(c) == 0 || strings.ContainsAny(c, " \t{}") {
+			return nil
+		}
+		choices[k] = c
+	}
+	return choices
+}
+
+// Verify that the default and implicit values of option 'o' are among
+// the choices declared in the spec.
+func (spec *Spec) checkDefaultChoice(o *optspec) error {
+	if len(o.choices) == 0 {
+		return nil
+	}
+
+	for _, v := range []string{spec.defaults[o.name], o.implicit} {
+		if len(v) > 0 && !slices.Contains(o.choices, v) {
+			return fmt.Errorf("Invalid option spec: %s of %s is not one of {%s}", v, o.name, strings.Join(o.choices, ","))
+		}
+	}
+	return nil
+}
+
+// Verify that 'value' is one of the choices of option 'o'
+func (spec *Spec) checkChoice(o *optspec, arg, value string) error {
+	for _, c := range o.choices {
+		if c == value {
+			return nil
+		}
+	}
+	return spec.optError(MsgBadChoice, o.name, arg, arg, spec.redact(o.name, value), orList(o.choices))
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,147 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+type mode int
+
+const (
+	modeFast mode = iota + 1
+	modeSafe
+	modeDebug
+)
+
+func TestEnum(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    mode=safe  -m,--mode=MODE,TOOL_MODE   Operating mode
+    other=     --other=                   Other
+    verbose    -v                         Verbose
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	modes := map[string]mode{"fast": modeFast, "safe": modeSafe, "debug": modeDebug}
+	if err = SetEnum(spec, "mode", modes); err != nil {
+		t.Fatal(err)
+	}
+	if err = SetEnum(spec, "verbose", modes); err == nil {
+		t.Error("expected an error for a flag")
+	}
+	if err = SetEnum(spec, "mode", map[string]mode{"fast": modeFast}); err == nil {
+		t.Error("expected an error for a default that isn't a choice")
+	}
+
+	opts, err := spec.Interpret([]string{"tool"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := GetEnum(opts, "mode", modes); !ok || m != modeSafe {
+		t.Errorf("expected the default, saw %v", m)
+	}
+
+	opts, err = spec.Interpret([]string{"tool", "--mode=debug"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := GetEnum(opts, "mode", modes); !ok || m != modeDebug {
+		t.Errorf("expected debug, saw %v", m)
+	}
+	if _, ok := GetEnum(opts, "other", modes); ok {
+		t.Error("expected no value for an unset option")
+	}
+
+	_, err = spec.Interpret([]string{"tool", "-m", "slow"}, nil)
+	if want := "Invalid option: -m: slow is not one of debug, fast or safe"; err == nil || err.Error() != want {
+		t.Errorf("expected %q, saw %v", want, err)
+	}
+	if _, err = spec.Interpret([]string{"tool"}, []string{"TOOL_MODE=x"}); err == nil {
+		t.Error("expected an error for a bad env value")
+	}
+
+	if c := spec.Complete([]string{"--mode=f"}); len(c) != 1 || c[0] != "--mode=fast" {
+		t.Errorf("bad completion %v", c)
+	}
+	if h, _ := spec.OptionHelp("mode"); !strings.Contains(h, "Choices:     debug, fast, safe") {
+		t.Errorf("choices missing from the help:\n%s", h)
+	}
+}
+
+func TestSpecChoices(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    format=json  -f,--format=         Output format {json,yaml,text}
+    color[=on]   --color              Colors { on, off }
+    name=        --name=              Name {not choices}
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	if !strings.Contains(spec.Usage(), "{json,yaml,text}") {
+		t.Errorf("choices missing from usage:\n%s", spec.Usage())
+	}
+	if h, _ := spec.OptionHelp("format"); !strings.Contains(h, "json, yaml, text") {
+		t.Errorf("choices missing from help:\n%s", h)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-f", "yaml", "--color", "--name", "x"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("format"); v != "yaml" {
+		t.Errorf("bad format %q", v)
+	}
+
+	_, err = spec.Interpret([]string{"tool", "--format=xml"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "xml") {
+		t.Errorf("expected an invalid choice error, saw %v", err)
+	}
+	if _, err = spec.Interpret([]string{"tool", "--color=dim"}, nil); err == nil {
+		t.Error("expected an invalid choice error for color")
+	}
+
+	_, err = Parse(`
+    usage: tool [options]
+    --
+    format=xml  -f,--format=         Output format {json,yaml,text}
+    --
+    --
+    --
+    `)
+	if err == nil {
+		t.Error("expected an error for a default that isn't a choice")
+	}
+}
+
+func TestParseChoices(t *testing.T) {
+	tests := map[string]string{
+		"Output format {json,yaml,text}": "json|yaml|text",
+		"Colors { on, off }  ":           "on|off",
+		"{a,b}":                          "a|b",
+		"Name {single}":                  "",
+		"Map key{a,b}":                   "",
+		"Empty {a,,b}":                   "",
+		"Text {with words, here}":        "",
+		"No choices":                     "",
+	}
+
+	for in, want := range tests {
+		if got := strings.Join(parseChoices(in), "|"); got != want {
+			t.Errorf("%q: expected %q, saw %q", in, want, got)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// env.go - Environment section entries
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+// Verify that the required entries of the environment section (e.g.
+// "!TOKEN=  TOKEN") were set in the environment; neither the command
+// line nor a config file can supply them. A default satisfies them
+// only with SetDefaultSatisfiesRequired().
+func (spec *Spec) checkRequiredEnv(opts *Options) error {
+	if spec.legacy {
+		return nil
+	}
+
+	for _, o := range spec.optlist {
+		if !o.isenv || !spec.required[o.name] || opts.sources[o.name] == SourceEnv {
+			continue
+		}
+		if _, ok := opts.defaults[o.name]; ok && spec.default_required {
+			continue
+		}
+		return spec.optError(MsgMissingEnv, o.name, "", orList(o.env))
+	}
+	return nil
+}
+
+// Return the value of option 'nm' only if it came from the environment
+// (see Source()); defaults, config files and the command line are
+// ignored. The bool retval will be false otherwise.
+func (opts *Options) GetEnv(nm string) (string, bool) {
+	if opts.sources[nm] != SourceEnv {
+		return "", false
+	}
+	v, ok := opts.options[nm]
+	return v, ok
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,206 @@
+// errors.go - Structured errors
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Where an option value (or the input that caused an error) came from
+type Source int
+
+const (
+	SourceNone Source = iota
+	SourceDefault
+	SourceConfig
+	SourceEnv
+	SourceCommandLine
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceDefault:
+		return "default"
+	case SourceConfig:
+		return "config"
+	case SourceEnv:
+		return "env"
+	case SourceCommandLine:
+		return "cli"
+	}
+	return "none"
+}
+
+// The error returned by Interpret(). Besides the message, it carries
+// the context of the failure so that applications and tests can check
+// the precise cause rather than match the text.
+type Error struct {
+	// the message key (one of the Msg* constants)
+	Key string
+
+	// the offending argument or environment variable
+	Token string
+
+	// the canonical name of the option involved, if any
+	Option string
+
+	// where Token came from
+	Source Source
+
+	// the index of Token in the args given to Interpret(); -1 if it
+	// didn't come from there
+	Index int
+
+	// the closest valid alternatives to Token, if any
+	Suggestions []string
+
+	// the underlying error, if any
+	Err error
+
+	msg string
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// The classes of errors returned by Interpret(). An *Error matches
+// (with errors.Is()) the class of its Key, so callers can branch on
+// the kind of failure and use errors.As() to get the details:
+//
+//	var e *options.Error
+//	if errors.Is(err, options.ErrUnknownOption) && errors.As(err, &e) {
+//		...e.Token, e.Index, e.Suggestions
+//	}
+//
+// ErrDeprecated is the class of the warnings from Options.Warnings().
+var (
+	ErrUnknownOption   = errors.New("unknown option")
+	ErrMissingRequired = errors.New("missing required option")
+	ErrMissingValue    = errors.New("missing option value")
+	ErrUnknownCommand  = errors.New("unknown command")
+	ErrBadValue        = errors.New("invalid option value")
+	ErrConflict        = errors.New("conflicting options")
+	ErrTooMany         = errors.New("too many arguments")
+	ErrDeprecated      = errors.New("deprecated option")
+)
+
+// The error class of each message key
+var errorClasses = map[string]error{
+	MsgUnknownOption:   ErrUnknownOption,
+	MsgUnknownOptHint:  ErrUnknownOption,
+	MsgAmbiguousOpt:    ErrUnknownOption,
+	MsgScopedOption:    ErrUnknownOption,
+	MsgUnknownDefault:  ErrUnknownOption,
+	MsgRemovedOption:   ErrUnknownOption,
+	MsgUnknownConfig:   ErrUnknownOption,
+	MsgMissingOption:   ErrMissingRequired,
+	MsgMissingOptions:  ErrMissingRequired,
+	MsgMissingCommand:  ErrMissingRequired,
+	MsgTooFewRepeats:   ErrMissingRequired,
+	MsgTooFewRepeats1:  ErrMissingRequired,
+	MsgMissingEnv:      ErrMissingRequired,
+	MsgMissingOneOf:    ErrMissingRequired,
+	MsgRequiresOption:  ErrMissingRequired,
+	MsgMissingArg:      ErrMissingRequired,
+	MsgNeedsValue:      ErrMissingValue,
+	MsgUnknownArg:      ErrUnknownCommand,
+	MsgUnknownCommand:  ErrUnknownCommand,
+	MsgNoCommand:       ErrUnknownCommand,
+	MsgAmbiguousCmd:    ErrUnknownCommand,
+	MsgNoValue:         ErrBadValue,
+	MsgBadValue:        ErrBadValue,
+	MsgBadChoice:       ErrBadValue,
+	MsgValueTooLong:    ErrBadValue,
+	MsgBadPath:         ErrBadValue,
+	MsgBadConstraint:   ErrBadValue,
+	MsgUnknownProfile:  ErrBadValue,
+	MsgBadFlagsEnv:     ErrBadValue,
+	MsgFuncFailed:      ErrBadValue,
+	MsgBadArgFile:      ErrBadValue,
+	MsgBadSecret:       ErrBadValue,
+	MsgConflictsOption: ErrConflict,
+	MsgTooManyOneOf:    ErrConflict,
+	MsgTooManyArgs:     ErrTooMany,
+	MsgTooManyRepeats:  ErrTooMany,
+	MsgTooManyRepeats1: ErrTooMany,
+	MsgDeprecated:      ErrDeprecated,
+	MsgMigratedValue:   ErrDeprecated,
+}
+
+// Report whether 'target' is the error class of e (see ErrUnknownOption
+// etc.)
+func (e *Error) Is(target error) bool {
+	c, ok := errorClasses[e.Key]
+	return ok && c == target
+}
+
+// The error returned by Parse() for a malformed spec line. It lets a
+// tool that embeds or generates specs point at the broken line:
+//
+//	var e *options.SpecError
+//	if errors.As(err, &e) {
+//		fmt.Fprintf(os.Stderr, "%s:%d: %s\n\t%s\n", file, e.Line, e.Err, e.Text)
+//	}
+//
+// Errors found only once the whole spec is read (e.g. a rule naming an
+// unknown option) are not tied to a line and are returned as is.
+type SpecError struct {
+	// the line number in the spec, counting from 1
+	Line int
+
+	// the offending line, trimmed of surrounding blanks
+	Text string
+
+	// the underlying error
+	Err error
+}
+
+func (e *SpecError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *SpecError) Unwrap() error {
+	return e.Err
+}
+
+// Return the error for message 'key' about option 'nm' supplied by
+// 'tok'; the message is formatted with 'args'.
+func (spec *Spec) optError(key, nm, tok string, args ...any) error {
+	// the argument of a secret option may carry its value
+	if s := spec.redactArg(nm, tok); s != tok {
+		for i, a := range args {
+			if a == tok {
+				args[i] = s
+			}
+		}
+		tok = s
+	}
+
+	e := spec.errorf(key, args...).(*Error)
+	e.Option = nm
+	e.Token = tok
+	return e
+}
+
+// Record where the input that caused 'err' came from, unless the error
+// already knows.
+func locate(err error, src Source, index int) {
+	if e, ok := err.(*Error); ok && e.Source == SourceNone {
+		e.Source = src
+		e.Index = index
+	}
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,217 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorContext(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command>
+    --
+    root=     -r,--root=,TOOL_ROOT        Data root
+    zone=:tz  -z,--zone=,TOOL_TZ          Time zone
+    debug     -d,--debug                  Debug
+    --
+    --
+    commit    commit                      Commit
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetFlagsEnv("TOOL_FLAGS")
+
+	tests := []struct {
+		args []string
+		env  []string
+		want Error
+	}{
+		{[]string{"-d", "--nope"}, nil, Error{Key: MsgUnknownOption, Token: "--nope", Source: SourceCommandLine, Index: 2}},
+		{[]string{"-d", "-r"}, nil, Error{Key: MsgNeedsValue, Token: "-r", Option: "root", Source: SourceCommandLine, Index: 2}},
+		{[]string{"--debug=1"}, nil, Error{Key: MsgNoValue, Token: "--debug=1", Option: "debug", Source: SourceCommandLine, Index: 1}},
+		{[]string{"-d", "comit"}, []string{"TOOL_FLAGS=-d"}, Error{Key: MsgUnknownCommand, Token: "comit", Source: SourceCommandLine, Index: 2, Suggestions: []string{"commit"}}},
+		{nil, []string{"TOOL_TZ=Nowhere"}, Error{Key: MsgBadValue, Token: "TOOL_TZ", Option: "zone", Source: SourceEnv, Index: -1}},
+		{nil, []string{"TOOL_FLAGS=--zone=Nowhere"}, Error{Key: MsgBadValue, Token: "--zone=Nowhere", Option: "zone", Source: SourceEnv, Index: -1}},
+	}
+
+	for i, tc := range tests {
+		_, err := spec.Interpret(append([]string{"tool"}, tc.args...), tc.env)
+
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%d: expected an *Error, saw %v", i, err)
+			continue
+		}
+
+		want := tc.want
+		if e.Key != want.Key || e.Token != want.Token || e.Option != want.Option ||
+			e.Source != want.Source || e.Index != want.Index ||
+			fmt.Sprint(e.Suggestions) != fmt.Sprint(want.Suggestions) {
+			t.Errorf("%d: expected %+v\n\tsaw %+v", i, want, *e)
+		}
+	}
+
+	fnErr := errors.New("boom")
+	spec.Func("root", func(string) error { return fnErr })
+	_, err = spec.Interpret([]string{"tool", "-r", "x"}, nil)
+	if !errors.Is(err, fnErr) {
+		t.Errorf("expected the callback error to be wrapped, saw %v", err)
+	}
+}
+
+func TestErrorClasses(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command>
+    --
+    !root=    -r,--root=                  Data root
+    zone=:tz  -z,--zone=                  Time zone
+    debug     -d,--debug                  Debug
+    --
+    --
+    commit    commit                      Commit
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		want error
+	}{
+		{[]string{"-r", "x", "--nope"}, ErrUnknownOption},
+		{[]string{"-r", "x", "--debgu"}, ErrUnknownOption},
+		{[]string{"commit"}, ErrMissingRequired},
+		{[]string{"-r"}, ErrMissingValue},
+		{[]string{"-r", "x", "comit"}, ErrUnknownCommand},
+		{[]string{"-r", "x", "-z", "Nowhere"}, ErrBadValue},
+		{[]string{"-r", "x", "--debug=1"}, ErrBadValue},
+	}
+
+	classes := []error{ErrUnknownOption, ErrMissingRequired, ErrMissingValue, ErrUnknownCommand, ErrBadValue}
+	for _, tc := range tests {
+		_, err := spec.Interpret(append([]string{"tool"}, tc.args...), nil)
+		for _, c := range classes {
+			if errors.Is(err, c) != (c == tc.want) {
+				t.Errorf("%v: errors.Is(%v, %v) = %v", tc.args, err, c, !(c == tc.want))
+			}
+		}
+	}
+
+	_, err = spec.Interpret([]string{"tool"}, nil)
+	var e *Error
+	if !errors.As(err, &e) || e.Option != "root" {
+		t.Errorf("expected the missing option to be named, saw %#v", err)
+	}
+}
+
+func TestErrorClassesComplete(t *testing.T) {
+	// The keys of help text rather than errors
+	text := map[string]bool{
+		MsgOptionsHeading:  true,
+		MsgEnvHeading:      true,
+		MsgCommandsHeading: true,
+		MsgAliasesLine:     true,
+		MsgUsageLine:       true,
+		MsgDefaultNote:     true,
+		MsgEnvNote:         true,
+		MsgChoicesNote:     true,
+		MsgConstraintNote:  true,
+		MsgRequiredNote:    true,
+		MsgDefaultCmdNote:  true,
+		MsgPresetHelp:      true,
+	}
+
+	want := map[string]error{
+		MsgUnknownOption:   ErrUnknownOption,
+		MsgUnknownOptHint:  ErrUnknownOption,
+		MsgAmbiguousOpt:    ErrUnknownOption,
+		MsgScopedOption:    ErrUnknownOption,
+		MsgUnknownDefault:  ErrUnknownOption,
+		MsgRemovedOption:   ErrUnknownOption,
+		MsgUnknownConfig:   ErrUnknownOption,
+		MsgMissingOption:   ErrMissingRequired,
+		MsgMissingOptions:  ErrMissingRequired,
+		MsgMissingCommand:  ErrMissingRequired,
+		MsgTooFewRepeats:   ErrMissingRequired,
+		MsgTooFewRepeats1:  ErrMissingRequired,
+		MsgMissingEnv:      ErrMissingRequired,
+		MsgMissingOneOf:    ErrMissingRequired,
+		MsgRequiresOption:  ErrMissingRequired,
+		MsgMissingArg:      ErrMissingRequired,
+		MsgNeedsValue:      ErrMissingValue,
+		MsgUnknownArg:      ErrUnknownCommand,
+		MsgUnknownCommand:  ErrUnknownCommand,
+		MsgNoCommand:       ErrUnknownCommand,
+		MsgAmbiguousCmd:    ErrUnknownCommand,
+		MsgNoValue:         ErrBadValue,
+		MsgBadValue:        ErrBadValue,
+		MsgBadChoice:       ErrBadValue,
+		MsgValueTooLong:    ErrBadValue,
+		MsgBadPath:         ErrBadValue,
+		MsgBadConstraint:   ErrBadValue,
+		MsgUnknownProfile:  ErrBadValue,
+		MsgBadFlagsEnv:     ErrBadValue,
+		MsgFuncFailed:      ErrBadValue,
+		MsgBadArgFile:      ErrBadValue,
+		MsgBadSecret:       ErrBadValue,
+		MsgConflictsOption: ErrConflict,
+		MsgTooManyOneOf:    ErrConflict,
+		MsgTooManyArgs:     ErrTooMany,
+		MsgTooManyRepeats:  ErrTooMany,
+		MsgTooManyRepeats1: ErrTooMany,
+		MsgDeprecated:      ErrDeprecated,
+		MsgMigratedValue:   ErrDeprecated,
+	}
+
+	classes := []error{ErrUnknownOption, ErrMissingRequired, ErrMissingValue, ErrUnknownCommand, ErrBadValue, ErrConflict, ErrTooMany, ErrDeprecated}
+	for k := range DefaultMessages {
+		if text[k] {
+			continue
+		}
+		w, ok := want[k]
+		if !ok {
+			t.Errorf("message %q has no expected error class", k)
+			continue
+		}
+		e := &Error{Key: k}
+		for _, c := range classes {
+			if errors.Is(e, c) != (c == w) {
+				t.Errorf("%s: errors.Is(%v) = %v", k, c, !(c == w))
+			}
+		}
+	}
+}
+
+func TestSpecError(t *testing.T) {
+	desc := `
+    Usage: tool [options]
+
+    --
+    d,-d,--debug  Enable debug
+    r,-r=DIR      Data root
+    z(3..1) -z    Bad count
+    --
+    --
+    `
+
+	_, err := Parse(desc)
+	var e *SpecError
+	if !errors.As(err, &e) {
+		t.Fatalf("expected a SpecError, saw %#v", err)
+	}
+	if e.Line != 7 || e.Text != "z(3..1) -z    Bad count" {
+		t.Errorf("expected line 7, saw %d %q", e.Line, e.Text)
+	}
+	if err.Error() != fmt.Sprintf("line 7: %s", e.Err) {
+		t.Errorf("bad message %q", err)
+	}
+
+	// errors in the spec as a whole have no line
+	_, err = Parse("usage\n--\nr -r  R\n[requires r] nope\n--\n--\n--\n")
+	if err == nil || errors.As(err, &e) {
+		t.Errorf("expected an error without a line, saw %#v", err)
+	}
+}
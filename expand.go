@@ -0,0 +1,83 @@
+// expand.go - Option value expansion
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"os"
+	"strings"
+)
+
+// Return 'value' of option 'nm' (supplied by 'arg') expanded as its
+// value type asks for: "path" (and the "file" and "dir" types)
+// replaces a leading "~" with the home directory and $VAR or ${VAR}
+// with the variables in 'environ';
+// "secret" replaces "file:PATH" with the contents of PATH, without the
+// trailing newline, so that credentials stay off the command line.
+func (spec *Spec) expand(nm, arg, value string, environ []string) (string, error) {
+	o := spec.optinfo[nm]
+	if o == nil || len(value) == 0 {
+		return value, nil
+	}
+
+	switch o.vtype {
+	case "path", "file", "dir", "dir!":
+		if value == "~" || strings.HasPrefix(value, "~/") {
+			home, ok := envFind(environ, "HOME", spec.env_fold)
+			if !ok {
+				home, _ = os.UserHomeDir()
+			}
+			value = home + value[1:]
+		}
+		value = os.Expand(value, func(v string) string {
+			s, _ := envFind(environ, v, spec.env_fold)
+			return s
+		})
+
+	case "secret":
+		if fn, ok := strings.CutPrefix(value, "file:"); ok {
+			b, err := os.ReadFile(fn)
+			if err != nil {
+				return "", spec.optError(MsgBadSecret, nm, arg, arg, err)
+			}
+			value = strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r")
+		}
+	}
+	return value, nil
+}
+
+// Expand the defaults of the options in opts that have no value
+func (spec *Spec) expandDefaults(opts *Options, environ []string) error {
+	var defs map[string]string
+	for k, v := range opts.defaults {
+		if o := spec.optinfo[k]; o == nil || (o.vtype != "secret" && !isPathType(o.vtype)) || opts.IsSet(k) {
+			continue
+		}
+
+		x, err := spec.expand(k, k, v, environ)
+		if err != nil {
+			return err
+		}
+		if x != v {
+			if defs == nil {
+				defs = make(map[string]string, len(opts.defaults))
+				for k, v := range opts.defaults {
+					defs[k] = v
+				}
+			}
+			defs[k] = x
+		}
+	}
+
+	if defs != nil {
+		opts.defaults = defs
+	}
+	return nil
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
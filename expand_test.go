@@ -0,0 +1,66 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=~/data:path    -r,--root=,TOOL_ROOT    Data root
+    cache=:path         --cache=                Cache dir
+    token=:secret       --token=,TOOL_TOKEN     API token
+    name=               --name=                 Name
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "token")
+	if err = os.WriteFile(fn, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	env := []string{"HOME=/home/me", "TMP=/tmp"}
+	opts, err := spec.Interpret([]string{"tool", "--cache=${TMP}/c-$NOPE", "--token=file:" + fn, "--name=~/$TMP"}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"root":  "/home/me/data",
+		"cache": "/tmp/c-",
+		"token": "s3cret",
+		"name":  "~/$TMP",
+	}
+	for nm, want := range tests {
+		if v, _ := opts.Get(nm); v != want {
+			t.Errorf("%s: expected %q, saw %q", nm, want, v)
+		}
+	}
+
+	env = append(env, "TOOL_ROOT=~", "TOOL_TOKEN=plain")
+	opts, err = spec.Interpret([]string{"tool"}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("root"); v != "/home/me" {
+		t.Errorf("bad root from env: %q", v)
+	}
+	if v, _ := opts.Get("token"); v != "plain" {
+		t.Errorf("bad token from env: %q", v)
+	}
+
+	_, err = spec.Interpret([]string{"tool", "--token=file:" + filepath.Join(dir, "none")}, env)
+	if e, ok := err.(*Error); !ok || e.Key != MsgBadSecret || e.Option != "token" {
+		t.Errorf("bad secret error: %v", err)
+	}
+}
@@ -0,0 +1,325 @@
+// format.go - Formatted usage rendering
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The widest first column of the formatted usage; longer entries put
+// their description on the next line.
+const maxFlagColumn = 32
+
+// Make PrintUsage(), PrintUsageWithError() and the built-in help (see
+// SetAutoHelp()) print FormatUsage() instead of the usage text as
+// written in the spec.
+func (spec *Spec) SetFormatUsage(on bool) {
+	spec.format_usage = on
+}
+
+// Set the width that FormatUsage() wraps the descriptions at; 0 (the
+// default) uses $COLUMNS, or else 80. The terminal itself isn't
+// queried, so $COLUMNS must be exported for the usage to follow it.
+func (spec *Spec) SetUsageWidth(n int) {
+	spec.usage_width = n
+}
+
+// Return the usage generated from the spec rather than echoed from it:
+// the usage section, then the options (by group, see OptionGroups()),
+// environment variables and commands in aligned columns with their
+// descriptions wrapped to the usage width (see SetUsageWidth()), and
+// finally the appendix. The descriptions show the default, environment
+// variables, choices and whether the option is required, e.g. "Data
+// root (default: /var) (env: ROOT) (required)", and the default command
+// is marked as such. Options scoped to a command are listed under it;
+// undocumented entries ("-") are left out.
+func (spec *Spec) FormatUsage() string {
+	return spec.formatUsage(spec.useColor(spec.IsTerminal()))
+}
+
+// Return the formatted usage, colored if 'color' is true
+func (spec *Spec) formatUsage(color bool) string {
+	f := &usageFormatter{width: spec.usageWidth()}
+
+	lines := append([]string{}, spec.aboutLines()...)
+
+	var opts, envs []*optspec
+	for _, o := range spec.optlist {
+		if len(o.usage) == 0 {
+			continue
+		}
+		switch {
+		case o.isenv:
+			envs = append(envs, o)
+		case len(o.cmds) == 0:
+			opts = append(opts, o)
+		}
+	}
+
+	for _, g := range append([]string{""}, spec.groups...) {
+		title := g + ":"
+		if len(g) == 0 {
+			title = spec.text(MsgOptionsHeading)
+		}
+		for _, o := range opts {
+			if o.group == g {
+				f.add(2, spec.flagNames(o, color), spec.optionNotes(o, color))
+			}
+		}
+		if len(g) == 0 {
+			for _, names := range spec.presetlist {
+				f.add(2, paint(color, sgrBold, strings.Join(names, ", ")), spec.presetHelp(names))
+			}
+		}
+		if len(f.rows) > 0 {
+			lines = append(lines, "", title)
+			lines = append(lines, f.flush()...)
+		}
+	}
+
+	if len(envs) > 0 {
+		lines = append(lines, "", spec.text(MsgEnvHeading))
+		for _, o := range envs {
+			f.add(2, paint(color, sgrBold, strings.Join(o.env, ", ")), spec.optionNotes(o, color))
+		}
+		lines = append(lines, f.flush()...)
+	}
+
+	var cmds []*cmdspec
+	for _, c := range spec.cmdlist {
+		if len(c.usage) > 0 {
+			cmds = append(cmds, c)
+		}
+	}
+
+	if len(cmds) > 0 {
+		lines = append(lines, "", spec.text(MsgCommandsHeading))
+		for _, c := range cmds {
+			help := c.help
+			if c.name == spec.default_cmd {
+				help = strings.TrimSpace(help + " " + spec.text(MsgDefaultCmdNote))
+			}
+			f.add(2, paint(color, sgrBold, strings.Join(c.aliases, ", ")), help)
+			for _, o := range spec.optlist {
+				if len(o.usage) > 0 && !o.isenv && slices.Contains(o.cmds, c.name) {
+					f.add(4, spec.flagNames(o, color), spec.optionNotes(o, color))
+				}
+			}
+		}
+		lines = append(lines, f.flush()...)
+	}
+
+	if app := trimBlank(spec.appendix); len(app) > 0 {
+		lines = append(append(lines, ""), app...)
+	}
+	lines = append(lines, spec.metaFooter()...)
+
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// Return the usage text printed by the Print* functions; 'tty' tells
+// whether it is printed to a terminal.
+func (spec *Spec) usageText(tty bool) string {
+	if s, ok := spec.templateUsage(); ok {
+		return s
+	}
+	if color := spec.useColor(tty); spec.format_usage || color {
+		return spec.formatUsage(color)
+	}
+
+	about := spec.aboutLines()
+	if len(about) == 0 || about[0] == spec.about[0] {
+		return spec.usage
+	}
+	if _, rest, ok := strings.Cut(spec.usage, "\n"); ok {
+		return about[0] + "\n" + rest
+	}
+	return about[0]
+}
+
+// Return the width to wrap the formatted usage at: the one set with
+// SetUsageWidth(), else $COLUMNS, else 80
+func (spec *Spec) usageWidth() int {
+	if spec.usage_width > 0 {
+		return spec.usage_width
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 80
+}
+
+// Return the command line spellings of option 'o' with the value
+// placeholder attached, e.g. ["-r DIR", "--root=DIR"].
+func (spec *Spec) flagColumn(o *optspec) []string {
+	flags := o.visibleFlags()
+	if spec.flags[o.name] {
+		return flags
+	}
+
+	mv := o.metavar
+	if len(mv) == 0 {
+		mv = strings.ToUpper(o.name)
+	}
+
+	w := make([]string, len(flags))
+	for i, f := range flags {
+		switch {
+		case len(o.implicit) > 0:
+			w[i] = f + "[=" + mv + "]"
+		case strings.HasPrefix(f, "--"):
+			w[i] = f + "=" + mv
+		default:
+			w[i] = f + " " + mv
+		}
+	}
+	return w
+}
+
+// Return the first column of option 'o' in the formatted usage: its
+// spellings, in bold (or highlighted if it is required) if 'color' is
+// true.
+func (spec *Spec) flagNames(o *optspec, color bool) string {
+	s := strings.Join(spec.flagColumn(o), ", ")
+	if spec.required[o.name] {
+		return paint(color, sgrRequired, s)
+	}
+	return paint(color, sgrBold, s)
+}
+
+// Return the description of option 'o' followed by the notes about
+// its default (dimmed if 'color' is true), environment variables,
+// choices and whether it is required.
+func (spec *Spec) optionNotes(o *optspec, color bool) string {
+	s := o.help
+
+	note := func(sgr, key string, args ...any) {
+		if len(s) > 0 {
+			s += " "
+		}
+		s += paint(color && len(sgr) > 0, sgr, spec.text(key, args...))
+	}
+
+	if v, ok := spec.defaultValue(o.name); ok {
+		note(sgrDim, MsgDefaultNote, spec.redact(o.name, v))
+	}
+	if len(o.env) > 0 && !o.isenv {
+		note("", MsgEnvNote, strings.Join(o.env, ", "))
+	}
+	if len(o.choices) > 0 && !strings.HasSuffix(o.help, "}") {
+		note("", MsgChoicesNote, strings.Join(o.choices, ", "))
+	}
+	if o.constraint != nil {
+		note("", MsgConstraintNote, o.constraint.text)
+	}
+	if spec.required[o.name] {
+		note("", MsgRequiredNote)
+	}
+	return s
+}
+
+// An entry of the formatted usage: its indent, first column and
+// description
+type usageRow struct {
+	indent int
+	name   string
+	desc   string
+}
+
+// Lays out the rows of a section of the formatted usage in two columns
+type usageFormatter struct {
+	width int
+	rows  []usageRow
+}
+
+// Add a row to the current section
+func (f *usageFormatter) add(indent int, name, desc string) {
+	f.rows = append(f.rows, usageRow{indent, name, desc})
+}
+
+// Return the lines of the current section and start a new one. The
+// descriptions start in the same column, two blanks after the widest
+// first column that fits maxFlagColumn.
+func (f *usageFormatter) flush() []string {
+	col := 8
+	for _, r := range f.rows {
+		if n := r.indent + displayWidth(r.name) + 2; n > col && n <= maxFlagColumn {
+			col = n
+		}
+	}
+
+	var lines []string
+	for _, r := range f.rows {
+		head := strings.Repeat(" ", r.indent) + r.name
+		desc := wrapText(r.desc, f.width-col)
+
+		if displayWidth(head)+2 > col && len(desc) > 0 {
+			lines = append(lines, head)
+			head = ""
+		}
+		if len(desc) == 0 {
+			lines = append(lines, head)
+			continue
+		}
+
+		pad := strings.Repeat(" ", col)
+		for i, d := range desc {
+			switch {
+			case len(d) == 0:
+				lines = append(lines, "")
+			case i == 0:
+				lines = append(lines, padRight(head, col)+d)
+			default:
+				lines = append(lines, pad+d)
+			}
+		}
+	}
+
+	f.rows = nil
+	return lines
+}
+
+// Wrap the paragraphs (separated by blank lines) of 's' into lines of
+// at most 'width' columns; a word wider than that gets a line of its
+// own. Paragraphs are separated by an empty line.
+func wrapText(s string, width int) []string {
+	if width < 20 {
+		width = 20
+	}
+
+	var lines []string
+	for i, para := range strings.Split(s, "\n\n") {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+
+		line, n := "", 0
+		for _, w := range strings.Fields(para) {
+			switch {
+			case len(line) == 0:
+				line, n = w, displayWidth(w)
+			case n+1+displayWidth(w) > width:
+				lines = append(lines, line)
+				line, n = w, displayWidth(w)
+			default:
+				line += " " + w
+				n += 1 + displayWidth(w)
+			}
+		}
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
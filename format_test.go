@@ -0,0 +1,132 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatUsage(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] cmd [args]
+    A tool
+    --
+    !root=/var   -r,--root=DIR,TOOL_ROOT   Data root
+    verbose      -v,--verbose              Verbose output
+    format=json  -f,--format=              Output format {json,yaml}
+    level=       --a-really-long-option-name=N  A long description that needs to be wrapped at the usage width
+    force@rm     --force                   Remove even if busy
+    hidden       --hidden                  -
+    --
+    home=        TOOL_HOME=                Home directory
+    --
+    list         ls,list                   List entries
+    rm           rm                        Remove entries
+    --
+    See the manual for more.
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetUsageWidth(60)
+
+	want := `usage: tool [options] cmd [args]
+A tool
+
+Options:
+  -r DIR, --root=DIR          Data root (default: /var)
+                              (env: TOOL_ROOT) (required)
+  -v, --verbose               Verbose output
+  -f FORMAT, --format=FORMAT  Output format {json,yaml}
+                              (default: json)
+  --a-really-long-option-name=N
+                              A long description that needs
+                              to be wrapped at the usage
+                              width
+
+Environment:
+  TOOL_HOME  Home directory
+
+Commands:
+  ls, list   List entries
+  rm         Remove entries
+    --force  Remove even if busy
+
+See the manual for more.`
+
+	if got := spec.FormatUsage(); got != want {
+		t.Errorf("expected\n%s\nsaw\n%s", want, got)
+	}
+
+	var out, errs strings.Builder
+	spec.SetOutput(&out, &errs)
+	spec.PrintUsage()
+	if out.String() != spec.Usage()+"\n" {
+		t.Errorf("usage was formatted:\n%s", out.String())
+	}
+
+	out.Reset()
+	spec.SetFormatUsage(true)
+	spec.PrintUsage()
+	if out.String() != want+"\n" {
+		t.Errorf("usage wasn't formatted:\n%s", out.String())
+	}
+}
+
+func TestUsageDirectives(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    profile=     --profile=                Defaults profile
+    quiet        -q,--quiet                Quiet
+    verbose      -v,--verbose              Verbose
+    format=text  -f,--format=              Output format
+    dir=         --dir=                    Old data root
+    [profile ci] verbose=true
+    [conflicts quiet] verbose
+    [deprecated --dir] use --root instead
+    [migrate format] plaintext=text
+    [preset --loud,-L] -v --format=json
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the directives are left out of the usage but the presets
+	want := `usage: tool [options]
+
+  --profile=                Defaults profile
+  -q,--quiet                Quiet
+  -v,--verbose              Verbose
+  -f,--format=              Output format
+  --dir=                    Old data root
+  --loud,-L                 Same as -v --format=json`
+
+	if got := spec.Usage(); got != want {
+		t.Errorf("expected\n%s\nsaw\n%s", want, got)
+	}
+
+	want = `usage: tool [options]
+
+Options:
+  --profile=PROFILE           Defaults profile
+  -q, --quiet                 Quiet
+  -v, --verbose               Verbose
+  -f FORMAT, --format=FORMAT  Output format (default: text)
+  --dir=DIR                   Old data root
+  --loud, -L                  Same as -v --format=json`
+
+	if got := spec.FormatUsage(); got != want {
+		t.Errorf("expected\n%s\nsaw\n%s", want, got)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	got := wrapText("one two three four five six seven eight nine ten eleven\n\nsecond   paragraph", 20)
+	want := []string{"one two three four", "five six seven eight", "nine ten eleven", "", "second paragraph"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %q, saw %q", want, got)
+	}
+}
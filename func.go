@@ -0,0 +1,83 @@
+// func.go - Options that invoke callbacks
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"context"
+	"fmt"
+)
+
+// Register 'fn' to be called with the value of option 'nm' every time
+// Interpret() encounters it - in the environment or on the command line
+// - before the rest of the arguments are processed. Flags pass "true"
+// as the value. This is the counterpart of flag.Func: e.g. "--trace"
+// can turn on tracing before anything else is parsed. An error
+// returned by 'fn' fails Interpret(). The value is recorded as usual
+// and remains available to the getters.
+func (spec *Spec) Func(nm string, fn func(value string) error) error {
+	return spec.FuncContext(nm, func(_ context.Context, value string) error {
+		return fn(value)
+	})
+}
+
+// Register 'fn' like Func() but with the context given to
+// InterpretContext() (or context.Background()) as its first argument.
+func (spec *Spec) FuncContext(nm string, fn func(ctx context.Context, value string) error) error {
+	if _, ok := spec.flags[nm]; !ok {
+		return fmt.Errorf("Unknown option: %s", nm)
+	}
+
+	if spec.funcs == nil {
+		spec.funcs = make(map[string]func(context.Context, string) error)
+	}
+	spec.funcs[nm] = fn
+	return nil
+}
+
+// Register 'fn' to observe option 'nm': it is called with the value
+// every time Interpret() assigns the option - from a config file, the
+// environment or the command line - in the order they are seen. Unlike
+// Func(), any number of observers can watch an option and they can't
+// fail Interpret(); they are meant for cross-cutting concerns such as
+// raising the log level as soon as "-v" is seen or counting the use of
+// each option.
+func (spec *Spec) OnSet(nm string, fn func(value string)) error {
+	if _, ok := spec.flags[nm]; !ok {
+		return fmt.Errorf("Unknown option: %s", nm)
+	}
+
+	if spec.observers == nil {
+		spec.observers = make(map[string][]func(string))
+	}
+	spec.observers[nm] = append(spec.observers[nm], fn)
+	return nil
+}
+
+// Call the observers and the callback registered for option 'nm', if
+// any; 'arg' is the argument or env var that supplied 'value'.
+func (spec *Spec) callFunc(ctx context.Context, nm, arg, value string) error {
+	for _, fn := range spec.observers[nm] {
+		fn(value)
+	}
+
+	fn, ok := spec.funcs[nm]
+	if !ok {
+		return nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := fn(ctx, value); err != nil {
+		return spec.optError(MsgFuncFailed, nm, arg, arg, err)
+	}
+	return nil
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
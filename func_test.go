@@ -0,0 +1,163 @@
+package options
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFunc(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    trace     -t,--trace,TOOL_TRACE       Turn on tracing
+    level=    -l,--level=                 Log level
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var seen []string
+	spec.Func("trace", func(v string) error {
+		seen = append(seen, "trace="+v)
+		return nil
+	})
+	spec.Func("level", func(v string) error {
+		if v == "bogus" {
+			return errors.New("unknown level")
+		}
+		seen = append(seen, "level="+v)
+		return nil
+	})
+
+	if err := spec.Func("nope", nil); err == nil {
+		t.Error("expected error for an unknown option")
+	}
+
+	_, err = spec.Interpret([]string{"tool", "-l", "debug", "x", "-t"}, []string{"TOOL_TRACE=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(seen, " ") != "trace=1 level=debug trace=true" {
+		t.Errorf("callbacks out of order: %q", seen)
+	}
+
+	_, err = spec.Interpret([]string{"tool", "--level=bogus"}, nil)
+	if err == nil || err.Error() != "Invalid option: --level=bogus: unknown level" {
+		t.Errorf("expected callback error, saw %v", err)
+	}
+}
+
+func TestInterpretContext(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    user=     -u,--user=                  User name
+    level=    -l,--level=                 Log level
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	type key struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "req-1"))
+
+	var seen []string
+	spec.FuncContext("user", func(ctx context.Context, v string) error {
+		id, _ := ctx.Value(key{}).(string)
+		seen = append(seen, v+":"+id)
+		cancel()
+		return nil
+	})
+
+	args := []string{"tool", "-u", "alice", "-l", "debug"}
+	_, err = spec.InterpretContext(ctx, args, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancellation, saw %v", err)
+	}
+	if len(seen) != 1 || seen[0] != "alice:req-1" {
+		t.Errorf("bad callbacks: %v", seen)
+	}
+
+	if _, err = spec.InterpretContext(ctx, []string{"tool", "-l", "x"}, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancellation, saw %v", err)
+	}
+
+	seen = nil
+	opts, err := spec.InterpretContext(context.Background(), args, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("level"); v != "debug" || len(seen) != 1 || seen[0] != "alice:" {
+		t.Errorf("bad result: %q %v", v, seen)
+	}
+}
+
+func TestOnSet(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    verbose   -v,--verbose,TOOL_VERBOSE   Show more info
+    level=    -l,--level=                 Log level
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var seen []string
+	counts := make(map[string]int)
+	for _, nm := range []string{"verbose", "level"} {
+		spec.OnSet(nm, func(v string) {
+			seen = append(seen, nm+"="+v)
+		})
+		spec.OnSet(nm, func(string) {
+			counts[nm]++
+		})
+	}
+	spec.Func("level", func(v string) error {
+		if v == "bogus" {
+			return errors.New("unknown level")
+		}
+		return nil
+	})
+
+	if err := spec.OnSet("nope", func(string) {}); err == nil {
+		t.Error("expected error for an unknown option")
+	}
+
+	_, err = spec.Interpret([]string{"tool", "-l", "info", "-v", "--level=debug"}, []string{"TOOL_VERBOSE=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "verbose=1 level=info verbose=true level=debug"
+	if s := strings.Join(seen, " "); s != want {
+		t.Errorf("expected %q, saw %q", want, s)
+	}
+	if counts["verbose"] != 2 || counts["level"] != 2 {
+		t.Errorf("bad counts %v", counts)
+	}
+
+	// the observers see a value even if the callback rejects it
+	seen = nil
+	if _, err = spec.Interpret([]string{"tool", "-l", "bogus"}, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(seen) != 1 || seen[0] != "level=bogus" {
+		t.Errorf("bad observations %q", seen)
+	}
+}
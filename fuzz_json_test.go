@@ -0,0 +1,31 @@
+//go:build !tinygo
+
+package options
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func FuzzSpecJSON(f *testing.F) {
+	f.Add(benchSpec)
+	f.Add("usage: x\n--\n  a= -a=\n--\n--\n*\n--\n")
+	f.Add("usage\n--\nc[=a]@c -c\n[requires c] c\n--\nE= E=\n--\nc c,d\n--\n")
+
+	f.Fuzz(func(t *testing.T, desc string) {
+		spec, err := Parse(desc)
+		if err != nil {
+			return
+		}
+
+		// a spec that parses has a valid JSON encoding
+		b, err := spec.MarshalJSON()
+		if err != nil {
+			t.Fatalf("can't encode the spec: %s", err)
+		}
+		var v map[string]any
+		if err = json.Unmarshal(b, &v); err != nil {
+			t.Fatalf("invalid JSON: %s\n%s", err, b)
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package options
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func FuzzParse(f *testing.F) {
+	f.Add(benchSpec)
+	f.Add("usage: x\n--\n  a= -a=\n--\n--\n*\n--\n")
+	f.Add("\n    usage: x\n\n  --\n# c\n#\n")
+	f.Add("usage\n--\nx -x\n  more\n--\nE= E=\n--\nc c,d\n--\nend")
+	f.Add("usage\n@version 1\n--\nc[=a]@c -c\n[deprecated -c 1]\n[requires c] c\n--\n--\nc c\n--\n[epilog c]\nx")
+	f.Add("--\n! 0\n+ 00")
+	f.Add("@0\n 000")
+	f.Add("@\v")
+	f.Add("--\n[deprecated\t0 -")
+
+	f.Fuzz(func(t *testing.T, desc string) {
+		spec, err := Parse(desc)
+		if err != nil {
+			return
+		}
+
+		// a spec that parses must be usable
+		spec.Usage()
+		spec.ShortUsage()
+		spec.MarkdownPages()
+		spec.Complete([]string{"-"})
+		spec.Synopsis()
+		spec.WriteDot(io.Discard)
+		spec.Diff(spec)
+		for _, c := range spec.cmdlist {
+			spec.CommandUsage(c.name)
+		}
+		for _, o := range spec.optlist {
+			spec.OptionHelp(o.name)
+		}
+		spec.FormatUsage()
+		spec.HelpData()
+		spec.Lint()
+		spec.SetSetenv(false)
+		spec.Interpret([]string{"x", "-a", "b", "--", "c"}, []string{"E=1", "X"})
+
+		// the canonical text of a spec parses to the same spec; specs
+		// with duplicates (see Lint()) have no canonical form
+		if len(spec.duplicates()) > 0 {
+			return
+		}
+		text := spec.String()
+		again, err := Parse(text)
+		if err != nil {
+			t.Fatalf("canonical spec doesn't parse: %s\n%s", err, text)
+		}
+		if s := again.String(); s != text {
+			t.Fatalf("canonical spec changed:\n%s\n---\n%s", text, s)
+		}
+	})
+}
+
+func FuzzInterpret(f *testing.F) {
+	spec, err := Parse(benchSpec)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Add(strings.Join(benchArgs, "\x00"), "HARAWAY_DEBUG=1")
+	f.Add("x\x00-r\x00--\x00-", "X")
+	f.Add("x\x00-\x00=\x00--=", "=")
+
+	f.Fuzz(func(t *testing.T, argv, env string) {
+		opts, err := spec.Interpret(strings.Split(argv, "\x00"), strings.Split(env, "\x00"))
+		if err != nil {
+			return
+		}
+
+		opts.GetMulti("include")
+		opts.GetInt("num")
+		opts.Clone()
+	})
+}
+
+func TestNoPanics(t *testing.T) {
+	specs := []string{
+		"",
+		"--",
+		"\t\t\n  \n",
+		"  usage\nx\n--\n",
+		"usage\n--\n[=a]\n--\n",
+		"usage\n--\na[=\n--\n",
+		"usage\n--\na[=]] -a\n--\n",
+		"usage\n--\n@ -a\n--\n",
+		"usage\n--\n[requires]\n--\n",
+		"usage\n--\n[deprecated ]\n--\n",
+		"usage\n--\n[preset ]\n--\n",
+		"usage\n--\n--\n--\n--\n[epilog ]\n",
+		"usage\n--\n#\n  # x\n--\n  \tE\n--\n\t c\n",
+	}
+	for _, s := range specs {
+		spec, err := Parse(s)
+		if err != nil {
+			continue
+		}
+		spec.SetSetenv(false)
+		spec.Usage()
+		spec.Synopsis()
+		spec.Interpret([]string{}, nil)
+		spec.Interpret([]string{"x", "", "-", "--", "="}, []string{"", "=", "=x"})
+	}
+
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    trace     -t,--trace                  Trace
+    exit      -x,--exit                   Exit
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	var failures []string
+	spec.SetMetrics(Metrics{Failure: func(key string) { failures = append(failures, key) }})
+	spec.Func("trace", func(string) error {
+		var m map[string]int
+		m["boom"]++
+		return nil
+	})
+	spec.Func("exit", func(string) error {
+		panic(&ExitError{Code: 3})
+	})
+
+	opts, err := spec.Interpret([]string{"tool", "-t"}, nil)
+	if opts != nil || err == nil || !strings.HasPrefix(err.Error(), "Invalid input: ") {
+		t.Errorf("expected an error for a panic, saw %v %v", opts, err)
+	}
+	if len(failures) != 1 {
+		t.Errorf("expected a failure to be counted, saw %v", failures)
+	}
+
+	// the exit of the js/wasm host is not an error
+	defer func() {
+		if e, ok := recover().(*ExitError); !ok || e.Code != 3 {
+			t.Errorf("expected an exit, saw %v", e)
+		}
+	}()
+	spec.Interpret([]string{"tool", "-x"}, nil)
+	t.Error("expected the exit to propagate")
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		desc string
+		err  string
+	}{
+		{"usage\n--\n! -a  A\n--\n--\n--\n", "line 3: Invalid option spec: missing option name"},
+		{"usage\n--\na -a A\n--\n= E\n--\n--\n", "line 5: Invalid env spec: missing variable name"},
+		{"usage\n@ 1.0\n--\n--\n--\n--\n", "line 2: Invalid metadata: @ 1.0 has no key"},
+		{"usage\n--\n[deprecated\t-a] x\n--\n--\n--\n", "line 3: Invalid option spec: [deprecated is not a valid name"},
+		{"\n    usage\n    --\n    a(2..1) -a  A\n", "line 4: Invalid option spec: a has a bad count (2..1)"},
+		{"usage\n--\na -a A\n[requires a] nope\n--\n--\n--\n", "Invalid rule spec: [requires a]: nope is not a known option"},
+	}
+
+	for _, tc := range tests {
+		_, err := Parse(tc.desc)
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("%q: expected %q, saw %v", tc.desc, tc.err, err)
+		}
+	}
+
+	// tabs separate the columns like blanks
+	spec, err := Parse("usage\n--\nroot=/var\t-r,--root=DIR\tData root\n--\n--\n--\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, _ := spec.OptionHelp("root"); !strings.Contains(h, "--root") {
+		t.Errorf("bad option from a tab separated line:\n%s", h)
+	}
+}
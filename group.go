@@ -0,0 +1,42 @@
+// group.go - Option groups
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+// A group of options started by a "## Title" line in the options
+// section
+type OptionGroup struct {
+	Title string
+
+	// the canonical names of the options in declaration order
+	Options []string
+}
+
+// Return the option groups in declaration order. Options declared
+// before the first "## Title" line belong to no group.
+func (spec *Spec) OptionGroups() []OptionGroup {
+	rv := make([]OptionGroup, 0, len(spec.groups))
+	for _, g := range spec.groups {
+		rv = append(rv, OptionGroup{Title: g, Options: spec.groupOptions(g)})
+	}
+	return rv
+}
+
+// Return the names of the options in group 'g'; an empty 'g' selects
+// the options that belong to no group.
+func (spec *Spec) groupOptions(g string) []string {
+	rv := []string{}
+	for _, o := range spec.optlist {
+		if !o.isenv && o.group == g {
+			rv = append(rv, o.name)
+		}
+	}
+	return rv
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
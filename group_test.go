@@ -0,0 +1,67 @@
+package options
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOptionGroups(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    verbose     -v,--verbose       Verbose
+    ## Network options
+    port=80     -p,--port=         Listen port
+    host=       --host=            Listen address
+    ## Storage options
+    root=       -r,--root=         Data root
+    --
+    home=       TOOL_HOME          Home directory
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []OptionGroup{
+		{"Network options", []string{"port", "host"}},
+		{"Storage options", []string{"root"}},
+	}
+	if g := spec.OptionGroups(); !reflect.DeepEqual(g, want) {
+		t.Errorf("bad groups: %+v", g)
+	}
+
+	usage := `usage: tool [options]
+
+  -v,--verbose       Verbose
+
+Network options:
+  -p,--port=         Listen port
+  --host=            Listen address
+
+Storage options:
+  -r,--root=         Data root
+
+  TOOL_HOME          Home directory`
+	if spec.Usage() != usage {
+		t.Errorf("bad usage:\n%s", spec.Usage())
+	}
+
+	spec.SetUsageWidth(60)
+	f := spec.FormatUsage()
+	for _, s := range []string{"Options:\n  -v, --verbose", "Network options:\n  -p PORT, --port=PORT", "Storage options:\n  -r ROOT, --root=ROOT"} {
+		if !strings.Contains(f, s) {
+			t.Errorf("formatted usage lacks %q:\n%s", s, f)
+		}
+	}
+
+	again, err := Parse(spec.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := again.OptionGroups(); !reflect.DeepEqual(g, want) {
+		t.Errorf("bad groups after reparse: %+v", g)
+	}
+}
@@ -0,0 +1,431 @@
+// help.go - Usage rendering
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Return the full usage string: the text of every section of the spec
+// including the environment variables and the appendix.
+func (spec *Spec) Usage() string {
+	return spec.usage
+}
+
+// Return a compact usage summary: the first line of the usage section
+// followed by the required options and the options marked with a '+'
+// prefix in the spec. This is the rendering meant for "-h", while
+// Usage() is meant for "--help".
+func (spec *Spec) ShortUsage() string {
+	var lines []string
+
+	if len(spec.about) > 0 {
+		lines = append(lines, spec.about[0])
+	}
+
+	var opts []string
+	for _, o := range spec.optlist {
+		if o.isenv || !(o.brief || spec.required[o.name]) {
+			continue
+		}
+		opts = append(opts, o.usage...)
+	}
+
+	if len(opts) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, opts...)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Return the one line synopsis of the program generated from the
+// global options, the commands and the positional arguments (see
+// SetArgs()), e.g. "tool [-v] [-r ROOT] (exec|shell) [ARGS...]".
+// Required options are shown without the brackets. A synopsis wider
+// than the usage width (see SetUsageWidth()) lists the optional
+// options as "[options]" and the commands as "<command>", e.g.
+// "tool [options] -r ROOT <command> <src> [dst]". Unlike the first line
+// of the usage section, it can't drift out of sync with the spec; see
+// SetAutoSynopsis().
+func (spec *Spec) Synopsis() string {
+	var all, required []string
+	for _, o := range spec.optlist {
+		if o.isenv || len(o.cmds) > 0 {
+			continue
+		}
+
+		f := o.shortFlag()
+		if len(f) == 0 {
+			continue
+		}
+
+		w := f
+		if !spec.flags[o.name] {
+			mv := o.metavar
+			if len(mv) == 0 {
+				mv = strings.ToUpper(o.name)
+			}
+			if len(o.implicit) > 0 {
+				w += "[=" + mv + "]"
+			} else if strings.HasPrefix(f, "--") {
+				w += "=" + mv
+			} else {
+				w += " " + mv
+			}
+		}
+
+		if spec.required[o.name] {
+			required = append(required, w)
+		} else {
+			w = "[" + w + "]"
+		}
+		all = append(all, w)
+	}
+
+	cmds := ""
+	if len(spec.cmdlist) > 0 {
+		names := make([]string, 0, len(spec.cmdlist))
+		for _, c := range spec.cmdlist {
+			names = append(names, c.name)
+		}
+		cmds = names[0]
+		if len(names) > 1 {
+			cmds = "(" + strings.Join(names, "|") + ")"
+		}
+	}
+
+	args := spec.argsPattern()
+	syn := synopsisLine(spec.progname(), all, cmds, args)
+	if displayWidth(syn) <= spec.usageWidth() {
+		return syn
+	}
+
+	if len(all) > len(required) {
+		required = append([]string{"[options]"}, required...)
+	}
+	if len(spec.cmdlist) > 1 {
+		cmds = "<command>"
+	}
+	return synopsisLine(spec.progname(), required, cmds, args)
+}
+
+// Join the words of a synopsis
+func synopsisLine(prog string, opts []string, cmds, args string) string {
+	words := append([]string{prog}, opts...)
+	if len(cmds) > 0 {
+		words = append(words, cmds)
+	}
+	return strings.Join(append(words, args), " ")
+}
+
+// Return the positional arguments declared with SetArgs() as a
+// pattern, e.g. "<src> [dst...]"; "[ARGS...]" if none are declared.
+func (spec *Spec) argsPattern() string {
+	if len(spec.positional) == 0 {
+		return "[ARGS...]"
+	}
+
+	words := make([]string, 0, len(spec.positional))
+	for _, a := range spec.positional {
+		w := a.name
+		if a.variadic {
+			w += "..."
+		}
+		if a.optional {
+			w = "[" + w + "]"
+		} else {
+			w = "<" + w + ">"
+		}
+		words = append(words, w)
+	}
+	return strings.Join(words, " ")
+}
+
+// Make the usage (see PrintUsage(), FormatUsage() and the help
+// template) show the generated Synopsis() in place of the line the
+// usage section starts with, if that line is of the form "usage: ...",
+// so that it can't drift out of date as options are added.
+func (spec *Spec) SetAutoSynopsis(on bool) {
+	spec.auto_synopsis = on
+}
+
+// Return the lines of the usage section; the first is the generated
+// synopsis if SetAutoSynopsis() is on.
+func (spec *Spec) aboutLines() []string {
+	if !spec.auto_synopsis || len(progName(spec.about)) == 0 {
+		return spec.about
+	}
+
+	w := strings.Fields(spec.about[0])
+	lines := slices.Clone(spec.about)
+	lines[0] = w[0] + " " + spec.Synopsis()
+	return lines
+}
+
+// Return the shortest command line spelling of 'o': its primary
+// spelling, its first single dash flag, or its canonical flag if it
+// has none.
+func (o *optspec) shortFlag() string {
+	if len(o.primary) > 0 {
+		return o.primary
+	}
+	for _, f := range o.flags {
+		if !strings.HasPrefix(f, "--") {
+			return f
+		}
+	}
+	return o.canonicalFlag()
+}
+
+// Return the synopsis line for command 'cmd' derived from the options
+// scoped to it and from its sub-spec (if any), e.g.
+// "tool exec [options] <command> [args...]". The line is generated so
+// that it can't drift out of sync with the spec.
+func (spec *Spec) CommandSynopsis(cmd string) (string, error) {
+	c, ok := spec.commands[cmd]
+	if !ok {
+		return "", fmt.Errorf("Unknown command: %s", cmd)
+	}
+
+	words := []string{spec.progname(), c}
+	hasopts := false
+	for _, o := range spec.optlist {
+		if !o.isenv && len(o.cmds) > 0 && o.scopedTo(c) {
+			hasopts = true
+			break
+		}
+	}
+
+	sub := spec.subspecs[c]
+	if sub != nil {
+		for _, o := range sub.optlist {
+			if !o.isenv && len(o.flags) > 0 {
+				hasopts = true
+				break
+			}
+		}
+	}
+
+	if hasopts {
+		words = append(words, "[options]")
+	}
+	if sub != nil && len(sub.cmdlist) > 0 {
+		words = append(words, "<command>")
+	}
+	if sub != nil && len(sub.positional) > 0 {
+		words = append(words, sub.argsPattern())
+	} else {
+		words = append(words, "[args...]")
+	}
+	return strings.Join(words, " "), nil
+}
+
+// Return the help for command 'cmd': its synopsis, description,
+// aliases, the options scoped to it, the usage of its sub-spec (if any)
+// and finally its epilog.
+func (spec *Spec) CommandUsage(cmd string) (string, error) {
+	syn, err := spec.CommandSynopsis(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	c := spec.findCommand(cmd)
+	lines := []string{spec.text(MsgUsageLine, syn)}
+	if len(c.help) > 0 {
+		lines = append(lines, "", c.help)
+	}
+	if len(c.aliases) > 1 {
+		lines = append(lines, "", spec.text(MsgAliasesLine, strings.Join(c.aliases, ", ")))
+	}
+	if u := spec.scopedUsage(c.name); len(u) > 0 {
+		lines = append(lines, "", spec.text(MsgOptionsHeading))
+		lines = append(lines, u...)
+	}
+	if sub := spec.subspecs[c.name]; sub != nil {
+		lines = append(lines, "", sub.Usage())
+	}
+	if epi := trimBlank(c.epilog); len(epi) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, epi...)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// Return the command named 'nm' (or one of its aliases) or nil
+func (spec *Spec) findCommand(nm string) *cmdspec {
+	if c, ok := spec.commands[nm]; ok {
+		nm = c
+	}
+	for _, c := range spec.cmdlist {
+		if c.name == nm {
+			return c
+		}
+	}
+	return nil
+}
+
+// Remove the leading and trailing blank lines of 'v'
+func trimBlank(v []string) []string {
+	for len(v) > 0 && len(v[0]) == 0 {
+		v = v[1:]
+	}
+	for len(v) > 0 && len(v[len(v)-1]) == 0 {
+		v = v[:len(v)-1]
+	}
+	return v
+}
+
+// Print the short usage string to STDOUT
+func (spec *Spec) PrintShortUsage() {
+	fmt.Fprintf(spec.outw(), "%s\n", spec.ShortUsage())
+}
+
+// Return the detailed help for a single option: its flags, type,
+// default, environment bindings and full description. 'nm' can be the
+// canonical name of the option, any of its command line spellings
+// (e.g., "--root" or "-r") or an environment variable bound to it.
+// This is meant for "--help=option" style lookups in tools with large
+// option sets.
+func (spec *Spec) OptionHelp(nm string) (string, error) {
+	o := spec.lookupOpt(nm)
+	if o == nil {
+		return "", fmt.Errorf("Unknown option: %s", nm)
+	}
+
+	var b strings.Builder
+
+	typ := "value"
+	if spec.flags[o.name] {
+		typ = "flag"
+	} else if len(o.vtype) > 0 {
+		typ = o.vtype
+	} else if len(o.metavar) > 0 {
+		typ = o.metavar
+	}
+
+	fmt.Fprintf(&b, "%s\n", o.name)
+	if len(o.flags) > 0 {
+		fmt.Fprintf(&b, "  Flags:       %s\n", o.flagText())
+	}
+	if len(o.secondary) > 0 {
+		fmt.Fprintf(&b, "  Aliases:     %s\n", strings.Join(o.secondary, ", "))
+	}
+	fmt.Fprintf(&b, "  Type:        %s\n", typ)
+	if v, ok := spec.defaultValue(o.name); ok {
+		fmt.Fprintf(&b, "  Default:     %s\n", spec.redact(o.name, v))
+	}
+	if len(o.implicit) > 0 {
+		fmt.Fprintf(&b, "  Implicit:    %s\n", o.implicit)
+	}
+	if len(o.choices) > 0 {
+		fmt.Fprintf(&b, "  Choices:     %s\n", strings.Join(o.choices, ", "))
+	}
+	if o.constraint != nil {
+		fmt.Fprintf(&b, "  Constraint:  {%s}\n", o.constraint.text)
+	}
+	if len(o.env) > 0 {
+		fmt.Fprintf(&b, "  Environment: %s\n", strings.Join(o.env, ", "))
+	}
+	if spec.required[o.name] {
+		fmt.Fprintf(&b, "  Required:    yes\n")
+	}
+	if len(o.help) > 0 {
+		fmt.Fprintf(&b, "\n  %s\n", strings.ReplaceAll(o.help, "\n\n", "\n\n  "))
+	}
+
+	return b.String(), nil
+}
+
+// Search the options, environment variables and commands for
+// 'keyword' (case insensitive) and return the usage text of each
+// matching entry. An entry matches if the keyword occurs in its name,
+// any of its spellings or its description. This powers a
+// "mytool help -k tls" style lookup.
+func (spec *Spec) SearchUsage(keyword string) []string {
+	var rv []string
+
+	key := strings.ToLower(keyword)
+	match := func(words ...string) bool {
+		for _, w := range words {
+			if strings.Contains(strings.ToLower(w), key) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, o := range spec.optlist {
+		if len(o.usage) == 0 {
+			continue
+		}
+
+		words := append([]string{o.name, o.help}, o.flags...)
+		words = append(words, o.env...)
+		if match(words...) {
+			rv = append(rv, strings.Join(o.usage, "\n"))
+		}
+	}
+
+	for _, c := range spec.cmdlist {
+		if len(c.usage) == 0 {
+			continue
+		}
+
+		words := append([]string{c.name, c.help}, c.aliases...)
+		if match(words...) {
+			rv = append(rv, strings.Join(c.usage, "\n"))
+		}
+	}
+
+	return rv
+}
+
+// Return the program name used in synopses: the one in the "usage:"
+// line, or else the name the program was run as.
+func (spec *Spec) progname() string {
+	if len(spec.prog) > 0 {
+		return spec.prog
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// Return the program name used in titles and page names
+func (spec *Spec) title() string {
+	if len(spec.prog) > 0 {
+		return spec.prog
+	}
+	return "index"
+}
+
+// Find the option or env var declaration for 'nm' - a canonical
+// name, a command line spelling or an environment variable.
+func (spec *Spec) lookupOpt(nm string) *optspec {
+	if v, ok := spec.options[nm]; ok {
+		nm = v
+	} else if v, ok := spec.environment[nm]; ok {
+		nm = v
+	} else if v, ok := spec.options["--"+nm]; ok {
+		nm = v
+	}
+
+	for _, o := range spec.optlist {
+		if o.name == nm {
+			return o
+		}
+	}
+	return nil
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
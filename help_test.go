@@ -0,0 +1,488 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShortUsage(t *testing.T) {
+	spec, err := Parse(`
+    usage: haraway [options] <command> <args>...
+    Manage the haraway sandbox
+    --
+    !root=    -r,--root=,HARAWAY_ROOT     Path to the haraway data root
+    +verbose  -v,--verbose                Show more info
+                                          about each step
+    debug     -d,--debug                  Show debug info
+    --
+    HOME=     HOME                        Home directory
+    --
+    --
+    See the manual for more.
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	short := spec.ShortUsage()
+	for _, want := range []string{"usage: haraway", "--root=", "--verbose", "about each step"} {
+		if !strings.Contains(short, want) {
+			t.Errorf("short usage is missing %q:\n%s", want, short)
+		}
+	}
+	for _, bad := range []string{"--debug", "HOME", "Manage the", "manual"} {
+		if strings.Contains(short, bad) {
+			t.Errorf("short usage has %q:\n%s", bad, short)
+		}
+	}
+
+	long := spec.Usage()
+	for _, want := range []string{"--debug", "HOME", "manual"} {
+		if !strings.Contains(long, want) {
+			t.Errorf("long usage is missing %q:\n%s", want, long)
+		}
+	}
+}
+
+func TestOptionHelp(t *testing.T) {
+	spec, err := Parse(`
+    usage: haraway [options] <command> <args>...
+    --
+    !root=XYZ -r,--root=,HARAWAY_ROOT     Path to the haraway data root
+                                          (must be writable)
+    verbose   -v,--verbose                Show more info
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, nm := range []string{"root", "-r", "--root", "HARAWAY_ROOT"} {
+		h, err := spec.OptionHelp(nm)
+		if err != nil {
+			t.Fatalf("%s: %s", nm, err)
+		}
+
+		for _, want := range []string{"-r, --root", "value", "XYZ", "HARAWAY_ROOT", "Required", "data root (must be writable)"} {
+			if !strings.Contains(h, want) {
+				t.Errorf("%s: help is missing %q:\n%s", nm, want, h)
+			}
+		}
+	}
+
+	h, err := spec.OptionHelp("verbose")
+	if err != nil || !strings.Contains(h, "flag") {
+		t.Errorf("bad help for verbose: %v\n%s", err, h)
+	}
+
+	if _, err := spec.OptionHelp("nope"); err == nil {
+		t.Error("expected error for unknown option")
+	}
+}
+
+func TestSearchUsage(t *testing.T) {
+	spec, err := Parse(`
+    usage: haraway [options] <command> <args>...
+    --
+    cert=     --tls-cert=                 Path to the certificate
+    key=      --key=                      Path to the TLS private key
+    verbose   -v,--verbose                Show more info
+    secret=   --secret=                   -
+    --
+    --
+    serve     serve                       Serve requests over TLS
+    shell     sh,shell                    Open a shell
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := spec.SearchUsage("tls")
+	if len(m) != 3 {
+		t.Fatalf("expected 3 matches, saw %d: %q", len(m), m)
+	}
+	if !strings.Contains(m[2], "serve") {
+		t.Errorf("expected serve command match, saw %q", m[2])
+	}
+
+	if m := spec.SearchUsage("secret"); len(m) != 0 {
+		t.Errorf("hidden options must not match: %q", m)
+	}
+}
+
+func TestMetavar(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    out=      -o,--out=FILE               Write output to FILE
+    count=1   -n=N,--count=N              Repeat N times
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := spec.OptionHelp("out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(h, "-o FILE, --out=FILE") || !strings.Contains(h, "Type:        FILE") {
+		t.Errorf("metavar missing from help:\n%s", h)
+	}
+
+	md := spec.MarkdownPages()["tool.md"]
+	if !strings.Contains(md, "`-n N`, `--count=N`") {
+		t.Errorf("metavar missing from docs:\n%s", md)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "--out=x", "-n", "3"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.GetInt("count"); v != 3 {
+		t.Errorf("expected 3, saw %d", v)
+	}
+}
+
+func TestOptionalMetavar(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=        -r,--root=DIR         Path to the data root
+    jobs=        -j=N                  Parallel jobs
+    color[=auto] --color[=WHEN]        Colorize the output
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetAutoSynopsis(true)
+
+	want := `usage: tool [-r DIR] [-j N] [--color[=WHEN]] [ARGS...]
+
+Options:
+  -r DIR, --root=DIR  Path to the data root
+  -j N                Parallel jobs
+  --color[=WHEN]      Colorize the output`
+
+	if got := spec.FormatUsage(); got != want {
+		t.Errorf("expected\n%s\nsaw\n%s", want, got)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "--color", "-j", "4"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("color"); v != "auto" {
+		t.Errorf("color: expected auto, saw %q", v)
+	}
+
+	// the placeholders survive a round trip through the spec text
+	again, err := Parse(spec.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	again.SetAutoSynopsis(true)
+	if got := again.FormatUsage(); got != want {
+		t.Errorf("reparsed: expected\n%s\nsaw\n%s", want, got)
+	}
+
+	_, err = Parse("usage: tool\n--\nout= --out[=FILE] Output\n--\n--\n--\n")
+	if err == nil || !strings.Contains(err.Error(), "isn't optional") {
+		t.Errorf("expected an error for an optional placeholder, saw %v", err)
+	}
+}
+
+func TestCommandSynopsis(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command> <args>...
+    --
+    verbose   -v,--verbose                Show more info
+    force@rm  -f,--force                  Don't ask
+    --
+    --
+    exec      exec                        Run a command
+    rm        rm                          Remove things
+    ls        ls                          List things
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := Parse(`
+    usage: exec [options] <command>
+    --
+    env=      -e,--env=                   Environment
+    --
+    --
+    run       run                         Run it
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = spec.SetCommandSpec("exec", sub); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"exec": "tool exec [options] <command> [args...]",
+		"rm":   "tool rm [options] [args...]",
+		"ls":   "tool ls [args...]",
+	}
+	for cmd, want := range tests {
+		syn, err := spec.CommandSynopsis(cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if syn != want {
+			t.Errorf("%s: expected %q, saw %q", cmd, want, syn)
+		}
+	}
+
+	if _, err := spec.CommandSynopsis("nope"); err == nil {
+		t.Error("expected an error for an unknown command")
+	}
+
+	if md := spec.MarkdownPages()["tool_exec.md"]; !strings.Contains(md, "tool exec [options] <command> [args...]") {
+		t.Errorf("synopsis missing from command page:\n%s", md)
+	}
+}
+
+func TestCommandEpilog(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command> <args>...
+    --
+    verbose   -v,--verbose                Show more info
+    force@rm  -f,--force                  Don't ask
+    --
+    --
+    rm        rm,del                      Remove things
+    ls        ls                          List things
+    --
+    Global notes.
+
+    [epilog del]
+    Examples:
+      tool rm -f a.txt
+
+    [epilog ls]
+    ls never modifies anything.
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u := spec.Usage(); strings.Contains(u, "Examples") || strings.Contains(u, "[epilog") {
+		t.Errorf("epilog leaked into the usage:\n%s", u)
+	}
+	if !strings.Contains(spec.Usage(), "Global notes.") {
+		t.Errorf("appendix missing from the usage:\n%s", spec.Usage())
+	}
+
+	u, err := spec.CommandUsage("rm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"usage: tool rm [options] [args...]", "Remove things",
+		"--force", "Examples:\n  tool rm -f a.txt"} {
+		if !strings.Contains(u, want) {
+			t.Errorf("rm: missing %q in:\n%s", want, u)
+		}
+	}
+	if strings.Contains(u, "never modifies") || strings.HasSuffix(u, "\n") {
+		t.Errorf("rm: bad epilog:\n%s", u)
+	}
+
+	u, err = spec.CommandUsage("ls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(u, "ls never modifies anything.") || strings.Contains(u, "Examples") {
+		t.Errorf("ls: bad epilog:\n%s", u)
+	}
+
+	if md := spec.MarkdownPages()["tool_rm.md"]; !strings.Contains(md, "tool rm -f a.txt") {
+		t.Errorf("epilog missing from command page:\n%s", md)
+	}
+
+	if _, err = spec.CommandUsage("nope"); err == nil {
+		t.Error("expected an error for an unknown command")
+	}
+
+	_, err = Parse(`
+    usage: tool
+    --
+    --
+    --
+    ls        ls                          List things
+    --
+    [epilog rm]
+    text
+    `)
+	if err == nil {
+		t.Error("expected an error for an epilog of an unknown command")
+	}
+}
+
+func TestSynopsis(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command> <args>...
+    --
+    verbose   -v,--verbose                Show more info
+    !root=    -r,--root=                  Root directory
+    out=      --out=FILE                  Output file
+    force@exec -f,--force                 Don't ask
+    --
+    HOME      HOME                        Home directory
+    --
+    exec      exec,x                      Run a command
+    shell     shell                       Start a shell
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "tool [-v] -r ROOT [--out=FILE] (exec|shell) [ARGS...]"
+	if syn := spec.Synopsis(); syn != want {
+		t.Errorf("expected %q, saw %q", want, syn)
+	}
+
+	spec, err = Parse(`
+    usage: cat [files...]
+    --
+    number    -n                          Number lines
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = "cat [-n] [ARGS...]"
+	if syn := spec.Synopsis(); syn != want {
+		t.Errorf("expected %q, saw %q", want, syn)
+	}
+
+	if err = spec.SetArgs("<file> [more...]"); err != nil {
+		t.Fatal(err)
+	}
+	want = "cat [-n] <file> [more...]"
+	if syn := spec.Synopsis(); syn != want {
+		t.Errorf("expected %q, saw %q", want, syn)
+	}
+
+	// without a usage line the program is named as it was run
+	spec, err = Parse(`
+    Print files
+    --
+    number    -n                          Number lines
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = filepath.Base(os.Args[0]) + " [-n] [ARGS...]"
+	if syn := spec.Synopsis(); syn != want {
+		t.Errorf("expected %q, saw %q", want, syn)
+	}
+}
+
+func TestSynopsisCompact(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command>
+    A tool
+    --
+    verbose   -v,--verbose                Show more info
+    !root=    -r,--root=                  Root directory
+    out=      --out=FILE                  Output file
+    level=    --level=LEVEL               Log level
+    --
+    --
+    exec      exec,x                      Run a command
+    shell     shell                       Start a shell
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec.SetUsageWidth(40)
+	want := "tool [options] -r ROOT <command> [ARGS...]"
+	if syn := spec.Synopsis(); syn != want {
+		t.Errorf("expected %q, saw %q", want, syn)
+	}
+
+	// the usage shows the synopsis only when asked to
+	if u := spec.usageText(false); !strings.HasPrefix(u, "usage: tool [options] <command>\n") {
+		t.Errorf("bad usage:\n%s", u)
+	}
+
+	spec.SetAutoSynopsis(true)
+	spec.SetUsageWidth(80)
+	want = "usage: tool [-v] -r ROOT [--out=FILE] [--level=LEVEL] (exec|shell) [ARGS...]\nA tool\n"
+	if u := spec.usageText(false); !strings.HasPrefix(u, want) {
+		t.Errorf("bad usage:\n%s", u)
+	}
+	if u := spec.FormatUsage(); !strings.HasPrefix(u, want) {
+		t.Errorf("bad formatted usage:\n%s", u)
+	}
+	if d := spec.HelpData(); d.Usage[0] != strings.TrimSuffix(want, "\nA tool\n") {
+		t.Errorf("bad help data: %q", d.Usage[0])
+	}
+}
+
+func TestMultiParagraphHelp(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=     -r,--root=                  Data root
+                                          where the files live.
+
+                                          Relative paths are taken
+                                          from the current directory.
+    verbose   -v                          Show more
+
+    quiet     -q                          Show less
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := spec.OptionHelp("root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\n  Data root where the files live.\n\n  Relative paths are taken from the current directory.\n"
+	if !strings.HasSuffix(h, want) {
+		t.Errorf("expected suffix %q in %q", want, h)
+	}
+
+	u := spec.Usage()
+	if i := strings.Index(u, "live.\n\n"); i < 0 || !strings.HasPrefix(strings.TrimLeft(u[i+7:], " "), "Relative paths") {
+		t.Errorf("paragraph break missing from the usage:\n%s", u)
+	}
+	if strings.Contains(u, "Show more\n\n") {
+		t.Errorf("blank line between options kept:\n%s", u)
+	}
+
+	if md := spec.MarkdownPages()["tool.md"]; !strings.Contains(md, "live.<br><br>Relative") {
+		t.Errorf("paragraph break missing from the docs:\n%s", md)
+	}
+	if h, _ := spec.OptionHelp("verbose"); strings.Contains(h, "\n\n  \n") {
+		t.Errorf("bad help for verbose: %q", h)
+	}
+}
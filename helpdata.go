@@ -0,0 +1,93 @@
+// helpdata.go - The data of the usage
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"slices"
+)
+
+// The data that a help template (see SetHelpTemplate()) is executed
+// with
+type HelpData struct {
+	// the program name
+	Prog string
+
+	// the lines of the usage section
+	Usage []string
+
+	// the documented options and environment section entries in
+	// declaration order
+	Options []HelpOption
+	Env     []HelpOption
+
+	// the commands in declaration order; Help is empty for the
+	// undocumented ones
+	Commands []CommandInfo
+
+	// the lines of the appendix
+	Appendix []string
+}
+
+// An option or environment section entry of HelpData
+type HelpOption struct {
+	// the canonical name
+	Name string
+
+	// the command line spellings with the value placeholder, e.g.
+	// ["-r DIR", "--root=DIR"], and the environment variables
+	Flags []string
+	Env   []string
+
+	// the description, the default (redacted for a secret option) and
+	// whether the option is required
+	Help     string
+	Default  string
+	Required bool
+
+	// the option group (see OptionGroups()) and the commands the
+	// option is scoped to, if any
+	Group    string
+	Commands []string
+}
+
+// Return the data that help templates are executed with
+func (spec *Spec) HelpData() HelpData {
+	d := HelpData{
+		Prog:     spec.prog,
+		Usage:    slices.Clone(spec.aboutLines()),
+		Commands: spec.Commands(),
+		Appendix: slices.Clone(trimBlank(spec.appendix)),
+	}
+
+	for _, o := range spec.optlist {
+		if len(o.usage) == 0 {
+			continue
+		}
+
+		def, _ := spec.defaultValue(o.name)
+		h := HelpOption{
+			Name:     o.name,
+			Env:      slices.Clone(o.env),
+			Help:     o.help,
+			Default:  spec.redact(o.name, def),
+			Required: spec.required[o.name],
+			Group:    o.group,
+			Commands: slices.Clone(o.cmds),
+		}
+		if o.isenv {
+			d.Env = append(d.Env, h)
+		} else {
+			h.Flags = slices.Clone(spec.flagColumn(o))
+			d.Options = append(d.Options, h)
+		}
+	}
+	return d
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
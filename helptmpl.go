@@ -0,0 +1,75 @@
+// helptmpl.go - Usage rendered from a template
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+//go:build !tinygo
+
+package options
+
+import (
+	"strings"
+	"text/template"
+)
+
+// The template that renders the usage; see SetHelpTemplate()
+type helpTemplate = *template.Template
+
+// The template used by SetHelpTemplate("")
+const DefaultHelpTemplate = `{{range .Usage}}{{.}}
+{{end}}{{if .Options}}
+Options:
+{{range .Options}}  {{pad (join .Flags ", ") 24}}  {{.Help}}{{if .Default}} (default: {{.Default}}){{end}}{{if .Required}} (required){{end}}
+{{end}}{{end}}{{if .Env}}
+Environment:
+{{range .Env}}  {{pad (join .Env ", ") 24}}  {{.Help}}{{if .Default}} (default: {{.Default}}){{end}}
+{{end}}{{end}}{{if .Commands}}
+Commands:
+{{range .Commands}}{{if .Help}}  {{pad (join .Aliases ", ") 24}}  {{.Help}}
+{{end}}{{end}}{{end}}{{if .Appendix}}
+{{range .Appendix}}{{.}}
+{{end}}{{end}}`
+
+// Render the usage printed by PrintUsage(), PrintUsageWithError() and
+// the built-in help from the text/template 'tmpl' executed with a
+// HelpData, instead of the usage text as written in the spec; an empty
+// 'tmpl' selects DefaultHelpTemplate. The template can use the
+// functions "join" (strings.Join) and "pad" (pad a string with blanks
+// to a number of columns). This allows branded or translated help
+// without giving up the spec. An error is returned if the template
+// doesn't parse; if it fails to execute, the usage text of the spec is
+// printed instead.
+func (spec *Spec) SetHelpTemplate(tmpl string) error {
+	if len(tmpl) == 0 {
+		tmpl = DefaultHelpTemplate
+	}
+
+	funcs := template.FuncMap{
+		"join": strings.Join,
+		"pad":  padRight,
+	}
+	t, err := template.New("help").Funcs(funcs).Parse(tmpl)
+	if err != nil {
+		return err
+	}
+	spec.help_tmpl = t
+	return nil
+}
+
+// Return the usage rendered from the help template, if there is one
+func (spec *Spec) templateUsage() (string, bool) {
+	if spec.help_tmpl == nil {
+		return "", false
+	}
+
+	var b strings.Builder
+	if err := spec.help_tmpl.Execute(&b, spec.HelpData()); err != nil {
+		return spec.usage, true
+	}
+	return strings.TrimRight(b.String(), "\n"), true
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
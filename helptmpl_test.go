@@ -0,0 +1,77 @@
+//go:build !tinygo
+
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHelpTemplate(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] command
+    --
+    !root=/var   -r,--root=DIR        Data root
+    verbose      -v,--verbose         Verbose
+    hidden       --hidden             -
+    --
+    home=        TOOL_HOME            Home directory
+    --
+    list         ls,list              List entries
+    --
+    See the manual.
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := spec.SetHelpTemplate(""); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errs strings.Builder
+	spec.SetOutput(&out, &errs)
+	spec.PrintUsage()
+
+	want := `usage: tool [options] command
+
+Options:
+  -r DIR, --root=DIR        Data root (default: /var) (required)
+  -v, --verbose             Verbose
+
+Environment:
+  TOOL_HOME                 Home directory
+
+Commands:
+  ls, list                  List entries
+
+See the manual.
+`
+	if out.String() != want {
+		t.Errorf("expected\n%s\nsaw\n%s", want, out.String())
+	}
+
+	err = spec.SetHelpTemplate(`{{.Prog}}: {{range .Options}}{{.Name}} {{end}}{{len .Commands}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	spec.PrintUsage()
+	if out.String() != "tool: root verbose 1\n" {
+		t.Errorf("bad custom help: %q", out.String())
+	}
+
+	if err := spec.SetHelpTemplate(`{{.Bogus`); err == nil {
+		t.Error("expected a template error")
+	}
+
+	// a template that fails falls back to the spec usage
+	if err := spec.SetHelpTemplate(`{{.Bogus}}`); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	spec.PrintUsage()
+	if out.String() != spec.Usage()+"\n" {
+		t.Errorf("bad fallback: %q", out.String())
+	}
+}
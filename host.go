@@ -0,0 +1,24 @@
+// host.go - Process environment of regular platforms
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+//go:build !js
+
+package options
+
+import (
+	"os"
+)
+
+// Interpret() exports the env-bound options to the process environment
+// if SetSetenv(true) is called
+const hostSetenv = true
+
+// Terminate the program
+var exit = os.Exit
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
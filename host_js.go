@@ -0,0 +1,22 @@
+// host_js.go - Process environment of js/wasm
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+// There is no process environment to export the options to; see
+// SetSetenv()
+const hostSetenv = false
+
+// Exiting would tear down the wasm instance (e.g. a browser
+// playground); unwind with an *ExitError that the host can recover
+// instead.
+var exit = func(code int) {
+	panic(&ExitError{Code: code})
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
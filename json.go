@@ -0,0 +1,43 @@
+// json.go - JSON valued options
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+//go:build !tinygo
+
+package options
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Unmarshal the JSON value of option 'nm' into 'v', e.g. for
+// "--selector '{"app":"web"}'". A value of the form "@file" reads the
+// JSON from that file instead. If the option isn't set 'v' is left as
+// is; use IsSet() to tell the cases apart.
+func (opts *Options) GetJSON(nm string, v any) error {
+	s, ok := opts.Get(nm)
+	if !ok {
+		return nil
+	}
+
+	b := []byte(s)
+	if len(s) > 1 && s[0] == '@' {
+		var err error
+		if b, err = os.ReadFile(s[1:]); err != nil {
+			return fmt.Errorf("Invalid option: %s: %w", nm, err)
+		}
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("Invalid option: %s: %w", nm, err)
+	}
+	return nil
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
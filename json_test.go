@@ -0,0 +1,69 @@
+//go:build !tinygo
+
+package options
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetJSON(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    selector=  -s,--selector=JSON         Label selector
+    limits=    --limits=JSON              Resource limits
+    bad=       --bad=                     Not JSON
+    none=      --none=                    Not given
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	fn := filepath.Join(t.TempDir(), "limits.json")
+	if err = os.WriteFile(fn, []byte(`{"cpu": 2, "mem": "1G"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-s", `{"app":"web"}`, "--limits=@" + fn, "--bad=x"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sel map[string]string
+	if err = opts.GetJSON("selector", &sel); err != nil || sel["app"] != "web" {
+		t.Errorf("selector: %v %v", sel, err)
+	}
+
+	var lim struct {
+		CPU int    `json:"cpu"`
+		Mem string `json:"mem"`
+	}
+	if err = opts.GetJSON("limits", &lim); err != nil || lim.CPU != 2 || lim.Mem != "1G" {
+		t.Errorf("limits: %v %v", lim, err)
+	}
+
+	if err = opts.GetJSON("bad", &sel); err == nil {
+		t.Error("bad: expected an error")
+	}
+
+	none := []int{1}
+	if err = opts.GetJSON("none", &none); err != nil || len(none) != 1 {
+		t.Errorf("none: %v %v", none, err)
+	}
+
+	opts, err = spec.Interpret([]string{"tool", "--limits=@" + fn + ".missing"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = opts.GetJSON("limits", &lim); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a missing file, saw %v", err)
+	}
+}
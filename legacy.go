@@ -0,0 +1,52 @@
+// legacy.go - Compatibility with the v1 semantics
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"runtime"
+	"strings"
+)
+
+// Reproduce the v1 semantics of Interpret() if 'legacy' is true, so
+// that existing tools can upgrade the package without surprises:
+//
+//   - the environment is read in its own order and the last of the
+//     variables bound to an option wins
+//   - the command line doesn't replace a value from the environment:
+//     Get() returns the env value and GetMulti() lists it first
+//   - an empty env var sets a flag to the empty string
+//   - env var names are matched with case, even on Windows
+//   - a missing required option is reported by its name alone, one at
+//     a time
+//   - unknown commands are reported without suggestions
+//   - short options can't be grouped ("-vd")
+//
+// Features that must be enabled explicitly are not affected.
+func (spec *Spec) SetLegacy(legacy bool) {
+	spec.legacy = legacy
+	spec.env_fold = !legacy && runtime.GOOS == "windows"
+}
+
+// Call 'fn' for every entry of 'environ' that is bound to an option
+func (spec *Spec) eachLegacyEnv(environ []string, fn func(name, env, value string) error) error {
+	for _, env := range environ {
+		nm, value, ok := strings.Cut(env, "=")
+		if !ok {
+			continue
+		}
+		if option, present := spec.environment[nm]; present {
+			if err := fn(option, nm, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
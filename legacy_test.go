@@ -0,0 +1,68 @@
+package options
+
+import (
+	"testing"
+)
+
+func TestLegacy(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command>
+    --
+    !root=    -r,--root=,ROOT,TOOL_ROOT   Data root
+    !out=     -o,--out=                   Output
+    debug     -d,--debug,DEBUG            Debug
+    --
+    --
+    exec      exec                        Run a command
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	env := []string{"TOOL_ROOT=/b", "ROOT=/a", "DEBUG="}
+	args := []string{"tool", "-o", "x", "--root=/c", "exec"}
+
+	opts, err := spec.Interpret(args, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("root"); v != "/c" {
+		t.Errorf("root: expected /c, saw %q", v)
+	}
+	if v, _ := opts.Get("debug"); v != "true" {
+		t.Errorf("debug: expected true, saw %q", v)
+	}
+
+	spec.SetLegacy(true)
+	opts, err = spec.Interpret(args, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("root"); v != "/a" {
+		t.Errorf("legacy root: expected /a, saw %q", v)
+	}
+	if v := opts.GetMulti("root"); len(v) != 2 || v[0] != "/a" || v[1] != "/c" {
+		t.Errorf("legacy root: bad values %v", v)
+	}
+	if v, ok := opts.Get("debug"); !ok || v != "" {
+		t.Errorf("legacy debug: expected empty, saw %q", v)
+	}
+
+	_, err = spec.Interpret([]string{"tool", "exec"}, nil)
+	if want := "Missing option: root"; err == nil || err.Error() != want {
+		t.Errorf("expected %q, saw %v", want, err)
+	}
+
+	_, err = spec.Interpret([]string{"tool", "-r", "a", "-o", "b", "exce"}, nil)
+	if want := "Invalid argument: exce was not recognized"; err == nil || err.Error() != want {
+		t.Errorf("expected %q, saw %v", want, err)
+	}
+
+	spec.SetLegacy(false)
+	_, err = spec.Interpret([]string{"tool", "exec"}, nil)
+	if want := "Missing options: root (-r, --root, ROOT, TOOL_ROOT), out (-o, --out)"; err == nil || err.Error() != want {
+		t.Errorf("expected %q, saw %v", want, err)
+	}
+}
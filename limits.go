@@ -0,0 +1,66 @@
+// limits.go - Resource limits for untrusted input
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+// Bounds on the input accepted by Interpret(); a zero field means no
+// limit.
+type Limits struct {
+	// maximum number of arguments after the program name (including
+	// those from the flags env var)
+	MaxArgs int
+
+	// maximum length in bytes of an option value (from the command
+	// line or the environment)
+	MaxValue int
+
+	// maximum number of times an option can be given
+	MaxRepeat int
+}
+
+// Set the limits on the input accepted by Interpret(). Services that
+// parse command strings supplied by untrusted users can bound the
+// resources spent on them; input exceeding a limit fails with an error.
+func (spec *Spec) SetLimits(l Limits) {
+	spec.limits = l
+}
+
+// Verify that 'args' (including the program name) are within limits
+func (spec *Spec) checkArgs(args []string) error {
+	if n := len(args) - 1; spec.limits.MaxArgs > 0 && n > spec.limits.MaxArgs {
+		return spec.errorf(MsgTooManyArgs, n, spec.limits.MaxArgs)
+	}
+	return nil
+}
+
+// Verify that the 'value' of option 'nm' supplied by 'arg' is within
+// limits
+func (spec *Spec) checkValueSize(nm, arg, value string) error {
+	if spec.limits.MaxValue > 0 && len(value) > spec.limits.MaxValue {
+		arg = spec.redactArg(nm, arg)
+		return spec.optError(MsgValueTooLong, "", arg, arg, spec.limits.MaxValue)
+	}
+	return nil
+}
+
+// Verify that option 'nm' supplied by 'arg' wasn't given more than the
+// limit; 'n' is the number of times it was seen.
+func (spec *Spec) checkRepeat(nm, arg string, n int) error {
+	if o := spec.optinfo[nm]; o != nil && o.maxCount > 0 && n > o.maxCount {
+		if o.maxCount == 1 {
+			return spec.optError(MsgTooManyRepeats1, nm, arg, arg)
+		}
+		return spec.optError(MsgTooManyRepeats, nm, arg, arg, o.maxCount)
+	}
+	if spec.limits.MaxRepeat > 0 && n > spec.limits.MaxRepeat {
+		return spec.optError(MsgTooManyRepeats, "", arg, arg, spec.limits.MaxRepeat)
+	}
+	return nil
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
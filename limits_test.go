@@ -0,0 +1,47 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    name=     -n,--name=,TOOL_NAME        Name
+    verbose   -v,--verbose                Verbose
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec.SetLimits(Limits{MaxArgs: 4, MaxValue: 8, MaxRepeat: 2})
+
+	tests := []struct {
+		args []string
+		env  []string
+		err  string
+	}{
+		{[]string{"-v", "-v", "-n", "12345678"}, nil, ""},
+		{[]string{"a", "b", "c", "d", "e"}, nil, "Too many arguments: 5"},
+		{[]string{"-n", "123456789"}, nil, "-n: value is longer than 8 bytes"},
+		{[]string{"--name=123456789"}, nil, "--name=123456789: value is longer"},
+		{nil, []string{"TOOL_NAME=123456789"}, "TOOL_NAME: value is longer"},
+		{[]string{"-v", "-v", "-v"}, nil, "-v: given more than 2 times"},
+	}
+
+	for i, tc := range tests {
+		_, err := spec.Interpret(append([]string{"tool"}, tc.args...), tc.env)
+		switch {
+		case len(tc.err) == 0 && err != nil:
+			t.Errorf("%d: unexpected error: %s", i, err)
+		case len(tc.err) > 0 && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("%d: expected %q, saw %v", i, tc.err, err)
+		}
+	}
+}
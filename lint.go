@@ -0,0 +1,150 @@
+// lint.go - Spec consistency checks
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A mistake in a spec found by Lint()
+type Problem struct {
+	// the canonical name of the option involved
+	Option string
+
+	// what is wrong
+	Message string
+}
+
+func (p Problem) String() string {
+	return p.Message
+}
+
+// Parse a spec like Parse() and also fail if Lint() finds any problem
+// with it. Programs can use this in their tests to catch typos in the
+// spec that Parse() accepts.
+func ParseStrict(desc string) (*Spec, error) {
+	spec, err := Parse(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	if p := spec.Lint(); len(p) > 0 {
+		s := make([]string, len(p))
+		for i := range p {
+			s[i] = p[i].Message
+		}
+		return nil, fmt.Errorf("Invalid spec: %s", strings.Join(s, "; "))
+	}
+	return spec, nil
+}
+
+// Return the problems in the spec that Parse() accepts but that are
+// likely mistakes: an option or command declared twice, a spelling or
+// environment variable bound to more than one option, a command
+// spelling used twice, a flag with a default that has no effect, a
+// required flag and an option or environment variable declared without
+// a description (use "-" to leave it out of the usage on purpose). The
+// problems are in declaration order; a nil slice implies none were
+// found.
+func (spec *Spec) Lint() []Problem {
+	rv := spec.duplicates()
+
+	add := func(o *optspec, format string, args ...any) {
+		rv = append(rv, Problem{o.name, fmt.Sprintf(format, args...)})
+	}
+
+	for _, o := range spec.optlist {
+		if spec.flags[o.name] {
+			if v, ok := spec.defaults[o.name]; ok {
+				if b, _ := parseBool(v); !b {
+					add(o, "Invalid flag: default %s of %s has no effect", v, o.name)
+				}
+			}
+			if spec.required[o.name] && !o.isenv {
+				add(o, "Invalid flag: %s can't be required", o.name)
+			}
+		}
+
+		if o.nodesc {
+			add(o, "Missing description: %s", o.name)
+		}
+	}
+
+	// keep the problems in declaration order
+	order := make(map[string]int)
+	for i, o := range spec.optlist {
+		order[o.name] = i
+	}
+	for i, c := range spec.cmdlist {
+		order[c.name] = len(spec.optlist) + i
+	}
+	slices.SortStableFunc(rv, func(a, b Problem) int {
+		return order[a.Option] - order[b.Option]
+	})
+	return rv
+}
+
+// Return the options and commands declared more than once and the
+// spellings, environment variables and command spellings bound to more
+// than one of them.
+func (spec *Spec) duplicates() []Problem {
+	var rv []Problem
+
+	add := func(nm, format string, args ...any) {
+		rv = append(rv, Problem{nm, fmt.Sprintf(format, args...)})
+	}
+
+	names := make(map[string]bool)
+	spellings := make(map[string]*optspec)
+	envs := make(map[string]*optspec)
+
+	for _, o := range spec.optlist {
+		if names[o.name] {
+			add(o.name, "Duplicate option: %s is declared more than once", o.name)
+		}
+		names[o.name] = true
+
+		for _, f := range o.flags {
+			if p, ok := spellings[f]; ok {
+				add(o.name, "Duplicate option: %s is bound to %s and %s", f, p.name, o.name)
+				continue
+			}
+			spellings[f] = o
+		}
+		for _, e := range o.env {
+			if p, ok := envs[e]; ok {
+				add(o.name, "Duplicate env var: %s is bound to %s and %s", e, p.name, o.name)
+				continue
+			}
+			envs[e] = o
+		}
+	}
+
+	cmds := make(map[string]bool)
+	aliases := make(map[string]*cmdspec)
+	for _, c := range spec.cmdlist {
+		if cmds[c.name] {
+			add(c.name, "Duplicate command: %s is declared more than once", c.name)
+		}
+		cmds[c.name] = true
+
+		for _, a := range c.aliases {
+			if p, ok := aliases[a]; ok {
+				add(c.name, "Duplicate command: %s is bound to %s and %s", a, p.name, c.name)
+				continue
+			}
+			aliases[a] = c
+		}
+	}
+	return rv
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,72 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    verbose       -v,--verbose,TOOL_V      Verbose
+    version       -v,--version             Show the version
+    quiet=false:bool -q,--quiet            Quiet
+    color=true:bool --color                Colored output
+    !force        -f,--force               Force
+    root=         -r,--root=,TOOL_V
+    hidden        --hidden                 -
+    --
+    home=         TOOL_HOME                Home directory
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Problem{
+		{"version", "Duplicate option: -v is bound to verbose and version"},
+		{"quiet", "Invalid flag: default false of quiet has no effect"},
+		{"force", "Invalid flag: force can't be required"},
+		{"root", "Duplicate env var: TOOL_V is bound to verbose and root"},
+		{"root", "Missing description: root"},
+	}
+
+	p := spec.Lint()
+	if len(p) != len(want) {
+		t.Fatalf("expected %d problems, saw %d: %v", len(want), len(p), p)
+	}
+	for i := range want {
+		if p[i] != want[i] {
+			t.Errorf("%d: expected %+v, saw %+v", i, want[i], p[i])
+		}
+	}
+
+	_, err = ParseStrict(`
+    usage: tool [options]
+    --
+    !verbose     -v,--verbose          Verbose
+    --
+    --
+    --
+    `)
+	if err == nil || !strings.Contains(err.Error(), "verbose can't be required") {
+		t.Errorf("bad strict error: %v", err)
+	}
+
+	spec, err = ParseStrict(`
+    usage: tool [options]
+    --
+    verbose      -v,--verbose          Verbose
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := spec.Lint(); p != nil {
+		t.Errorf("unexpected problems: %v", p)
+	}
+}
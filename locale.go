@@ -0,0 +1,118 @@
+// locale.go - Locale aware number parsing
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"strings"
+)
+
+// The digit grouping and decimal separators of a locale
+type numberFormat struct {
+	group   string // all the accepted grouping separators
+	decimal byte
+}
+
+var (
+	numDot   = &numberFormat{",", '.'}             // 1,234.5
+	numComma = &numberFormat{".", ','}             // 1.234,5
+	numSpace = &numberFormat{" \u00a0\u202f", ','} // 1 234,5
+	numApos  = &numberFormat{"'", '.'}             // 1'234.5
+)
+
+// number formats by language; territories that differ from their
+// language are listed as "ll_TT"
+var numberFormats = map[string]*numberFormat{
+	"en": numDot, "ja": numDot, "zh": numDot, "ko": numDot,
+	"hi": numDot, "he": numDot, "th": numDot,
+
+	"de": numComma, "es": numComma, "it": numComma, "nl": numComma,
+	"pt": numComma, "id": numComma, "da": numComma, "tr": numComma,
+	"el": numComma, "ro": numComma,
+
+	"fr": numSpace, "ru": numSpace, "pl": numSpace, "cs": numSpace,
+	"sv": numSpace, "fi": numSpace, "nb": numSpace, "uk": numSpace,
+	"sk": numSpace, "hu": numSpace, "bg": numSpace,
+
+	"de_CH": numApos, "it_CH": numApos, "fr_CH": numApos,
+}
+
+// Make GetInt() and GetUint() (and the other numeric getters) accept
+// numbers formatted for locale 'loc', e.g. "1.234" for "de_DE" or
+// "1 234" for "fr_FR". 'loc' is a POSIX locale name such as
+// "de_DE.UTF-8"; "auto" selects the locale named by LC_ALL, LC_NUMERIC
+// or LANG in the environment given to Interpret(). An empty 'loc'
+// turns the feature off; unknown locales parse plain numbers.
+func (spec *Spec) SetNumberLocale(loc string) {
+	spec.num_locale = loc
+}
+
+// Return the number format for the configured locale
+func (spec *Spec) numberFormat(environ []string) *numberFormat {
+	loc := spec.num_locale
+	if loc == "auto" {
+		loc = envLocale(environ, "LC_NUMERIC")
+	}
+
+	loc = baseLocale(loc)
+	if len(loc) == 0 {
+		return nil
+	}
+
+	if f, ok := numberFormats[loc]; ok {
+		return f
+	}
+	if i := strings.IndexByte(loc, '_'); i > 0 {
+		return numberFormats[loc[:i]]
+	}
+	return numberFormats[loc]
+}
+
+// Return the locale named by LC_ALL, the category 'cat' (e.g.
+// LC_NUMERIC) or LANG in 'environ'
+func envLocale(environ []string, cat string) string {
+	for _, k := range []string{"LC_ALL", cat, "LANG"} {
+		if v, ok := envLookup(environ, k); ok && len(v) > 0 {
+			return v
+		}
+	}
+	return ""
+}
+
+// Strip the codeset and modifier from the locale name 'loc'
+// (ll_TT.UTF-8@euro becomes ll_TT)
+func baseLocale(loc string) string {
+	if i := strings.IndexAny(loc, ".@"); i >= 0 {
+		loc = loc[:i]
+	}
+	return strings.ReplaceAll(loc, "-", "_")
+}
+
+// Rewrite the locale formatted number 's' in the plain form that
+// strconv understands: grouping separators are dropped and the decimal
+// separator becomes '.'. A nil format returns 's' unchanged.
+func (f *numberFormat) normalize(s string) string {
+	if f == nil {
+		return s
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case strings.ContainsRune(f.group, r):
+			continue
+		case r == rune(f.decimal):
+			b.WriteByte('.')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
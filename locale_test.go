@@ -0,0 +1,49 @@
+package options
+
+import (
+	"testing"
+)
+
+func TestNumberLocale(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    num=      -n=                         Number of things
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		loc  string
+		env  []string
+		arg  string
+		want int64
+		ok   bool
+	}{
+		{"", nil, "1,234", 0, false},
+		{"en_US", nil, "1,234", 1234, true},
+		{"de_DE.UTF-8", nil, "1.234", 1234, true},
+		{"fr_FR", nil, "1 234", 1234, true},
+		{"de_CH", nil, "1'234", 1234, true},
+		{"auto", []string{"LANG=C", "LC_NUMERIC=de_AT.UTF-8"}, "12.345", 12345, true},
+		{"auto", []string{"LC_ALL=fr_FR", "LANG=en_US"}, "12 345", 12345, true},
+		{"xx_YY", nil, "42", 42, true},
+	}
+
+	for i, tc := range tests {
+		spec.SetNumberLocale(tc.loc)
+		opts, err := spec.Interpret([]string{"tool", "-n", tc.arg}, tc.env)
+		if err != nil {
+			t.Fatalf("%d: %s", i, err)
+		}
+
+		v, ok := opts.GetInt("num")
+		if ok != tc.ok || v != tc.want {
+			t.Errorf("%d: %s %q: expected %d/%v, saw %d/%v", i, tc.loc, tc.arg, tc.want, tc.ok, v, ok)
+		}
+	}
+}
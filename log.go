@@ -0,0 +1,39 @@
+// log.go - Debug logging of the parse
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+//go:build !tinygo
+
+package options
+
+import (
+	"context"
+	"log/slog"
+)
+
+// The debug logger of a spec; see SetLogger()
+type debugLogger = *slog.Logger
+
+// Log the milestones of Interpret() - the environment variables and
+// command line options consulted, values that replace others and the
+// profile applied - to 'l' at the Debug level. This helps operators
+// diagnose the configuration of daemons. A nil 'l' turns logging off.
+func (spec *Spec) SetLogger(l *slog.Logger) {
+	spec.logger = l
+}
+
+// Log 'msg' with the key-value pairs 'args' at the Debug level. The
+// callers pass values and arguments through redact() and redactArg()
+// so that secrets stay out of the log.
+func (spec *Spec) debug(msg string, args ...any) {
+	if spec.logger == nil || !spec.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	spec.logger.Debug(msg, args...)
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
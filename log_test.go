@@ -0,0 +1,86 @@
+//go:build !tinygo
+
+package options
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=     -r,--root=,TOOL_ROOT        Data root
+    --
+    --
+    run       run                         Run it
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	var b strings.Builder
+	spec.SetLogger(slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	_, err = spec.Interpret([]string{"tool", "-r", "/cli", "run"}, []string{"TOOL_ROOT=/env"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`msg="option from env" option=root env=TOOL_ROOT value=/env`,
+		`msg="option overrides env" option=root arg=-r`,
+		`msg="option from command line" option=root arg=-r value=/cli`,
+		`msg=command command=run`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("log is missing %q:\n%s", want, b.String())
+		}
+	}
+
+	b.Reset()
+	spec.SetLogger(slog.New(slog.NewTextHandler(&b, nil)))
+	spec.Interpret([]string{"tool", "-r", "/cli"}, nil)
+	if b.Len() > 0 {
+		t.Errorf("logged above the debug level:\n%s", b.String())
+	}
+}
+
+func TestLoggerRedacts(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    ^token=   -t,--token=,TOOL_TOKEN      API token
+    verbose   -v                          Verbose
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+	spec.SetFlagsEnv("TOOL_FLAGS")
+
+	var b strings.Builder
+	spec.SetLogger(slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	env := []string{"TOOL_TOKEN=envsecret", "TOOL_FLAGS=-t flagsecret -tattached -vtclustered"}
+	_, err = spec.Interpret([]string{"tool", "--token=hunter2"}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{"envsecret", "flagsecret", "attached", "clustered", "hunter2"} {
+		if strings.Contains(b.String(), s) {
+			t.Errorf("log shows the secret %s:\n%s", s, b.String())
+		}
+	}
+	if !strings.Contains(b.String(), `arg="--token=<redacted>" value=<redacted>`) {
+		t.Errorf("log is missing the redacted option:\n%s", b.String())
+	}
+}
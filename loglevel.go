@@ -0,0 +1,74 @@
+// loglevel.go - Log level value type
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+//go:build !tinygo
+
+package options
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	valueTypes["loglevel"] = func(s string) error {
+		_, err := parseLogLevel(s)
+		return err
+	}
+	typedValues["loglevel"] = func(s string) (any, error) {
+		return parseLogLevel(s)
+	}
+}
+
+// Interpret the option corresponding to the key 'nm' as a log level:
+// one of debug, info, warn (or warning) and error in any case, with an
+// optional offset ("info+2"), or a number. The second retval will be
+// false if the parse fails or the key is not found.
+func (opts *Options) GetLogLevel(nm string) (slog.Level, bool) {
+	return memo(opts, convLogLevel, nm, func(v string) (slog.Level, bool) {
+		l, err := parseLogLevel(v)
+		return l, err == nil
+	})
+}
+
+// Map the number of times the flag 'nm' was given (see GetCount()) to
+// a log level: 'levels' lists the level for zero, one, two ...
+// occurrences and the last one applies to higher counts as well. With
+// no 'levels' the mapping is warn, info, debug, i.e. "-v" logs info
+// and "-vv" debug messages.
+func (opts *Options) GetVerbosity(nm string, levels ...slog.Level) slog.Level {
+	if len(levels) == 0 {
+		levels = []slog.Level{slog.LevelWarn, slog.LevelInfo, slog.LevelDebug}
+	}
+
+	n := opts.GetCount(nm)
+	if n >= len(levels) {
+		n = len(levels) - 1
+	}
+	return levels[n]
+}
+
+func parseLogLevel(s string) (slog.Level, error) {
+	var l slog.Level
+
+	if i, err := strconv.Atoi(s); err == nil {
+		return slog.Level(i), nil
+	}
+
+	if strings.HasPrefix(strings.ToLower(s), "warning") {
+		s = "warn" + s[len("warning"):]
+	}
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return l, fmt.Errorf("unknown log level %q", s)
+	}
+	return l, nil
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,100 @@
+//go:build !tinygo
+
+package options
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestLogLevel(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    level=info:loglevel  -l,--log-level=   Log level
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]slog.Level{
+		"":        slog.LevelInfo,
+		"debug":   slog.LevelDebug,
+		"WARN":    slog.LevelWarn,
+		"Warning": slog.LevelWarn,
+		"error":   slog.LevelError,
+		"info+2":  slog.LevelInfo + 2,
+		"-8":      slog.Level(-8),
+	}
+
+	for in, want := range tests {
+		args := []string{"tool"}
+		if len(in) > 0 {
+			args = append(args, "--log-level="+in)
+		}
+
+		opts, err := spec.Interpret(args, []string{})
+		if err != nil {
+			t.Fatalf("%s: %s", in, err)
+		}
+		if l, ok := opts.GetLogLevel("level"); !ok || l != want {
+			t.Errorf("%s: expected %v, saw %v", in, want, l)
+		}
+	}
+
+	if _, err = spec.Interpret([]string{"tool", "-l", "loud"}, []string{}); err == nil {
+		t.Error("expected an error for an invalid level")
+	}
+}
+
+func TestVerbosity(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    verbose   -v,--verbose                More output
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		n     int
+		want  slog.Level
+		steps slog.Level
+	}{
+		{0, slog.LevelWarn, slog.LevelError},
+		{1, slog.LevelInfo, slog.LevelWarn},
+		{2, slog.LevelDebug, slog.LevelInfo},
+		{4, slog.LevelDebug, slog.LevelDebug},
+	}
+
+	for _, tc := range tests {
+		args := []string{"tool"}
+		for i := 0; i < tc.n; i++ {
+			args = append(args, "-v")
+		}
+
+		opts, err := spec.Interpret(args, []string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n := opts.GetCount("verbose"); n != tc.n {
+			t.Errorf("expected count %d, saw %d", tc.n, n)
+		}
+		if l := opts.GetVerbosity("verbose"); l != tc.want {
+			t.Errorf("%d: expected %v, saw %v", tc.n, tc.want, l)
+		}
+
+		l := opts.GetVerbosity("verbose", slog.LevelError, slog.LevelWarn, slog.LevelInfo, slog.LevelDebug)
+		if l != tc.steps {
+			t.Errorf("%d: expected %v with custom steps, saw %v", tc.n, tc.steps, l)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// map.go - Map valued options
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"strings"
+)
+
+// Return the "key=value" values of option 'nm' as a map, so that
+// "-D a=1 -D b=2 -D a=3" yields {a:3 b:2}: a later occurrence of a key
+// replaces an earlier one and a value without "=", such as "-D debug",
+// maps the key to "". Blanks around the keys are removed and empty
+// keys are dropped. If the option isn't given, its default is used
+// instead; a nil map implies neither is set.
+func (opts *Options) GetMap(nm string) map[string]string {
+	vals := opts.optionv[nm]
+	if len(vals) == 0 {
+		v, ok := opts.defaults[nm]
+		if !ok {
+			return nil
+		}
+		vals = []string{v}
+	}
+
+	rv := make(map[string]string, len(vals))
+	for _, v := range vals {
+		k, val, _ := strings.Cut(v, "=")
+		if k = strings.TrimSpace(k); len(k) > 0 {
+			rv[k] = val
+		}
+	}
+	return rv
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
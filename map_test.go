@@ -0,0 +1,39 @@
+package options
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestGetMap(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    define=       -D,--define=              Define a symbol
+    label=prod    -l,--label=               Labels
+    tag=          -t,--tag=                 Tags
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	opts, err := spec.Interpret([]string{"tool", "-D", "a=1", "--define=b=x=y", "-D", "debug", "-D", "a=3", "-D", " =z"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"a": "3", "b": "x=y", "debug": ""}
+	if m := opts.GetMap("define"); !maps.Equal(m, want) {
+		t.Errorf("bad define: %v", m)
+	}
+	if m := opts.GetMap("label"); !maps.Equal(m, map[string]string{"prod": ""}) {
+		t.Errorf("bad label default: %v", m)
+	}
+	if m := opts.GetMap("tag"); m != nil {
+		t.Errorf("expected nil map, got %v", m)
+	}
+}
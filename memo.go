@@ -0,0 +1,75 @@
+// memo.go - Memoized typed conversions
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+// Kinds of typed conversions cached by Options
+const (
+	convBool = iota
+	convInt
+	convUint
+	convLocation
+	convLogLevel
+	convFloat
+	convDuration
+	convSize
+	convTyped
+)
+
+type memoKey struct {
+	kind int
+	nm   string
+}
+
+type memoEntry struct {
+	v  any
+	ok bool
+}
+
+// Return the result of converting the value of option 'nm' with 'conv',
+// computing it only on first use. Typed getters are often called in
+// hot paths; this saves re-running strconv on every call. A missing
+// option is cached as a failed conversion.
+func memo[T any](opts *Options, kind int, nm string, conv func(string) (T, bool)) (T, bool) {
+	k := memoKey{kind, nm}
+
+	opts.mu.RLock()
+	e, ok := opts.memo[k]
+	opts.mu.RUnlock()
+
+	if ok {
+		return e.v.(T), e.ok
+	}
+
+	var v T
+	if s, ok := opts.Get(nm); ok {
+		v, ok = conv(s)
+		e = memoEntry{v, ok}
+	} else {
+		e = memoEntry{v, false}
+	}
+
+	opts.mu.Lock()
+	if opts.memo == nil {
+		opts.memo = make(map[memoKey]memoEntry)
+	}
+	opts.memo[k] = e
+	opts.mu.Unlock()
+
+	return v, e.ok
+}
+
+// Forget all memoized conversions; this must be called whenever the
+// option values change.
+func (opts *Options) forget() {
+	opts.mu.Lock()
+	opts.memo = nil
+	opts.mu.Unlock()
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,53 @@
+package options
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestMemo(t *testing.T) {
+	spec, err := Parse(`
+    usage: memo
+    --
+    num=2     -n=                         Number of things
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"memo", "-n", "5"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, ok := opts.GetInt("num"); !ok || v != 5 {
+				t.Errorf("-n != 5; saw %d", v)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the cached conversion survives a change to the raw value until
+	// the cache is dropped
+	opts.options["num"] = "7"
+	if v, _ := opts.GetInt("num"); v != 5 {
+		t.Errorf("expected memoized 5, saw %d", v)
+	}
+
+	opts.forget()
+	if v, _ := opts.GetInt("num"); v != 7 {
+		t.Errorf("expected 7, saw %d", v)
+	}
+
+	if _, ok := opts.GetUint("missing"); ok {
+		t.Error("missing option must not convert")
+	}
+}
@@ -0,0 +1,67 @@
+// merge.go - Composing specs
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Return a new spec with the options, environment variables and
+// commands of 'base' followed by those of 'extra', e.g. to share a
+// common block of flags across a family of tools. The usage section of
+// 'extra' replaces that of 'base' unless it is empty; the metadata,
+// profiles, rules, presets and appendices are combined with those of
+// 'extra' winning. An option, spelling, environment variable or
+// command that both specs declare is an error. Neither spec is
+// modified and, as with String(), the settings made from code are not
+// carried over.
+func Merge(base, extra *Spec) (*Spec, error) {
+	about := extra.about
+	if len(about) == 0 {
+		about = base.about
+	}
+
+	b := NewSpec().Usage(about...)
+	redefined := make(map[string]bool)
+	for _, m := range extra.meta {
+		redefined[m.key] = true
+	}
+	for _, m := range base.meta {
+		if !redefined[m.key] {
+			b.Usage(metaLine(m))
+		}
+	}
+	for _, m := range extra.meta {
+		b.Usage(metaLine(m))
+	}
+
+	base.addEntries(b)
+	extra.addEntries(b)
+	b.Appendix(base.appendix...)
+	b.Appendix(extra.appendix...)
+	base.addEpilogs(b)
+	extra.addEpilogs(b)
+
+	spec, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	if dup := spec.duplicates(); len(dup) > 0 {
+		w := make([]string, len(dup))
+		for i, p := range dup {
+			w[i] = p.Message
+		}
+		return nil, fmt.Errorf("Invalid spec: %s", strings.Join(w, "; "))
+	}
+	return spec, nil
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
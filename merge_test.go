@@ -0,0 +1,91 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	common, err := Parse(`
+    usage: common
+    @version 1.0
+    --
+    verbose      -v,--verbose          Verbose
+    ## Logging
+    log=info     --log=LEVEL           Log level
+    --
+    home=        TOOL_HOME             Home directory
+    --
+    --
+    Report bugs to the team.
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tool, err := Parse(`
+    usage: tool [options] command
+    @version 2.0
+    --
+    root=/var    -r,--root=DIR         Data root
+    --
+    --
+    exec         exec,x                Run a program
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := Merge(common, tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(spec.usage, "usage: tool") {
+		t.Errorf("bad usage: %q", spec.usage)
+	}
+	if v := spec.Meta("version"); v != "2.0" {
+		t.Errorf("bad version: %q", v)
+	}
+	if !strings.Contains(spec.usage, "Report bugs") {
+		t.Errorf("appendix missing: %q", spec.usage)
+	}
+	if g := spec.OptionGroups(); len(g) != 1 || g[0].Title != "Logging" || len(spec.groupOptions("")) != 2 {
+		t.Errorf("bad groups: %+v", g)
+	}
+
+	spec.SetSetenv(false)
+	opts, err := spec.Interpret([]string{"tool", "-v", "--log=debug", "-r", "/tmp", "x"}, []string{"TOOL_HOME=/opt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.GetBool("verbose") || opts.Command != "exec" {
+		t.Errorf("bad merged options: %+v", opts)
+	}
+	for nm, want := range map[string]string{"log": "debug", "root": "/tmp", "home": "/opt"} {
+		if v, _ := opts.Get(nm); v != want {
+			t.Errorf("%s: expected %q, saw %q", nm, want, v)
+		}
+	}
+
+	clash, err := Parse(`
+    usage: other
+    --
+    version      -v,--version          Show the version
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Merge(common, clash)
+	if err == nil || !strings.Contains(err.Error(), "-v is bound to verbose and version") {
+		t.Errorf("expected a conflict, saw %v", err)
+	}
+
+	_, err = Merge(tool, tool)
+	if err == nil || !strings.Contains(err.Error(), "root is declared more than once") {
+		t.Errorf("expected a conflict, saw %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+// messages.go - Error message templates
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+)
+
+// Keys of the messages produced by Interpret(). Each message is a fmt
+// format string; the comment lists its arguments in order. Templates
+// can reorder the arguments with explicit indexes (e.g. "%[2]s").
+const (
+	MsgUnknownOption   = "unknown-option"   // the argument
+	MsgUnknownOptHint  = "unknown-opt-hint" // the argument, the suggestions
+	MsgNoValue         = "no-value"         // the argument
+	MsgNeedsValue      = "needs-value"      // the argument
+	MsgUnknownArg      = "unknown-arg"      // the argument
+	MsgUnknownCommand  = "unknown-command"  // the argument, the suggestions
+	MsgAmbiguousCmd    = "ambiguous-cmd"    // the argument, the candidates
+	MsgAmbiguousOpt    = "ambiguous-opt"    // the argument, the candidates
+	MsgMissingOption   = "missing-option"   // the option and its spellings
+	MsgMissingOptions  = "missing-options"  // the list of missing options
+	MsgScopedOption    = "scoped-option"    // the argument, the commands
+	MsgUnknownProfile  = "unknown-profile"  // the profile, the profiles
+	MsgUnknownDefault  = "unknown-default"  // the option name
+	MsgBadFlagsEnv     = "bad-flags-env"    // the env var, the error
+	MsgFuncFailed      = "func-failed"      // the argument, the error
+	MsgBadValue        = "bad-value"        // the argument, the value, the type
+	MsgBadChoice       = "bad-choice"       // the argument, the value, the choices
+	MsgTooManyArgs     = "too-many-args"    // the number of args, the limit
+	MsgValueTooLong    = "value-too-long"   // the argument, the limit
+	MsgTooManyRepeats  = "too-many-repeat"  // the argument, the limit
+	MsgRequiresOption  = "requires-option"  // the option, the required option
+	MsgConflictsOption = "conflicts-option" // the option, the other option
+	MsgDeprecated      = "deprecated"       // the argument, the message
+	MsgRemovedOption   = "removed-option"   // the argument, the version
+	MsgUnknownConfig   = "unknown-config"   // the key
+	MsgMissingArg      = "missing-arg"      // the argument name
+	MsgBadArgFile      = "bad-arg-file"     // the argument, the error
+	MsgMissingCommand  = "missing-command"  // the commands
+	MsgBadSecret       = "bad-secret"       // the argument, the error
+	MsgNoCommand       = "no-command"       // the argument, the commands
+	MsgTooFewRepeats   = "too-few-repeat"   // the option and its spellings, the minimum
+	MsgTooFewRepeats1  = "too-few-once"     // the option and its spellings
+	MsgTooManyRepeats1 = "too-many-once"    // the argument
+	MsgMissingEnv      = "missing-env"      // the variables
+	MsgMigratedValue   = "migrated-value"   // the argument, the old value, the new value
+	MsgBadPath         = "bad-path"         // the argument, the error
+	MsgBadConstraint   = "bad-constraint"   // the argument, the value, the constraint
+	MsgMissingOneOf    = "missing-one-of"   // the options of the group
+	MsgTooManyOneOf    = "too-many-one-of"  // the option, the other option, the options of the group
+)
+
+// Keys of the headings and notes of FormatUsage() and CommandUsage();
+// they are translated with SetMessages() like the error messages.
+const (
+	MsgOptionsHeading  = "options-heading"  // none
+	MsgEnvHeading      = "env-heading"      // none
+	MsgCommandsHeading = "commands-heading" // none
+	MsgAliasesLine     = "aliases-line"     // the aliases
+	MsgUsageLine       = "usage-line"       // the command synopsis
+	MsgDefaultNote     = "default-note"     // the default value
+	MsgEnvNote         = "env-note"         // the env vars
+	MsgChoicesNote     = "choices-note"     // the choices
+	MsgConstraintNote  = "constraint-note"  // the constraint
+	MsgRequiredNote    = "required-note"    // none
+	MsgDefaultCmdNote  = "default-cmd-note" // none
+	MsgPresetHelp      = "preset-help"      // the expansion of the preset
+)
+
+// A set of message templates indexed by the Msg* keys
+type Messages map[string]string
+
+// The default (English) message templates
+var DefaultMessages = Messages{
+	MsgUnknownOption:   "Invalid option: %s was not recognized",
+	MsgUnknownOptHint:  "Invalid option: %s was not recognized (did you mean %s?)",
+	MsgNoValue:         "Invalid option: %s was not recognized (doesn't take a value)",
+	MsgNeedsValue:      "Invalid option: %s was not recognized (requires a value)",
+	MsgUnknownArg:      "Invalid argument: %s was not recognized",
+	MsgUnknownCommand:  "Invalid argument: %s was not recognized (did you mean %s?)",
+	MsgAmbiguousCmd:    "Invalid argument: %s is ambiguous (could be %s)",
+	MsgAmbiguousOpt:    "Invalid option: %s is ambiguous (could be %s)",
+	MsgMissingOption:   "Missing option: %s",
+	MsgMissingOptions:  "Missing options: %s",
+	MsgScopedOption:    "Invalid option: %s is only valid with the %s command",
+	MsgUnknownProfile:  "Invalid profile: %s (choose from %s)",
+	MsgUnknownDefault:  "Invalid default: %s is not a known option",
+	MsgBadFlagsEnv:     "Invalid %s: %s",
+	MsgFuncFailed:      "Invalid option: %s: %s",
+	MsgBadValue:        "Invalid option: %s: %s is not a valid %s",
+	MsgBadChoice:       "Invalid option: %s: %s is not one of %s",
+	MsgTooManyArgs:     "Too many arguments: %d (at most %d are allowed)",
+	MsgValueTooLong:    "Invalid option: %s: value is longer than %d bytes",
+	MsgTooManyRepeats:  "Invalid option: %s: given more than %d times",
+	MsgRequiresOption:  "Invalid option: %s requires %s",
+	MsgConflictsOption: "Invalid option: %s can't be used with %s",
+	MsgDeprecated:      "Deprecated option: %s: %s",
+	MsgRemovedOption:   "Invalid option: %s was removed in version %s",
+	MsgUnknownConfig:   "Invalid config: %s is not a known option",
+	MsgMissingArg:      "Missing argument: %s",
+	MsgBadArgFile:      "Invalid argument: %s: %s",
+	MsgMissingCommand:  "Missing command: expected %s",
+	MsgBadSecret:       "Invalid option: %s: %s",
+	MsgNoCommand:       "Invalid argument: %s was not recognized; the commands are:\n%s",
+	MsgTooFewRepeats:   "Missing option: %s must be given at least %d times",
+	MsgTooFewRepeats1:  "Missing option: %s must be given at least once",
+	MsgTooManyRepeats1: "Invalid option: %s: may be given only once",
+	MsgMissingEnv:      "Missing environment variable: %s",
+	MsgMigratedValue:   "Deprecated value: %s: %s is now %s",
+	MsgBadPath:         "Invalid option: %s: %s",
+	MsgBadConstraint:   "Invalid option: %s: %s does not satisfy {%s}",
+	MsgMissingOneOf:    "Missing option: exactly one of %s is required",
+	MsgTooManyOneOf:    "Invalid option: %s can't be used with %s (exactly one of %s is allowed)",
+
+	MsgOptionsHeading:  "Options:",
+	MsgEnvHeading:      "Environment:",
+	MsgCommandsHeading: "Commands:",
+	MsgAliasesLine:     "Aliases: %s",
+	MsgUsageLine:       "usage: %s",
+	MsgDefaultNote:     "(default: %s)",
+	MsgEnvNote:         "(env: %s)",
+	MsgChoicesNote:     "(choices: %s)",
+	MsgConstraintNote:  "(must be {%s})",
+	MsgRequiredNote:    "(required)",
+	MsgDefaultCmdNote:  "(default)",
+	MsgPresetHelp:      "Same as %s",
+}
+
+// Override the templates of the messages produced by Interpret() and
+// of the usage headings with those in 'msgs'. Keys missing from 'msgs'
+// keep their current templates, so a product can change just the
+// messages it cares about.
+func (spec *Spec) SetMessages(msgs Messages) {
+	if spec.messages == nil {
+		spec.messages = make(Messages)
+	}
+	for k, v := range msgs {
+		spec.messages[k] = v
+	}
+}
+
+// Return the template of message 'key'
+func (spec *Spec) message(key string) string {
+	if tmpl, ok := spec.messages[key]; ok {
+		return tmpl
+	}
+	return DefaultMessages[key]
+}
+
+// Return the usage text for message 'key' formatted with 'args'
+func (spec *Spec) text(key string, args ...any) string {
+	if len(args) == 0 {
+		return spec.message(key)
+	}
+	return fmt.Sprintf(spec.message(key), args...)
+}
+
+// Return the error for message 'key' formatted with 'args'; an error
+// among 'args' becomes the underlying error.
+func (spec *Spec) errorf(key string, args ...any) error {
+	e := &Error{Key: key, Index: -1, msg: fmt.Sprintf(spec.message(key), args...)}
+	for _, a := range args {
+		if err, ok := a.(error); ok {
+			e.Err = err
+		}
+	}
+	return e
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
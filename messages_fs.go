@@ -0,0 +1,55 @@
+// messages_fs.go - Message translations from a file system
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+//go:build !tinygo
+
+package options
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Load the message translations for the locale named by LC_ALL,
+// LC_MESSAGES or LANG in 'environ' from the directory 'dir' of 'fsys'
+// (typically an embed.FS) and install them with SetMessages(). The
+// translations for a locale such as "de_CH.UTF-8" are read from
+// "de_CH.json" or, failing that, "de.json"; each file is a JSON object
+// mapping the Msg* keys to templates. A locale without translations
+// (including "C" and "POSIX") keeps the current messages.
+func (spec *Spec) LoadMessages(fsys fs.FS, dir string, environ []string) error {
+	loc := baseLocale(envLocale(environ, "LC_MESSAGES"))
+	if len(loc) == 0 {
+		return nil
+	}
+
+	names := []string{loc}
+	if i := strings.IndexByte(loc, '_'); i > 0 {
+		names = append(names, loc[:i])
+	}
+
+	for _, nm := range names {
+		b, err := fs.ReadFile(fsys, path.Join(dir, nm+".json"))
+		if err != nil {
+			continue
+		}
+
+		var msgs Messages
+		if err = json.Unmarshal(b, &msgs); err != nil {
+			return fmt.Errorf("Invalid messages %s: %w", nm+".json", err)
+		}
+		spec.SetMessages(msgs)
+		return nil
+	}
+	return nil
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,54 @@
+//go:build !tinygo
+
+package options
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMessages(t *testing.T) {
+	fsys := fstest.MapFS{
+		"msgs/de.json":    {Data: []byte(`{"unknown-option": "Unbekannte Option: %s"}`)},
+		"msgs/fr_CA.json": {Data: []byte(`{"unknown-option": "Option inconnue: %s"}`)},
+		"msgs/xx.json":    {Data: []byte(`{bad`)},
+	}
+
+	tests := []struct {
+		env  []string
+		want string
+	}{
+		{[]string{"LANG=de_DE.UTF-8"}, "Unbekannte Option: -x"},
+		{[]string{"LANG=de_DE", "LC_MESSAGES=fr_CA.UTF-8"}, "Option inconnue: -x"},
+		{[]string{"LC_ALL=C", "LANG=de_DE"}, "Invalid option: -x was not recognized"},
+		{[]string{}, "Invalid option: -x was not recognized"},
+	}
+
+	for _, tc := range tests {
+		spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=     -r,--root=                  Data root
+    --
+    --
+    --
+    `)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = spec.LoadMessages(fsys, "msgs", tc.env); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = spec.Interpret([]string{"tool", "-x"}, []string{})
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("%v: expected %q, saw %v", tc.env, tc.want, err)
+		}
+	}
+
+	spec, _ := Parse("usage: tool\n--\n--\n--\n--\n")
+	if err := spec.LoadMessages(fsys, "msgs", []string{"LANG=xx"}); err == nil {
+		t.Error("expected an error for malformed messages")
+	}
+}
@@ -0,0 +1,90 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMessages(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    !root=    -r,--root=                  Data root
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = spec.Interpret([]string{"tool", "-x"}, []string{})
+	if err == nil || err.Error() != "Invalid option: -x was not recognized" {
+		t.Errorf("unexpected default message: %v", err)
+	}
+
+	spec.SetMessages(Messages{
+		MsgUnknownOption: "tool doesn't know %q",
+	})
+	spec.SetMessages(Messages{
+		MsgMissingOption: "please set %s",
+	})
+
+	_, err = spec.Interpret([]string{"tool", "-x"}, []string{})
+	if err == nil || err.Error() != `tool doesn't know "-x"` {
+		t.Errorf("unexpected message: %v", err)
+	}
+
+	_, err = spec.Interpret([]string{"tool"}, []string{})
+	if err == nil || err.Error() != "please set root (-r, --root)" {
+		t.Errorf("unexpected message: %v", err)
+	}
+
+	_, err = spec.Interpret([]string{"tool", "-r"}, []string{})
+	if err == nil || err.Error() != "Invalid option: -r was not recognized (requires a value)" {
+		t.Errorf("unexpected message: %v", err)
+	}
+}
+
+func TestUsageMessages(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] command
+    --
+    !root=/var   -r,--root=DIR        Data root
+    --
+    --
+    list         ls,list              List entries
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetUsageWidth(80)
+	spec.SetMessages(Messages{
+		MsgOptionsHeading:  "Optionen:",
+		MsgCommandsHeading: "Befehle:",
+		MsgDefaultNote:     "(Standard: %s)",
+		MsgRequiredNote:    "(erforderlich)",
+		MsgUsageLine:       "Aufruf: %s",
+		MsgAliasesLine:     "Aliase: %s",
+	})
+
+	want := `usage: tool [options] command
+
+Optionen:
+  -r DIR, --root=DIR  Data root (Standard: /var) (erforderlich)
+
+Befehle:
+  ls, list  List entries`
+	if got := spec.FormatUsage(); got != want {
+		t.Errorf("expected\n%s\nsaw\n%s", want, got)
+	}
+
+	u, err := spec.CommandUsage("list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(u, "Aufruf: tool ") || !strings.Contains(u, "Aliase: ls, list") {
+		t.Errorf("untranslated command usage:\n%s", u)
+	}
+}
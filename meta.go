@@ -0,0 +1,111 @@
+// meta.go - Spec metadata
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// A "@key value" line from the usage section
+type metaField struct {
+	key   string
+	value string
+}
+
+// Return the metadata value for 'key' (e.g. "version", "author",
+// "homepage" or "license") declared with a "@key value" line in the
+// usage section of the spec. Keys are case insensitive.
+func (spec *Spec) Meta(key string) string {
+	for _, m := range spec.meta {
+		if strings.EqualFold(m.key, key) {
+			return m.value
+		}
+	}
+	return ""
+}
+
+// Return the version declared in the spec ("@version") or set with
+// SetVersion(); see also SetBuildInfo().
+func (spec *Spec) Version() string {
+	v := spec.Meta("version")
+	if len(v) == 0 && spec.build_info {
+		v = buildVersion()
+	}
+	return v
+}
+
+// Set the version of the program, overriding the one declared in the
+// spec. This is typically set from a value stamped in at build time.
+func (spec *Spec) SetVersion(v string) {
+	for i := range spec.meta {
+		if strings.EqualFold(spec.meta[i].key, "version") {
+			spec.meta[i].value = v
+			return
+		}
+	}
+	spec.meta = append(spec.meta, metaField{key: "version", value: v})
+}
+
+// Return the text printed for "--version": the program name and
+// version followed by the other metadata, one per line.
+func (spec *Spec) VersionString() string {
+	var b strings.Builder
+
+	b.WriteString(spec.progname())
+	if v := spec.Version(); len(v) > 0 {
+		fmt.Fprintf(&b, " version %s", v)
+	}
+	b.WriteString("\n")
+
+	for _, m := range spec.meta {
+		if !strings.EqualFold(m.key, "version") {
+			fmt.Fprintf(&b, "%s: %s\n", metaTitle(m.key), m.value)
+		}
+	}
+	if spec.build_info {
+		for _, l := range buildLines() {
+			fmt.Fprintf(&b, "%s\n", l)
+		}
+	}
+	return b.String()
+}
+
+// Record the metadata line "key value"
+func (spec *Spec) addMeta(line string) error {
+	key, value, _ := strings.Cut(line, " ")
+	if len(key) == 0 || strings.ContainsFunc(key, unicode.IsSpace) {
+		return fmt.Errorf("Invalid metadata: @%s has no key", line)
+	}
+	spec.meta = append(spec.meta, metaField{key: key, value: strings.TrimSpace(value)})
+	return nil
+}
+
+// Return the footer lines of the usage text that show the metadata
+func (spec *Spec) metaFooter() []string {
+	if len(spec.meta) == 0 {
+		return nil
+	}
+
+	rv := []string{""}
+	for _, m := range spec.meta {
+		rv = append(rv, fmt.Sprintf("%s: %s", metaTitle(m.key), m.value))
+	}
+	return rv
+}
+
+func metaTitle(key string) string {
+	if len(key) == 0 {
+		return key
+	}
+	return strings.ToUpper(key[:1]) + key[1:]
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
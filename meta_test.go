@@ -0,0 +1,102 @@
+package options
+
+import (
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestMeta(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    Does things
+    @version 1.2.3
+    @author  Jane Doe <jane@example.com>
+    @license BSD-2-Clause
+    --
+    verbose   -v,--verbose                Show more info
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if spec.Version() != "1.2.3" || spec.Meta("Author") != "Jane Doe <jane@example.com>" {
+		t.Errorf("bad metadata: %q %q", spec.Version(), spec.Meta("author"))
+	}
+	if spec.Meta("homepage") != "" {
+		t.Error("homepage wasn't declared")
+	}
+
+	u := spec.Usage()
+	if strings.Contains(u, "@version") || !strings.HasSuffix(u, "License: BSD-2-Clause") {
+		t.Errorf("bad usage footer:\n%s", u)
+	}
+
+	v := spec.VersionString()
+	if !strings.HasPrefix(v, "tool version 1.2.3\n") || !strings.Contains(v, "Author: Jane Doe") {
+		t.Errorf("bad version string:\n%s", v)
+	}
+
+	if md := spec.MarkdownPages()["tool.md"]; !strings.Contains(md, "* License: BSD-2-Clause") {
+		t.Errorf("metadata missing from docs:\n%s", md)
+	}
+}
+
+func TestBuildInfo(t *testing.T) {
+	defer func(fn func() (*debug.BuildInfo, bool)) {
+		readBuildInfo = fn
+	}(readBuildInfo)
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			GoVersion: "go1.22.0",
+			Main:      debug.Module{Path: "example.com/tool", Version: "v1.4.2"},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "abc123"},
+				{Key: "vcs.time", Value: "2024-01-02T03:04:05Z"},
+				{Key: "vcs.modified", Value: "true"},
+			},
+		}, true
+	}
+
+	spec, err := Parse(`
+    usage: tool [options]
+    @author A. Hacker
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := spec.Version(); v != "" {
+		t.Errorf("expected no version, saw %q", v)
+	}
+
+	spec.SetBuildInfo(true)
+	if v := spec.Version(); v != "v1.4.2" {
+		t.Errorf("expected the module version, saw %q", v)
+	}
+
+	want := "tool version v1.4.2\nAuthor: A. Hacker\nRevision: abc123 (modified)\nBuilt: 2024-01-02T03:04:05Z\nGo: go1.22.0\n"
+	if s := spec.VersionString(); s != want {
+		t.Errorf("expected %q, saw %q", want, s)
+	}
+
+	spec.SetVersion("2.0")
+	if v := spec.Version(); v != "2.0" {
+		t.Errorf("expected the declared version, saw %q", v)
+	}
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}}, true
+	}
+	spec.SetVersion("")
+	if v := spec.Version(); v != "" {
+		t.Errorf("expected no version for a devel build, saw %q", v)
+	}
+}
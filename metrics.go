@@ -0,0 +1,50 @@
+// metrics.go - Metrics hooks
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"errors"
+)
+
+// Callbacks that let services embedding the parser count what it sees,
+// e.g. with Prometheus counters. Any of them can be nil.
+type Metrics struct {
+	// called at the start of every Interpret()
+	Attempt func()
+
+	// called when Interpret() fails; 'key' is the message key (one
+	// of the Msg* constants) of the error, e.g. MsgUnknownOption
+	Failure func(key string)
+
+	// called for every use of a deprecated option; 'tok' is the
+	// spelling that was used
+	Deprecated func(option, tok string)
+}
+
+// Install the metrics callbacks 'm'
+func (spec *Spec) SetMetrics(m Metrics) {
+	spec.metrics = m
+}
+
+// Report the failure 'err' of Interpret()
+func (spec *Spec) countFailure(err error) {
+	fn := spec.metrics.Failure
+	if fn == nil {
+		return
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		fn(e.Key)
+	} else {
+		fn("")
+	}
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
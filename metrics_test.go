@@ -0,0 +1,38 @@
+package options
+
+import (
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=     -r,--root=                  Data root
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attempts := 0
+	failures := map[string]int{}
+	spec.SetMetrics(Metrics{
+		Attempt: func() { attempts++ },
+		Failure: func(key string) { failures[key]++ },
+	})
+
+	spec.Interpret([]string{"tool", "-r", "x"}, nil)
+	spec.Interpret([]string{"tool", "-x"}, nil)
+	spec.Interpret([]string{"tool", "--nope"}, nil)
+	spec.Interpret([]string{"tool", "-r"}, nil)
+
+	if attempts != 4 {
+		t.Errorf("expected 4 attempts, saw %d", attempts)
+	}
+	if failures[MsgUnknownOption] != 2 || failures[MsgNeedsValue] != 1 || len(failures) != 2 {
+		t.Errorf("bad failure counts: %v", failures)
+	}
+}
@@ -0,0 +1,132 @@
+// migrate.go - Renamed option values
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Parse a "[migrate OPT] old=new ..." line. The option is verified by
+// checkMigrations() once all the options are declared.
+func (spec *Spec) parseMigration(line string) error {
+	i := strings.IndexByte(line, ']')
+	if i < 0 {
+		return fmt.Errorf("Invalid migration spec: %s", line)
+	}
+
+	nm := strings.TrimSpace(line[len("[migrate "):i])
+	if len(nm) == 0 {
+		return fmt.Errorf("Invalid migration spec: %s", line)
+	}
+
+	words, err := SplitPOSIX(line[i+1:])
+	if err != nil {
+		return fmt.Errorf("Invalid migration spec: %s: %s", line, err)
+	}
+	if len(words) == 0 {
+		return fmt.Errorf("Invalid migration spec: %s", line)
+	}
+
+	for _, w := range words {
+		old, val, ok := strings.Cut(w, "=")
+		if !ok || len(old) == 0 || old == val {
+			return fmt.Errorf("Invalid migration spec: %s: %s is not of the form old=new", line, w)
+		}
+		spec.addMigration(nm, old, val)
+	}
+	return nil
+}
+
+// Record that value 'old' of option 'nm' is now 'val'
+func (spec *Spec) addMigration(nm, old, val string) {
+	if spec.migrations == nil {
+		spec.migrations = make(map[string]map[string]string)
+	}
+
+	m, ok := spec.migrations[nm]
+	if !ok {
+		m = make(map[string]string)
+		spec.migrations[nm] = m
+	}
+	m[old] = val
+}
+
+// Verify that the migrations name declared options and that the new
+// values are among the choices of those options
+func (spec *Spec) checkMigrations() error {
+	for _, nm := range sortedKeys(spec.migrations) {
+		o := spec.optinfo[nm]
+		if o == nil {
+			return fmt.Errorf("Invalid migration spec: %s is not a known option", nm)
+		}
+		if err := spec.checkMigration(o, spec.migrations[nm]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify that the new values of the migrations 'm' of option 'o' are
+// valid choices
+func (spec *Spec) checkMigration(o *optspec, m map[string]string) error {
+	if len(o.choices) == 0 {
+		return nil
+	}
+
+	for _, old := range sortedKeys(m) {
+		if !slices.Contains(o.choices, m[old]) {
+			return fmt.Errorf("Invalid migration spec: %s: %s is not one of %s", o.name, m[old], orList(o.choices))
+		}
+	}
+	return nil
+}
+
+// Rewrite the value 'old' of option 'nm' to 'val' wherever it is given
+// (the command line, the environment or a config file) and record a
+// warning (see Options.Warnings()) each time; this is the same as the
+// spec line "[migrate nm] old=val". It lets a tool rename the values of
+// an option, e.g. of a choice like "Output format {text,json}",
+// without breaking the scripts that use the old names:
+//
+//	spec.MapValue("format", "plaintext", "text")
+func (spec *Spec) MapValue(nm, old, val string) error {
+	o := spec.optinfo[nm]
+	if o == nil {
+		return fmt.Errorf("Unknown option: %s", nm)
+	}
+	if len(old) == 0 || old == val {
+		return fmt.Errorf("Invalid migration: %s=%s", old, val)
+	}
+	if err := spec.checkMigration(o, map[string]string{old: val}); err != nil {
+		return err
+	}
+
+	spec.addMigration(nm, old, val)
+	return nil
+}
+
+// Return the new value of option 'nm' if 'value' was renamed, noting
+// the use of the old value in opts; 'arg' is the argument or env var
+// that supplied it.
+func (spec *Spec) migrate(opts *Options, nm, arg, value string) string {
+	val, ok := spec.migrations[nm][value]
+	if !ok {
+		return value
+	}
+
+	w := spec.optError(MsgMigratedValue, nm, arg, arg, value, val)
+	opts.warnings = append(opts.warnings, w)
+
+	spec.debug("migrated value", "option", nm, "token", spec.redactArg(nm, arg), "value", spec.redact(nm, value))
+	return val
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,86 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrateValues(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    format=text    -f,--format=F,TOOL_FORMAT  Output format {text,json}
+    level=         -l,--level=L        Level
+    [migrate format] plaintext=text js=json
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-f", "plaintext"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("format"); v != "text" {
+		t.Errorf("format: expected text, saw %s", v)
+	}
+	w := opts.Warnings()
+	if len(w) != 1 || w[0].Error() != "Deprecated value: -f: plaintext is now text" {
+		t.Errorf("unexpected warnings: %v", w)
+	}
+
+	opts, err = spec.Interpret([]string{"tool"}, []string{"TOOL_FORMAT=js"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("format"); v != "json" || len(opts.Warnings()) != 1 {
+		t.Errorf("format from env: saw %s, warnings %v", v, opts.Warnings())
+	}
+
+	opts, err = spec.Interpret([]string{"tool", "--format=json"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.Warnings()) != 0 {
+		t.Errorf("unexpected warnings: %v", opts.Warnings())
+	}
+
+	if err := spec.MapValue("level", "hi", "high"); err != nil {
+		t.Fatal(err)
+	}
+	opts, err = spec.Interpret([]string{"tool", "-l", "hi"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("level"); v != "high" || len(opts.Warnings()) != 1 {
+		t.Errorf("level: saw %s, warnings %v", v, opts.Warnings())
+	}
+
+	if err := spec.MapValue("format", "xml", "yaml"); err == nil {
+		t.Error("expected an error for a new value that isn't a choice")
+	}
+	if err := spec.MapValue("bogus", "a", "b"); err == nil {
+		t.Error("expected an error for an unknown option")
+	}
+
+	if s := spec.String(); !strings.Contains(s, "[migrate format] js=json plaintext=text") ||
+		!strings.Contains(s, "[migrate level] hi=high") {
+		t.Errorf("bad spec text:\n%s", s)
+	}
+
+	bad := []string{
+		"[migrate nope] a=b",
+		"[migrate format] plaintext",
+		"[migrate format] xml=yaml",
+		"[migrate format]",
+	}
+	for _, line := range bad {
+		_, err := Parse("usage: tool\n--\nformat=text  -f,--format=F  Output format {text,json}\n" + line + "\n--\n--\n--\n")
+		if err == nil {
+			t.Errorf("%s: expected error", line)
+		}
+	}
+}
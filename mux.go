@@ -0,0 +1,112 @@
+// mux.go - Multicall (busybox style) dispatch
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Selects the spec for a command line by the name the program was
+// invoked as, for one binary installed (or symlinked) under several
+// names:
+//
+//	mux := options.NewMux().Register("ls", lsSpec).Register("cp", cpSpec)
+//	name, opts := mux.MustInterpret(os.Args, os.Environ())
+//
+// As with busybox, the name can also be given as the first argument
+// ("tool ls -l") when the binary is run under a name that isn't
+// registered.
+type Mux struct {
+	specs map[string]*Spec
+
+	// the names in the order they were registered
+	names []string
+}
+
+// Return an empty Mux
+func NewMux() *Mux {
+	return &Mux{specs: make(map[string]*Spec)}
+}
+
+// Parse the command lines of program 'name' with 'spec'; a later
+// registration of the same name replaces the earlier one.
+func (m *Mux) Register(name string, spec *Spec) *Mux {
+	if _, ok := m.specs[name]; !ok {
+		m.names = append(m.names, name)
+	}
+	m.specs[name] = spec
+	return m
+}
+
+// Return the registered names in the order they were registered
+func (m *Mux) Names() []string {
+	return append([]string{}, m.names...)
+}
+
+// Return the name 'args' selects, its spec and the command line for
+// the spec; the spec is nil if no registered name matches.
+func (m *Mux) Lookup(args []string) (string, *Spec, []string) {
+	if len(args) == 0 {
+		return "", nil, args
+	}
+
+	nm := invokedAs(args[0])
+	if spec, ok := m.specs[nm]; ok {
+		return nm, spec, args
+	}
+
+	// "tool ls -l" runs ls with "ls -l"
+	if len(args) > 1 {
+		if spec, ok := m.specs[args[1]]; ok {
+			return args[1], spec, args[1:]
+		}
+	}
+	return nm, nil, args
+}
+
+// Return the program name in 'arg0' without its directory and, on
+// Windows, its ".exe" suffix
+func invokedAs(arg0 string) string {
+	nm := filepath.Base(arg0)
+	if ext := filepath.Ext(nm); strings.EqualFold(ext, ".exe") {
+		nm = nm[:len(nm)-len(ext)]
+	}
+	return nm
+}
+
+// Interpret 'args' and 'environ' with the spec that 'args' selects (see
+// Lookup()) and return the name it was registered under along with
+// the parsed options.
+func (m *Mux) Interpret(args []string, environ []string) (string, *Options, error) {
+	nm, spec, args := m.Lookup(args)
+	if spec == nil {
+		return nm, nil, fmt.Errorf("Unknown program: %s (choose from %s)", nm, orList(m.names))
+	}
+
+	opts, err := spec.Interpret(args, environ)
+	return nm, opts, err
+}
+
+// Like Interpret() but exit with the usage of the selected spec if
+// parsing fails (see Spec.MustInterpret()); if no registered name
+// matches, print the error to stderr and exit with status 1.
+func (m *Mux) MustInterpret(args []string, environ []string) (string, *Options) {
+	nm, spec, args := m.Lookup(args)
+	if spec == nil {
+		fmt.Fprintf(os.Stderr, "Unknown program: %s (choose from %s)\n", nm, orList(m.names))
+		exit(1)
+		return nm, nil
+	}
+	return nm, spec.MustInterpret(args, environ)
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
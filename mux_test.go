@@ -0,0 +1,73 @@
+package options
+
+import (
+	"testing"
+)
+
+func TestMux(t *testing.T) {
+	ls, err := Parse(`
+    usage: ls [options] [file...]
+    --
+    long      -l                  Long listing
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp, err := Parse(`
+    usage: cp [options] src dst
+    --
+    force     -f                  Overwrite
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := NewMux().Register("ls", ls).Register("cp", cp)
+
+	tests := []struct {
+		args []string
+		name string
+		flag string
+	}{
+		{[]string{"/usr/bin/ls", "-l", "a"}, "ls", "long"},
+		{[]string{"cp", "-f", "a", "b"}, "cp", "force"},
+		{[]string{"/opt/bin/cp.EXE", "-f", "a", "b"}, "cp", "force"},
+		{[]string{"/bin/box", "ls", "-l"}, "ls", "long"},
+	}
+	for _, tc := range tests {
+		nm, opts, err := mux.Interpret(tc.args, []string{})
+		if err != nil {
+			t.Errorf("%q: %s", tc.args, err)
+			continue
+		}
+		if nm != tc.name || !opts.GetBool(tc.flag) {
+			t.Errorf("%q: expected %s with %s, saw %s", tc.args, tc.name, tc.flag, nm)
+		}
+	}
+
+	// the options of one program aren't valid for another
+	if _, _, err := mux.Interpret([]string{"ls", "-f"}, []string{}); err == nil {
+		t.Error("expected an error for -f with ls")
+	}
+
+	_, _, err = mux.Interpret([]string{"/bin/box", "mv"}, []string{})
+	if err == nil || err.Error() != "Unknown program: box (choose from ls or cp)" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	mux.Register("ls", cp)
+	if n := mux.Names(); len(n) != 2 || n[0] != "ls" {
+		t.Errorf("bad names %q", n)
+	}
+	if _, spec, _ := mux.Lookup([]string{"ls"}); spec != cp {
+		t.Error("re-registration didn't replace the spec")
+	}
+}
@@ -0,0 +1,137 @@
+// netaddr.go - Network address value types
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+//go:build !tinygo
+
+package options
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+)
+
+func init() {
+	valueTypes["url"] = func(s string) error {
+		_, err := parseURL(s)
+		return err
+	}
+	valueTypes["hostport"] = func(s string) error {
+		_, _, err := parseHostPort(s)
+		return err
+	}
+	typedValues["url"] = func(s string) (any, error) {
+		return parseURL(s)
+	}
+}
+
+// Parse an absolute URL such as "https://example.com/api"
+func parseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(u.Scheme) == 0 || (len(u.Host) == 0 && len(u.Opaque) == 0 && len(u.Path) == 0) {
+		return nil, fmt.Errorf("%s is not an absolute URL", s)
+	}
+	return u, nil
+}
+
+// Split "host:port", "[::1]:port" or ":port" into the host and a
+// numeric port
+func parseHostPort(s string) (string, int, error) {
+	host, p, err := net.SplitHostPort(s)
+	if err != nil {
+		return "", 0, err
+	}
+
+	port, err := strconv.ParseUint(p, 10, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("%s has an invalid port %s", s, p)
+	}
+	return host, int(port), nil
+}
+
+// Return the error for the value 'v' of option 'nm' that isn't a valid
+// 'typ'
+func badValue(nm, v, typ string) error {
+	return fmt.Errorf("Invalid option: %s: %s is not a valid %s", nm, v, typ)
+}
+
+// Interpret the option corresponding to the key 'nm' as an IPv4 or
+// IPv6 address (e.g. "10.0.0.1" or "fe80::1%eth0"). An option that
+// isn't set yields the zero Addr; a value that doesn't parse is an
+// error naming the option.
+func (opts *Options) GetIP(nm string) (netip.Addr, error) {
+	v, ok := opts.Get(nm)
+	if !ok || len(v) == 0 {
+		return netip.Addr{}, nil
+	}
+
+	a, err := netip.ParseAddr(v)
+	if err != nil {
+		return netip.Addr{}, badValue(nm, v, "ip")
+	}
+	return a, nil
+}
+
+// Interpret the option corresponding to the key 'nm' as a network in
+// CIDR notation (e.g. "10.0.0.0/8"). An option that isn't set yields
+// the zero Prefix; a value that doesn't parse is an error naming the
+// option.
+func (opts *Options) GetCIDR(nm string) (netip.Prefix, error) {
+	v, ok := opts.Get(nm)
+	if !ok || len(v) == 0 {
+		return netip.Prefix{}, nil
+	}
+
+	p, err := netip.ParsePrefix(v)
+	if err != nil {
+		return netip.Prefix{}, badValue(nm, v, "cidr")
+	}
+	return p, nil
+}
+
+// Interpret the option corresponding to the key 'nm' as an absolute
+// URL (one with a scheme, e.g. "https://example.com/api"). An option
+// that isn't set yields nil; a value that doesn't parse is an error
+// naming the option.
+func (opts *Options) GetURL(nm string) (*url.URL, error) {
+	v, ok := opts.Get(nm)
+	if !ok || len(v) == 0 {
+		return nil, nil
+	}
+
+	u, err := parseURL(v)
+	if err != nil {
+		return nil, badValue(nm, v, "url")
+	}
+	return u, nil
+}
+
+// Interpret the option corresponding to the key 'nm' as "host:port",
+// "[ipv6]:port" or ":port" (any host, as for a listen address) and
+// return the host and the numeric port. An option that isn't set
+// yields "" and 0; a value that doesn't parse is an error naming the
+// option.
+func (opts *Options) GetHostPort(nm string) (string, int, error) {
+	v, ok := opts.Get(nm)
+	if !ok || len(v) == 0 {
+		return "", 0, nil
+	}
+
+	host, port, err := parseHostPort(v)
+	if err != nil {
+		return "", 0, badValue(nm, v, "hostport")
+	}
+	return host, port, nil
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
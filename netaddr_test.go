@@ -0,0 +1,93 @@
+//go:build !tinygo
+
+package options
+
+import (
+	"net/netip"
+	"net/url"
+	"testing"
+)
+
+func TestNetTypes(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    listen=:8080:hostport    -l,--listen=ADDR     Listen address
+    peer=:ip                 -p,--peer=IP         Peer address
+    allow=10.0.0.0/8:cidr    -a,--allow=NET       Allowed network
+    upstream=:url            -u,--upstream=URL    Upstream server
+    gateway=                 -g,--gateway=IP      Gateway
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-p", "fe80::1", "-u", "https://example.com/api",
+		"-l", "[::1]:9000"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ip, err := opts.GetIP("peer")
+	if err != nil || ip != netip.MustParseAddr("fe80::1") {
+		t.Errorf("bad ip %v: %v", ip, err)
+	}
+	p, err := opts.GetCIDR("allow")
+	if err != nil || p != netip.MustParsePrefix("10.0.0.0/8") {
+		t.Errorf("bad cidr %v: %v", p, err)
+	}
+	u, err := opts.GetURL("upstream")
+	if err != nil || u.Host != "example.com" || u.Path != "/api" {
+		t.Errorf("bad url %v: %v", u, err)
+	}
+	host, port, err := opts.GetHostPort("listen")
+	if err != nil || host != "::1" || port != 9000 {
+		t.Errorf("bad hostport %s %d: %v", host, port, err)
+	}
+
+	if x, ok := opts.GetTyped("upstream"); !ok || x.(*url.URL).Scheme != "https" {
+		t.Errorf("bad typed url %v", x)
+	}
+	if x, ok := opts.GetTyped("peer"); !ok || x.(netip.Addr) != ip {
+		t.Errorf("bad typed ip %v", x)
+	}
+
+	bad := [][]string{
+		{"-p", "10.0.0.300"},
+		{"-a", "10.0.0.0/33"},
+		{"-u", "example.com"},
+		{"-l", "localhost"},
+		{"-l", "localhost:http"},
+		{"-l", ":70000"},
+	}
+	for _, args := range bad {
+		_, err := spec.Interpret(append([]string{"tool"}, args...), []string{})
+		if err == nil {
+			t.Errorf("%q: expected error", args)
+		}
+	}
+
+	_, err = spec.Interpret([]string{"tool", "--peer=1.2.3"}, []string{})
+	if err == nil || err.Error() != "Invalid option: --peer=1.2.3: 1.2.3 is not a valid ip" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// the getters name the option when the value wasn't validated
+	opts, err = spec.Interpret([]string{"tool", "-g", "nope"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opts.GetIP("gateway"); err == nil || err.Error() != "Invalid option: gateway: nope is not a valid ip" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if ip, err := opts.GetIP("peer"); err != nil || ip.IsValid() {
+		t.Errorf("unset ip: %v %v", ip, err)
+	}
+	if u, err := opts.GetURL("upstream"); err != nil || u != nil {
+		t.Errorf("unset url: %v %v", u, err)
+	}
+}
@@ -0,0 +1,94 @@
+// normalize.go - Option value normalizers
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// A function that rewrites an option value into its canonical form
+type Normalizer func(value string) string
+
+// Common normalizers
+var (
+	// Remove leading and trailing white space
+	TrimSpace Normalizer = strings.TrimSpace
+
+	// Convert to lower case
+	ToLower Normalizer = strings.ToLower
+
+	// Clean up a path (see filepath.Clean); empty values are kept
+	CleanPath Normalizer = func(v string) string {
+		if len(v) == 0 {
+			return v
+		}
+		return filepath.Clean(v)
+	}
+
+	// Remove a matching pair of single or double quotes around the
+	// value: "bar" and 'bar' become bar
+	StripQuotes Normalizer = func(v string) string {
+		if n := len(v); n >= 2 && (v[0] == '"' || v[0] == '\'') && v[n-1] == v[0] {
+			return v[1 : n-1]
+		}
+		return v
+	}
+)
+
+// Remove a matching pair of quotes around the values of environment
+// variables (see StripQuotes) before they are normalized. Unit files
+// and dotenv files are often written as FOO="bar" and pass the quotes
+// through verbatim. This is off by default.
+func (spec *Spec) SetStripEnvQuotes(strip bool) {
+	spec.strip_quotes = strip
+}
+
+// Register the normalizers 'fns' for option 'nm'. They are applied in
+// order to every value of the option - from the command line, the
+// environment, the defaults or Options.Set() - before the value is
+// validated (see Func()) and stored. Thus every getter sees the
+// clean value regardless of its source. Registering normalizers
+// replaces the ones registered earlier for 'nm'.
+func (spec *Spec) SetNormalizer(nm string, fns ...Normalizer) error {
+	if _, ok := spec.flags[nm]; !ok {
+		return fmt.Errorf("Unknown option: %s", nm)
+	}
+
+	if spec.normalizers == nil {
+		spec.normalizers = make(map[string][]Normalizer)
+	}
+	spec.normalizers[nm] = fns
+	return nil
+}
+
+// Return the normalized form of 'value' for option 'nm'
+func (spec *Spec) normalize(nm, value string) string {
+	for _, fn := range spec.normalizers[nm] {
+		value = fn(value)
+	}
+	return value
+}
+
+// Normalize the defaults of opts; the defaults are copied so that the
+// spec's own map is left alone.
+func (spec *Spec) normalizeDefaults(opts *Options) {
+	if len(spec.normalizers) == 0 {
+		return
+	}
+
+	defs := make(map[string]string, len(opts.defaults))
+	for k, v := range opts.defaults {
+		defs[k] = spec.normalize(k, v)
+	}
+	opts.defaults = defs
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
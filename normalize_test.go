@@ -0,0 +1,116 @@
+package options
+
+import (
+	"testing"
+)
+
+func TestNormalizer(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    mode=Fast  -m,--mode=,TOOL_MODE       Mode
+    dir=       -d,--dir=                  Directory
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = spec.SetNormalizer("mode", TrimSpace, ToLower); err != nil {
+		t.Fatal(err)
+	}
+	if err = spec.SetNormalizer("dir", CleanPath); err != nil {
+		t.Fatal(err)
+	}
+	if err = spec.SetNormalizer("nope", ToLower); err == nil {
+		t.Error("expected an error for an unknown option")
+	}
+
+	var seen string
+	spec.Func("mode", func(v string) error {
+		seen = v
+		return nil
+	})
+
+	opts, err := spec.Interpret([]string{"tool", "--dir", "/a/b/../c/"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("mode"); v != "fast" {
+		t.Errorf("default wasn't normalized: %q", v)
+	}
+	if v, _ := opts.Get("dir"); v != "/a/c" {
+		t.Errorf("argument wasn't normalized: %q", v)
+	}
+
+	opts, err = spec.Interpret([]string{"tool"}, []string{"TOOL_MODE= SLOW "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("mode"); v != "slow" || seen != "slow" {
+		t.Errorf("env var wasn't normalized: %q, %q", v, seen)
+	}
+
+	if err = opts.Set("mode", "QUICK"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("mode"); v != "quick" {
+		t.Errorf("Set() value wasn't normalized: %q", v)
+	}
+
+	if spec.defaults["mode"] != "Fast" {
+		t.Errorf("spec defaults were modified: %q", spec.defaults["mode"])
+	}
+}
+
+func TestStripEnvQuotes(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    name=      -n,--name=,TOOL_NAME       Name
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := []string{`TOOL_NAME="bar"`}
+	opts, err := spec.Interpret([]string{"tool"}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("name"); v != `"bar"` {
+		t.Errorf("quotes stripped without opting in: %q", v)
+	}
+
+	spec.SetStripEnvQuotes(true)
+	tests := map[string]string{
+		`"bar"`: "bar",
+		`'bar'`: "bar",
+		`"bar'`: `"bar'`,
+		`"`:     `"`,
+		`""`:    "",
+		`a"b"`:  `a"b"`,
+	}
+	for in, want := range tests {
+		opts, err := spec.Interpret([]string{"tool"}, []string{"TOOL_NAME=" + in})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := opts.Get("name"); v != want {
+			t.Errorf("%s: expected %q, saw %q", in, want, v)
+		}
+	}
+
+	opts, err = spec.Interpret([]string{"tool", `--name="x"`}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("name"); v != `"x"` {
+		t.Errorf("command line value was changed: %q", v)
+	}
+}
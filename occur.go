@@ -0,0 +1,77 @@
+// occur.go - Order of options on the command line
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+// One appearance of an option on the command line
+type Occurrence struct {
+	// canonical name of the option
+	Name string
+
+	// the value it was given ("true" for flags)
+	Value string
+
+	// index of the option in the args given to Interpret(); -1 for
+	// options that came from the flags env var (see SetFlagsEnv())
+	Index int
+
+	// the argv tokens exactly as given: e.g. ["--root=/x"] or
+	// ["-r", "/x"]
+	Tokens []string
+}
+
+// Return every option given on the command line in the order they
+// appeared, for tools whose semantics depend on the order (e.g. "-i"
+// and "-x" filters applied in sequence). Options from the environment
+// and defaults are not included. The returned slice must not be
+// modified.
+func (opts *Options) Order() []Occurrence {
+	return opts.order
+}
+
+// Return the appearances of option 'nm' on the command line in order,
+// each with its argv index and raw tokens, so that a wrapper can pass a
+// subset of the options on to a child process exactly as they were
+// given. A nil slice implies the option wasn't given on the command
+// line.
+func (opts *Options) Occurrences(nm string) []Occurrence {
+	var rv []Occurrence
+	for _, o := range opts.order {
+		if o.Name == nm {
+			rv = append(rv, o)
+		}
+	}
+	return rv
+}
+
+// Return the argv tokens that supplied the values of option 'nm', in
+// order, exactly as the user typed them (e.g. ["-r", "/x"] or
+// ["--root=/x"]). Wrappers can use this to faithfully forward or log an
+// option.
+func (opts *Options) RawTokens(nm string) []string {
+	var rv []string
+	for _, o := range opts.order {
+		if o.Name == nm {
+			rv = append(rv, o.Tokens...)
+		}
+	}
+	return rv
+}
+
+// Forget the recorded occurrences of option 'nm'
+func (opts *Options) dropOccurrences(nm string) {
+	o := opts.order[:0]
+	for _, x := range opts.order {
+		if x.Name != nm {
+			o = append(o, x)
+		}
+	}
+	opts.order = o
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
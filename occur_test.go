@@ -0,0 +1,155 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestOrder(t *testing.T) {
+	spec, err := Parse(`
+    usage: filter [options] <files>...
+    --
+    include=  -i,--include=               Include pattern
+    exclude=  -x,--exclude=               Exclude pattern
+    verbose   -v,--verbose,VERBOSE        Show more info
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetFlagsEnv("FILTER_FLAGS")
+
+	env := []string{"VERBOSE=1", "FILTER_FLAGS=-x '*.o' -v"}
+	opts, err := spec.Interpret([]string{"filter", "-i", "*.c", "a", "--exclude=*.h", "-i=x.h"}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, o := range opts.Order() {
+		got = append(got, fmt.Sprintf("%s=%s@%d", o.Name, o.Value, o.Index))
+	}
+
+	want := "[verbose=true@-1 include=*.c@1 exclude=*.h@4 include=x.h@5]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("expected %s, saw %s", want, got)
+	}
+}
+
+func TestRawTokens(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=     -r,--root=                  Data root
+    verbose   -v,--verbose                Show more info
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-r", "/x", "-v", "--root=/y"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := fmt.Sprint(opts.RawTokens("root")); r != "[-r /x --root=/y]" {
+		t.Errorf("bad root tokens: %s", r)
+	}
+	if r := fmt.Sprint(opts.RawTokens("verbose")); r != "[-v]" {
+		t.Errorf("bad verbose tokens: %s", r)
+	}
+	if opts.RawTokens("nope") != nil {
+		t.Error("expected no tokens")
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	spec, err := Parse(`
+    usage: wrap [options] host [command...]
+    --
+    port=     -p,--port=                  Port
+    option=   -o,--option=                Option for the child
+    verbose   -v,--verbose                Show more info
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	args := []string{"wrap", "-o", "A=1", "-p", "22", "host", "--option=B=2", "-v"}
+	opts, err := spec.Interpret(args, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rebuild the options meant for the child
+	var child []string
+	for _, o := range opts.Occurrences("option") {
+		if o.Tokens[0] != args[o.Index] {
+			t.Errorf("%s: index %d doesn't match the tokens %v", o.Name, o.Index, o.Tokens)
+		}
+		child = append(child, o.Tokens...)
+	}
+	if s := fmt.Sprint(child); s != "[-o A=1 --option=B=2]" {
+		t.Errorf("bad child options: %s", s)
+	}
+
+	if o := opts.Occurrences("port"); len(o) != 1 || o[0].Value != "22" || o[0].Index != 3 {
+		t.Errorf("bad port occurrences: %+v", o)
+	}
+	if opts.Occurrences("nope") != nil {
+		t.Error("expected no occurrences")
+	}
+}
+
+func TestClusterIndexes(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    verbose   -v,--verbose                Verbose
+    debug     -d,--debug                  Debug
+    root=     -r,--root=                  Root
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	// indexes and tokens refer to the args as given, not as expanded
+	opts, err := spec.Interpret([]string{"tool", "-vd", "-r", "/x"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, o := range opts.Order() {
+		got = append(got, fmt.Sprintf("%s@%d%v", o.Name, o.Index, o.Tokens))
+	}
+	want := "[verbose@1[-vd] debug@1[-vd] root@2[-r /x]]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("expected %s, saw %s", want, got)
+	}
+	if i := opts.SourceIndex("root"); i != 2 {
+		t.Errorf("root: expected index 2, saw %d", i)
+	}
+
+	var e *Error
+	_, err = spec.Interpret([]string{"tool", "-vd", "-r", "/x", "--nope"}, []string{})
+	if !errors.As(err, &e) || e.Index != 4 || e.Token != "--nope" {
+		t.Errorf("expected --nope at 4, saw %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+// optest.go - Helpers to test command line handling
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+// Package optest helps test how a program wires up its command line:
+// it builds the argument and environment fixtures, runs a spec on them
+// the way the program would, captures the usage output and the exit
+// code, and checks the results.
+//
+//	r := optest.Run(spec, optest.Argv("tool", "-r", "/data", "list"),
+//	    optest.Environ(map[string]string{"TOOL_HOME": "/home"}))
+//	r.WantValue(t, "root", "/data")
+//	r.WantCommand(t, "list")
+//
+// No process level state (os.Args, os.Exit, the environment) is
+// touched.
+package optest
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/opencoff/go-options"
+)
+
+// Return the command line of program 'prog' with the arguments 'args'
+func Argv(prog string, args ...string) []string {
+	return append([]string{prog}, args...)
+}
+
+// Return the environment holding the variables in 'vars', sorted by
+// name
+func Environ(vars map[string]string) []string {
+	env := make([]string, 0, len(vars))
+	for k, v := range vars {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// The outcome of running a spec on a command line
+type Result struct {
+	// the parsed options; nil if parsing failed
+	Opts *options.Options
+
+	// the error from Interpret(); Run() prints it to Stderr instead
+	Err error
+
+	// whether the program would have exited and with which code
+	Exited bool
+	Code   int
+
+	// what was written to the standard output and error
+	Stdout string
+	Stderr string
+}
+
+// Interpret 'argv' and 'environ' with 'spec' and return the parsed
+// options or the error
+func Interpret(spec *options.Spec, argv, environ []string) *Result {
+	opts, err := spec.Interpret(argv, environ)
+	return &Result{Opts: opts, Err: err}
+}
+
+// Run 'spec' on 'argv' and 'environ' the way a program does with
+// MustInterpret(): the usage and errors it prints and the code it would
+// exit with are captured in the result. This replaces the output and
+// exit function of 'spec' (see SetOutput() and SetExit()) and resets
+// them to the defaults when done.
+func Run(spec *options.Spec, argv, environ []string) *Result {
+	var stdout, stderr strings.Builder
+	r := &Result{}
+
+	spec.SetOutput(&stdout, &stderr)
+	spec.SetExit(func(code int) {
+		r.Exited = true
+		r.Code = code
+	})
+	defer func() {
+		spec.SetOutput(nil, nil)
+		spec.SetExit(nil)
+	}()
+
+	r.Opts = spec.MustInterpret(argv, environ)
+	r.Stdout = stdout.String()
+	r.Stderr = stderr.String()
+	return r
+}
+
+// Fail 't' unless parsing succeeded
+func (r *Result) parsed(t testing.TB) bool {
+	t.Helper()
+	if r.Opts == nil {
+		t.Errorf("parse failed: err %v, exit %v (code %d)\n%s", r.Err, r.Exited, r.Code, r.Stderr)
+		return false
+	}
+	return true
+}
+
+// Check that option 'nm' has the value 'want'
+func (r *Result) WantValue(t testing.TB, nm, want string) {
+	t.Helper()
+	if !r.parsed(t) {
+		return
+	}
+	if v, ok := r.Opts.Get(nm); !ok || v != want {
+		t.Errorf("option %s: expected %q, saw %q (set %v)", nm, want, v, ok)
+	}
+}
+
+// Check that boolean option 'nm' is 'want'
+func (r *Result) WantBool(t testing.TB, nm string, want bool) {
+	t.Helper()
+	if r.parsed(t) && r.Opts.GetBool(nm) != want {
+		t.Errorf("option %s: expected %v, saw %v", nm, want, !want)
+	}
+}
+
+// Check that option 'nm' is not set
+func (r *Result) WantUnset(t testing.TB, nm string) {
+	t.Helper()
+	if r.parsed(t) && r.Opts.IsSet(nm) {
+		t.Errorf("option %s: expected it to be unset", nm)
+	}
+}
+
+// Check that the non-option arguments (Options.Args) are 'want'
+func (r *Result) WantArgs(t testing.TB, want ...string) {
+	t.Helper()
+	if r.parsed(t) && !slices.Equal(r.Opts.Args, want) {
+		t.Errorf("args: expected %q, saw %q", want, r.Opts.Args)
+	}
+}
+
+// Check that the command is 'want'
+func (r *Result) WantCommand(t testing.TB, want string) {
+	t.Helper()
+	if r.parsed(t) && r.Opts.Command != want {
+		t.Errorf("command: expected %q, saw %q", want, r.Opts.Command)
+	}
+}
+
+// Check that parsing failed with the message 'key' (one of the Msg*
+// constants); an empty 'key' accepts any error.
+func (r *Result) WantError(t testing.TB, key string) {
+	t.Helper()
+	if r.Err == nil {
+		t.Errorf("expected error %s, saw none", key)
+		return
+	}
+
+	var e *options.Error
+	if len(key) > 0 && (!errors.As(r.Err, &e) || e.Key != key) {
+		t.Errorf("expected error %s, saw %v", key, r.Err)
+	}
+}
+
+// Check that the program would have exited with 'code'
+func (r *Result) WantExit(t testing.TB, code int) {
+	t.Helper()
+	if !r.Exited || r.Code != code {
+		t.Errorf("expected exit %d, saw exit %v (code %d)", code, r.Exited, r.Code)
+	}
+}
+
+// Check that the standard output contains 'want'
+func (r *Result) WantStdout(t testing.TB, want string) {
+	t.Helper()
+	if !strings.Contains(r.Stdout, want) {
+		t.Errorf("stdout: expected %q in\n%s", want, r.Stdout)
+	}
+}
+
+// Check that the standard error contains 'want'
+func (r *Result) WantStderr(t testing.TB, want string) {
+	t.Helper()
+	if !strings.Contains(r.Stderr, want) {
+		t.Errorf("stderr: expected %q in\n%s", want, r.Stderr)
+	}
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,66 @@
+package optest
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-options"
+)
+
+func newSpec(t *testing.T) *options.Spec {
+	spec, err := options.Parse(`
+    usage: tool [options] command
+    --
+    root=/var    -r,--root=DIR        Data root
+    verbose      -v,--verbose         Verbose
+    --
+    home=        TOOL_HOME            Home directory
+    --
+    list         ls,list              List entries
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetAutoHelp(true)
+	return spec
+}
+
+func TestFixtures(t *testing.T) {
+	argv := Argv("tool", "-v")
+	if len(argv) != 2 || argv[0] != "tool" || argv[1] != "-v" {
+		t.Errorf("bad argv %q", argv)
+	}
+
+	env := Environ(map[string]string{"B": "2", "A": "1"})
+	if len(env) != 2 || env[0] != "A=1" || env[1] != "B=2" {
+		t.Errorf("bad environ %q", env)
+	}
+}
+
+func TestRun(t *testing.T) {
+	spec := newSpec(t)
+
+	r := Run(spec, Argv("tool", "-v", "ls", "x"), Environ(map[string]string{"TOOL_HOME": "/home"}))
+	r.WantValue(t, "root", "/var")
+	r.WantValue(t, "home", "/home")
+	r.WantBool(t, "verbose", true)
+	r.WantCommand(t, "list")
+	r.WantArgs(t, "list", "x")
+	if r.Exited {
+		t.Errorf("unexpected exit %d", r.Code)
+	}
+
+	r = Run(spec, Argv("tool", "--help"), nil)
+	r.WantExit(t, 0)
+	r.WantStdout(t, "usage: tool [options] command")
+
+	r = Run(spec, Argv("tool", "-x"), nil)
+	r.WantExit(t, 1)
+	r.WantStderr(t, "-x was not recognized")
+
+	r = Interpret(spec, Argv("tool", "-x"), nil)
+	r.WantError(t, options.MsgUnknownOption)
+
+	r = Interpret(spec, Argv("tool", "list"), nil)
+	r.WantUnset(t, "verbose")
+}
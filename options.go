@@ -30,20 +30,223 @@
 //     run         run                      Run some function
 //     --
 //     Additional help for options or defaults etc. go here.
+//
+// An option name prefixed with '!' is required; one prefixed with '+'
+// is shown in the compact summary returned by ShortUsage(); one
+// prefixed with '^' is secret: its values are redacted in the usage,
+// the generated docs and error messages (see Options.Redacted()). A
+// required entry of the environment section must be set in the
+// environment; see Options.GetEnv().
+//
+// An indented line continues the description of the option, env var
+// or command above it; a blank line before it starts a new paragraph,
+// which is kept in the usage, OptionHelp() and the generated docs.
+//
+// A line "## Title" in the options section starts a group of options
+// that is shown under that title in the usage; see OptionGroups(). A
+// line "##" ends the group.
+//
+// Lines of the form "@key value" in the usage section declare metadata
+// such as "@version 1.2.3", "@author", "@homepage" or "@license"; see
+// Meta().
+//
+// A value placeholder can be named in the flags column, e.g.
+// "--out=FILE" or "-j=N", or "--color[=WHEN]" for an optional value;
+// it is used by the synopsis, the generated help and documentation.
+//
+// A flag prefixed with '*' in the flags column is the primary spelling,
+// listed first in the help and used in the synopsis; one prefixed with
+// '~' is accepted but not shown in the help, e.g.
+// "*-v,--verbose,~--verbosity". See Aliases().
+//
+// Single letter options can be grouped: "-vd" is "-v -d", and the last
+// option of a group can take the rest as its value: "-n5" is "-n 5".
+//
+// Every flag with a long spelling can be turned off with "--no-"
+// prepended, e.g. "--no-verbose" for "--verbose"; GetTristate() tells
+// this apart from a flag that wasn't given. An option with a default of
+// type bool and spellings that take no value, as in
+// "color=true:bool  --color", is a flag that is on by default.
+//
+// An option name suffixed with "[=value]" takes an optional value:
+// e.g. with "color[=auto]=never" a bare "--color" sets it to "auto"
+// while "--color=always" overrides that, and the default is "never".
+// The value must be attached with '=' to every spelling. The shorthand
+// "color?=auto" is the same as "color[=auto]" without a default.
+//
+// A description ending in a list of choices, as in "Output format
+// {json,yaml,text}", restricts the option to those values; any other
+// value is an error.
+//
+// An option name suffixed with "(MIN..MAX)", as in "include(0..8)=",
+// must be given at least MIN and at most MAX times; either bound can be
+// left out.
+//
+// An option name suffixed with "@cmd1,cmd2" (e.g. "force@delete") is
+// only valid with those commands and is listed under them in the
+// usage.
+//
+// The default of an option can be followed by ":type" to validate its
+// values, e.g. "zone=UTC:tz" or "zone=:tz"; the value types are:
+//
+//     bool      true/false, yes/no, on/off or 1/0 (see GetBoolErr())
+//     tz        a time zone name (see GetLocation())
+//     loglevel  a log level name or number (see GetLogLevel())
+//     ip        an IPv4 or IPv6 address (see GetIP())
+//     cidr      a network such as "10.0.0.0/8" (see GetCIDR())
+//     url       an absolute URL (see GetURL())
+//     hostport  "host:port", "[::1]:port" or ":port" (see GetHostPort())
+//     path      expands a leading "~" and $VAR or ${VAR}
+//     file      a path that must exist and not be a directory
+//     dir       a path that must be an existing directory
+//     dir!      a directory that is created if it doesn't exist
+//     secret    "file:PATH" is replaced by the contents of PATH
+//
+// The values of the "path", "file", "dir", "dir!" and "secret" types
+// (including the default) are expanded when they are interpreted; the
+// files and directories are checked once all the options are read.
+// Applications add their own types with RegisterType().
+//
+// The name column of an option can end with a constraint that its
+// values must satisfy: a numeric range as in "port=8080{1..65535}"
+// (either end can be left open, "{0..}") or a regular expression as in
+// "name={re:^[a-z][a-z0-9-]*$}". The constraint is shown in the
+// formatted usage and a value that doesn't satisfy it is an error
+// quoting it.
+//
+// A line of the form "[profile NAME] opt=value ..." in the options
+// section declares a named set of defaults; see SetProfileOption().
+//
+// Lines of the form "[requires tls] cert key" and "[conflicts quiet]
+// verbose" in the options section declare that an option needs or
+// excludes the options that follow when it is given. The condition of
+// a requirement can name a value, as in "[requires format=json]
+// schema", and a line of the form "[oneof input] file stdin url"
+// declares a group of options of which exactly one must be given. The
+// rules are checked for unknown options, cycles and contradictions
+// when the spec is parsed.
+//
+// A line of the form "[deprecated --old,OLD_ENV] use --new instead" in
+// the options section marks options (by name) or some of their
+// spellings as deprecated; their use is reported by Options.Warnings().
+// A version after the names, as in "[deprecated --old 2.0] ...", is the
+// version that removes them: once the version of the program (see
+// SetVersion()) reaches it, their use is an error instead.
+//
+// A line of the form "[migrate format] plaintext=text" in the options
+// section renames values of an option: the old value is taken as the
+// new one and its use is reported by Options.Warnings(); see
+// MapValue().
+//
+// A line "*default=CMD" in the commands section makes CMD the command
+// when the command line has none; a line "*required" makes a missing
+// command an error. See SetDefaultCommand() and SetCommandRequired().
+//
+// A line of the form "[epilog CMD]" in the appendix starts free-form
+// text (examples, caveats) that is shown only in the help of command
+// CMD (see CommandUsage()); it extends to the next such line or the end
+// of the appendix.
+//
+// A line of the form "[preset --fast,-F] --jobs=8 --cache=on" in the
+// options section declares the flags --fast and -F as shorthands for
+// the options that follow; they are expanded on the command line
+// before the options are parsed. Occurrence indexes and tokens (see
+// Order()) refer to the command line as given, i.e. to the preset.
+//
+// The lines in brackets are directives and aren't shown in the usage;
+// only the presets are listed, described by their expansion.
+//
+// Building with the "tinygo" tag (which TinyGo sets) leaves out the
+// parts that need encoding/json, log/slog, text/template and net -
+// Options.SaveConfig(), Options.Dump(), Spec.InterpretWithConfig(),
+// Spec.MarshalJSON(), Spec.LoadMessages(), Spec.SetLogger(),
+// Spec.SetHelpTemplate(), the "loglevel", "url" and "hostport" value
+// types and their getters - so that the parser builds for firmware
+// tools with minimal dependencies.
 package options
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Representation of a parsed option specification.
 type Spec struct {
 	usage string
 
+	// program name and the free-form text of the usage and appendix
+	// sections
+	prog     string
+	about    []string
+	appendix []string
+
+	// metadata from the "@key value" lines in the usage section
+	meta []metaField
+
 	allow_unknown_args bool
+	opts_after_cmd     bool
+	cmd_prefix         bool
+	opt_prefix         bool
+	posix_args         bool
+	arg_files          bool
+	cmd_required       bool
+	strict_bool        bool
+	pass_unknown       bool
+	win_syntax         bool
+	fold_case          bool
+
+	// the command used when the command line has none
+	default_cmd string
+
+	// env var holding extra command line options
+	flags_env string
+
+	// remove quotes around env var values
+	strip_quotes bool
+
+	// match env var names without regard to case
+	env_fold bool
+
+	// handle -h, --help and --version; see SetAutoHelp()
+	auto_help bool
+
+	// reproduce the v1 semantics; see SetLegacy()
+	legacy bool
+
+	// complete the version from the build info; see SetBuildInfo()
+	build_info bool
+
+	// terminal detection overridden by SetTerminal()
+	tty_set bool
+	tty_in  bool
+	tty_out bool
+
+	// export the env-bound options to the process environment
+	setenv bool
+
+	// a default value satisfies a required option
+	default_required bool
+
+	// locale for numeric values
+	num_locale string
+
+	// the titles of the option groups in declaration order
+	groups []string
+
+	// options, env vars and commands in declaration order
+	optlist []*optspec
+	cmdlist []*cmdspec
+	optinfo map[string]*optspec
 
 	options     map[string]string
 	defaults    map[string]string
@@ -51,6 +254,165 @@ type Spec struct {
 	required    map[string]bool
 	environment map[string]string
 	commands    map[string]string
+
+	// dynamic completion callbacks
+	completers map[string]Completer
+
+	// command line splitter for InterpretString()
+	splitter Splitter
+
+	// message templates that override DefaultMessages
+	messages Messages
+
+	// callbacks invoked as options are parsed
+	funcs map[string]func(context.Context, string) error
+
+	// observers of the options; see OnSet()
+	observers map[string][]func(string)
+
+	// value types added with RegisterType()
+	types map[string]func(string) (any, error)
+
+	// separators of the list valued options; see SetSeparator()
+	separators map[string]string
+
+	// value normalizers applied before the values are stored
+	normalizers map[string][]Normalizer
+
+	// positional arguments declared with SetArgs()
+	positional []argspec
+
+	// specs for the arguments of commands
+	subspecs map[string]*Spec
+
+	// named default profiles and the option that selects one
+	profiles    map[string]map[string]string
+	profile_opt string
+
+	// preset flags and the options they expand to
+	presets map[string][]string
+
+	// the spellings of each preset in declaration order
+	presetlist [][]string
+
+	// user defined command aliases
+	aliases map[string][]string
+
+	// the "[requires ..]", "[conflicts ..]" and "[oneof ..]" lines by
+	// kind and option (or group), and the resolved dependencies
+	rules      map[string]map[string][]string
+	requires   map[string][]string
+	requiresIf []condRule
+	conflicts  map[string]map[string]bool
+	oneof      []oneofGroup
+
+	// resource limits for untrusted input
+	limits Limits
+
+	// output of the Print* and Must* functions
+	stdout io.Writer
+	stderr io.Writer
+
+	// render the usage with FormatUsage() at this width (0 uses
+	// $COLUMNS); see SetFormatUsage()
+	format_usage bool
+	usage_width  int
+
+	// show the generated synopsis in the usage; see SetAutoSynopsis()
+	auto_synopsis bool
+
+	// when to color the usage; see SetColor()
+	color ColorMode
+
+	// the template that renders the usage; see SetHelpTemplate()
+	help_tmpl helpTemplate
+
+	// called instead of exiting; see SetExit()
+	exit_fn func(code int)
+
+	// exit codes and error output of the Must* functions
+	exit_policy ExitPolicy
+
+	// debug log of the parse
+	logger debugLogger
+
+	// metrics callbacks
+	metrics Metrics
+
+	// deprecated options and spellings with their messages
+	deprecated map[string]deprecation
+
+	// the flags removed since an older spec and the version that
+	// removed them, see SetRemoved()
+	removed map[string]string
+
+	// renamed values by option: old value to new value
+	migrations map[string]map[string]string
+
+	// command handlers for Run(); "" is the handler without a command
+	handlers map[string]func(*Options) error
+
+	// defaults computed by Interpret(); see DefaultFunc()
+	default_funcs map[string]func() string
+}
+
+// An option or environment variable as declared in the spec
+type optspec struct {
+	name   string   // canonical name
+	flags  []string // command line spellings (-x, --xx)
+	env    []string // environment variable bindings
+	help   string   // description
+	isenv  bool     // declared in the environment section
+	brief  bool     // shown in the short usage
+	secret bool     // values are redacted, see Redacted()
+	cmds   []string // commands the option is restricted to
+
+	// name of the value placeholder (e.g. FILE in "--out=FILE")
+	metavar string
+
+	// value type (e.g. "tz" in "zone=:tz"); see also RegisterType()
+	vtype string
+
+	// the values allowed by SetEnum()
+	choices []string
+
+	// value of the bare option if the value is optional (e.g. "auto"
+	// in "color[=auto]")
+	implicit string
+
+	// the lines echoed in the usage string for this entry
+	usage []string
+
+	// declared without a description (rather than "-")
+	nodesc bool
+
+	// the number of times it must and can (0 is unlimited) be given;
+	// e.g. "include(1..8)="
+	minCount int
+	maxCount int
+
+	// the title of the group it is declared in, if any
+	group string
+
+	// the range or pattern the values must satisfy, e.g.
+	// "port=8080{1..65535}"
+	constraint *constraint
+
+	// the spelling marked as primary, if any, and those accepted but
+	// not shown in the help; see Aliases()
+	primary   string
+	secondary []string
+}
+
+// A command as declared in the spec
+type cmdspec struct {
+	name    string
+	aliases []string
+	help    string
+	usage   []string
+
+	// free-form text shown only in the command's help
+	epilog []string
 }
 
 // Representation of parsed command line arguments according to a
@@ -58,17 +420,72 @@ type Spec struct {
 type Options struct {
 	options map[string]string
 
-	// If the options are repeated on the command line, second and subsequent
-	// values are in optionv
+	// All the values of each option in the order they were seen; the
+	// first one is also in options.
 	optionv map[string][]string
 
 	defaults map[string]string
 	Command  string
 	Args     []string
+
+	// The options of Command parsed with its sub-spec (if any)
+	Sub *Options
+
+	// The options that weren't recognized, verbatim and in order; see
+	// SetPassUnknown()
+	Unknown []string
+
+	// The commands from Command down through the specs attached to
+	// commands with SetCommandSpec(), e.g. [remote add] for "git
+	// remote add origin URL". The commands section of a spec declares
+	// only one level of commands.
+	CommandPath []string
+
+	// When "--" separators are used, the arguments before the first
+	// separator and those between each separator and the next, e.g.
+	// "tool -v a -- b c -- d" yields [[a] [b c] [d]]. Args holds all
+	// of them (including the second and subsequent separators).
+	ArgGroups [][]string
+
+	// the positional arguments by name; see SetArgs()
+	named map[string][]string
+
+	// locale specific number format or nil
+	numfmt *numberFormat
+
+	// the options in the order they appeared in argv
+	order []Occurrence
+
+	// where the value of each option came from; see Source()
+	sources map[string]Source
+
+	// the spec that produced these options
+	spec *Spec
+
+	// warnings about the input, e.g. deprecated options
+	warnings []error
+
+	// memoized results of the typed getters
+	mu   sync.RWMutex
+	memo map[memoKey]memoEntry
 }
 
 // Parse a spec string and return a Spec object
 func Parse(desc string) (spec *Spec, err error) {
+	// the number and text of the line being parsed; 0 once all are
+	lineno := 0
+	text := ""
+
+	// a malformed spec must be an error rather than a crash
+	defer func() {
+		if r := recover(); r != nil {
+			spec, err = nil, fmt.Errorf("Invalid spec: %v", r)
+		}
+		if err != nil && lineno > 0 {
+			err = &SpecError{Line: lineno, Text: text, Err: err}
+		}
+	}()
+
 	spec = new(Spec)
 	spec.options = make(map[string]string, 0)
 	spec.defaults = make(map[string]string, 0)
@@ -77,27 +494,73 @@ func Parse(desc string) (spec *Spec, err error) {
 	spec.commands = make(map[string]string, 0)
 	spec.environment = make(map[string]string, 0)
 	spec.allow_unknown_args = false
+	spec.profile_opt = "profile"
+	spec.env_fold = runtime.GOOS == "windows"
 
 	g_indent := -1
 	indent := -1
+
+	// the column of the option descriptions in the usage
+	helpcol := -1
 	section := 0
-	lines := []string{}
+	lines := make([]string, 0, strings.Count(desc, "\n")+1)
+
+	// the command whose epilog the appendix lines belong to
+	var epilog *cmdspec
+
+	// a blank line was seen in the options, env or commands section
+	para := false
+
+	// the title of the current option group
+	group := ""
+
+	// options scoped to the last command are listed after its
+	// description
+	pending := ""
+	flush := func() {
+		if len(pending) > 0 {
+			lines = append(lines, spec.scopedUsage(pending)...)
+			pending = ""
+		}
+	}
 
-	for _, line := range strings.Split(desc, "\n") {
+	for line := range strings.SplitSeq(desc, "\n") {
+		lineno++
+		text = strings.TrimSpace(line)
 		if g_indent == -1 {
 			clean_line := strings.TrimLeft(line, " \t")
 			if clean_line != "" {
 				g_indent = len(line) - len(clean_line)
+				line = clean_line
 			}
 		} else {
-			line = line[g_indent:]
+			line = unindent(line, g_indent)
 		}
 
 		line := strings.TrimRight(line, " \t")
 
 		if line == "" {
-			if section != 1 && section != 2 && section != 3 {
+			if section == 4 && epilog != nil {
+				epilog.epilog = append(epilog.epilog, line)
+			} else if section != 1 && section != 2 && section != 3 {
 				lines = append(lines, line)
+			} else {
+				para = true
+			}
+			continue
+		}
+
+		// "## Title" starts a group of options and "##" ends it
+		if section == 1 && (line == "##" || strings.HasPrefix(line, "## ")) {
+			group = strings.TrimSpace(line[2:])
+			if len(lines) > 0 && lines[len(lines)-1] != "" {
+				lines = append(lines, "")
+			}
+			if len(group) > 0 {
+				if !slices.Contains(spec.groups, group) {
+					spec.groups = append(spec.groups, group)
+				}
+				lines = append(lines, group+":")
 			}
 			continue
 		}
@@ -111,13 +574,35 @@ func Parse(desc string) (spec *Spec, err error) {
 				if line == "#" {
 					lines = append(lines, "")
 				} else {
-					line = line[indent:]
+					line = unindent(line[1:], indent-1)
 					lines = append(lines, line)
 				}
 				continue
 			}
 		}
 
+		// Lines that start with whitespace continue the description
+		// of the preceding option, env var or command.
+		if section == 1 || section == 2 || section == 3 {
+			if line[0] == ' ' || line[0] == '\t' {
+				text := strings.TrimLeft(line, " \t")
+				if n := len(line) - len(text); indent > 0 && n > indent {
+					text = line[indent:]
+				}
+				// a blank line before it starts a new paragraph
+				if para && !spec.continueHelp(section, "") {
+					lines = append(lines, "")
+				}
+				para = false
+
+				if !spec.continueHelp(section, "  "+text) {
+					lines = append(lines, "  "+text)
+				}
+				continue
+			}
+		}
+		para = false
+
 		switch section {
 
 		case 0: // usage
@@ -129,7 +614,16 @@ func Parse(desc string) (spec *Spec, err error) {
 				continue
 			}
 
+			// "@key value" declares metadata
+			if line[0] == '@' {
+				if err = spec.addMeta(line[1:]); err != nil {
+					return
+				}
+				continue
+			}
+
 			lines = append(lines, line)
+			spec.about = append(spec.about, line)
 
 		case 1: // options
 			if line == "--" {
@@ -140,59 +634,239 @@ func Parse(desc string) (spec *Spec, err error) {
 				continue
 			}
 
-			parts := strings.SplitN(line, " ", 2)
-			if len(parts) == 1 {
+			if strings.HasPrefix(line, "[profile ") {
+				if err = spec.parseProfile(line); err != nil {
+					return
+				}
+				continue
+			}
+
+			if strings.HasPrefix(line, "[requires ") || strings.HasPrefix(line, "[conflicts ") || strings.HasPrefix(line, "[oneof ") {
+				if err = spec.parseRule(line); err != nil {
+					return
+				}
+				continue
+			}
+
+			if strings.HasPrefix(line, "[deprecated ") {
+				if err = spec.parseDeprecated(line); err != nil {
+					return
+				}
+				continue
+			}
+
+			if strings.HasPrefix(line, "[migrate ") {
+				if err = spec.parseMigration(line); err != nil {
+					return
+				}
+				continue
+			}
+
+			if strings.HasPrefix(line, "[preset ") {
+				if err = spec.parsePreset(line); err != nil {
+					return
+				}
+				lines = append(lines, spec.presetUsage(len(spec.presetlist)-1, helpcol))
+				continue
+			}
+
+			option, rest, ok := cutBlank(line)
+			if !ok {
 				err = fmt.Errorf("Invalid option spec: %s", line)
 				return
 			}
 			if indent == -1 {
-				indent = len(line) - len(strings.TrimLeft(parts[1], " \t"))
+				indent = displayWidth(line) - displayWidth(strings.TrimLeft(rest, " \t"))
 			}
-			option := parts[0]
-			line = strings.Trim(parts[1], " \t")
+			line = strings.Trim(rest, " \t")
 
 			required := false
+			brief := false
+			secret := false
 			flag := true
 
-			if strings.HasPrefix(option, "!") {
+			for len(option) > 0 && strings.IndexByte("!+^", option[0]) >= 0 {
+				switch option[0] {
+				case '!':
+					required = true
+				case '+':
+					brief = true
+				case '^':
+					secret = true
+				}
 				option = option[1:]
-				required = true
 			}
 
-			if strings.Contains(option, "=") {
-				ks := strings.Split(option, "=")
-				option = ks[0]
-				if defval := ks[1]; len(defval) > 0 {
+			// "port=8080{1..65535}" constrains the values
+			option, cons, cerr := splitConstraint(option)
+			if cerr != nil {
+				err = cerr
+				return
+			}
+
+			// "name(MIN..MAX)" limits the number of occurrences
+			option, least, most, aerr := parseArity(option)
+			if aerr != nil {
+				err = aerr
+				return
+			}
+
+			// "name?=value" is short for "name[=value]"
+			if i := strings.Index(option, "?="); i > 0 {
+				val, def, _ := strings.Cut(option[i+2:], "=")
+				option = option[:i] + "[=" + val + "]=" + def
+			}
+
+			// "name[=value]" makes the value optional
+			implicit := ""
+			if i := strings.Index(option, "[="); i > 0 {
+				j := strings.IndexByte(option[i:], ']')
+				if j <= 2 {
+					err = fmt.Errorf("Invalid option spec: %s has no implicit value", option)
+					return
+				}
+				implicit = option[i+2 : i+j]
+				option = option[:i] + option[i+j+1:]
+				flag = false
+			}
+
+			// "name@cmd1,cmd2" scopes the option to those commands
+			var scope []string
+			if i := strings.IndexByte(option, '@'); i > 0 {
+				j := strings.IndexByte(option, '=')
+				if j < 0 {
+					j = len(option)
+				}
+				if i < j {
+					scope = strings.Split(option[i+1:j], ",")
+					option = option[:i] + option[j:]
+				}
+			}
+
+			vtype := ""
+			if nm, def, ok := strings.Cut(option, "="); ok {
+				def, _, _ = strings.Cut(def, "=")
+				option = nm
+				defval, typ := splitType(def)
+				if len(typ) > 0 && len(defval) > 0 && valueTypes[typ](defval) != nil {
+					err = fmt.Errorf("Invalid option spec: default %s of %s is not a valid %s", defval, option, typ)
+					return
+				}
+				if len(defval) > 0 {
 					spec.defaults[option] = defval
 				}
+				vtype = typ
 				flag = false
+
+				// "name=true:bool" with spellings that take no value is
+				// a flag that is on by default
+				if typ == "bool" && len(implicit) == 0 {
+					spellings, _, _ := cutBlank(line)
+					if !strings.Contains(spellings, "=") {
+						flag = true
+						vtype = ""
+					}
+				}
 			}
 
+			if len(option) == 0 {
+				err = fmt.Errorf("Invalid option spec: missing option name")
+				return
+			}
+			if option[0] == '[' {
+				err = fmt.Errorf("Invalid option spec: %s is not a valid name", option)
+				return
+			}
 			spec.flags[option] = flag
 			spec.required[option] = required
 
-			parts = strings.SplitN(line, " ", 2)
-			if len(parts) == 1 {
-				parts = append(parts, "-")
+			spellings, help, ok := cutBlank(line)
+			nodesc := !ok
+			if nodesc {
+				help = "-"
+			}
+			help = strings.Trim(help, " \t")
+
+			o := &optspec{name: option, help: descHelp(help), brief: brief, secret: secret, cmds: scope, vtype: vtype, implicit: implicit, nodesc: nodesc, group: group}
+			o.minCount, o.maxCount = least, most
+			o.constraint = cons
+			spec.addOpt(o)
+
+			if cons != nil && flag {
+				err = fmt.Errorf("Invalid option spec: %s is a flag and can't have a constraint", option)
+				return
+			}
+			if err = spec.checkDefaultConstraint(o); err != nil {
+				return
 			}
-			parts[1] = strings.Trim(parts[1], " \t")
 
-			if parts[1] != "-" {
-				lines = append(lines, "  "+line)
+			// "{a,b,c}" ending the description restricts the value
+			if !flag {
+				o.choices = parseChoices(help)
+				if err = spec.checkDefaultChoice(o); err != nil {
+					return
+				}
 			}
 
-			parts = strings.Split(parts[0], ",")
+			if help != "-" {
+				// scoped options are listed under their commands
+				u := "  " + respell(line, spellings)
+				if helpcol == -1 && len(help) > 0 {
+					helpcol = displayWidth(u) - displayWidth(help)
+				}
+				if len(scope) == 0 {
+					lines = append(lines, u)
+				}
+				o.usage = append(o.usage, u)
+			}
 
-			for _, part := range parts {
-				pieces := strings.SplitN(part, "=", 2)
-				part = pieces[0]
+			o.flags = make([]string, 0, strings.Count(spellings, ",")+1)
+			for part := range strings.SplitSeq(spellings, ",") {
+				mark, part := splitMark(part)
+				part, mv, _ := strings.Cut(part, "=")
+
+				// "--color[=WHEN]" names the placeholder of an optional
+				// value
+				if p, ok := strings.CutSuffix(part, "["); ok && strings.HasSuffix(mv, "]") {
+					if len(o.implicit) == 0 {
+						err = fmt.Errorf("Invalid option spec: %s: the value of %s isn't optional", option, p)
+						return
+					}
+					part, mv = p, strings.TrimSuffix(mv, "]")
+				}
 
-				if strings.HasPrefix(part, "--") || strings.HasPrefix(part, "-") {
+				if strings.HasPrefix(part, "-") {
 					spec.options[part] = option
+					switch mark {
+					case '*':
+						if len(o.primary) > 0 {
+							err = fmt.Errorf("Invalid option spec: %s has more than one primary spelling", option)
+							return
+						}
+						o.primary = part
+						o.flags = slices.Insert(o.flags, 0, part)
+					case '~':
+						o.secondary = append(o.secondary, part)
+						o.flags = append(o.flags, part)
+					default:
+						o.flags = append(o.flags, part)
+					}
+
+					// "--out=FILE" names the value placeholder
+					if len(mv) > 0 && len(o.metavar) == 0 {
+						o.metavar = mv
+					}
 					continue
 				}
 
 				spec.environment[part] = option
+				if len(part) > 0 {
+					o.env = append(o.env, part)
+				}
+			}
+			if len(o.flags) > 0 && len(o.secondary) == len(o.flags) {
+				err = fmt.Errorf("Invalid option spec: %s has no spelling shown in the help", option)
+				return
 			}
 
 		case 2: // environment variables
@@ -204,16 +878,15 @@ func Parse(desc string) (spec *Spec, err error) {
 				continue
 			}
 
-			parts := strings.SplitN(line, " ", 2)
-			if len(parts) == 1 {
+			env, rest, ok := cutBlank(line)
+			if !ok {
 				err = fmt.Errorf("Invalid env spec: %s", line)
 				return
 			}
 			if indent == -1 {
-				indent = len(line) - len(strings.TrimLeft(parts[1], " \t"))
+				indent = displayWidth(line) - displayWidth(strings.TrimLeft(rest, " \t"))
 			}
-			env := parts[0]
-			line = strings.Trim(parts[1], " \t")
+			line = strings.Trim(rest, " \t")
 
 			required := false
 			flag := true
@@ -228,27 +901,39 @@ func Parse(desc string) (spec *Spec, err error) {
 				flag = false
 			}
 
+			if len(env) == 0 {
+				err = fmt.Errorf("Invalid env spec: missing variable name")
+				return
+			}
 			spec.flags[env] = flag
 			spec.required[env] = required
 
-			parts = strings.SplitN(line, " ", 2)
-			if len(parts) == 1 {
-				parts = append(parts, "-")
+			vars, help, ok := cutBlank(line)
+			nodesc := !ok
+			if nodesc {
+				help = "-"
 			}
-			parts[1] = strings.Trim(parts[1], " \t")
+			help = strings.Trim(help, " \t")
 
-			if parts[1] != "-" {
-				lines = append(lines, "  "+line)
-			}
+			o := &optspec{name: env, help: descHelp(help), isenv: true, nodesc: nodesc}
+			spec.addOpt(o)
 
-			parts = strings.Split(parts[0], ",")
+			if help != "-" {
+				u := "  " + line
+				lines = append(lines, u)
+				o.usage = append(o.usage, u)
+			}
 
-			for _, part := range parts {
-				part = strings.SplitN(part, "=", 2)[0]
+			for part := range strings.SplitSeq(vars, ",") {
+				part, _, _ = strings.Cut(part, "=")
 				spec.environment[part] = env
+				if len(part) > 0 {
+					o.env = append(o.env, part)
+				}
 			}
 
 		case 3: // commands
+			flush()
 			if line == "--" {
 				if len(lines) > 0 && lines[len(lines)-1] != "" {
 					lines = append(lines, "")
@@ -261,31 +946,40 @@ func Parse(desc string) (spec *Spec, err error) {
 				spec.allow_unknown_args = true
 				continue
 			}
+			if spec.parseCommandRule(line) {
+				continue
+			}
 
-			parts := strings.SplitN(line, " ", 2)
-			if len(parts) == 1 {
+			command, rest, ok := cutBlank(line)
+			if !ok {
 				err = fmt.Errorf("Invalid command spec: %s", line)
 				return
 			}
 			if indent == -1 {
-				indent = len(line) - len(strings.TrimLeft(parts[1], " \t"))
+				indent = displayWidth(line) - displayWidth(strings.TrimLeft(rest, " \t"))
 			}
-			command := parts[0]
-			line = strings.Trim(parts[1], " \t")
+			line = strings.Trim(rest, " \t")
 
-			parts = strings.SplitN(line, " ", 2)
-			if len(parts) == 1 {
-				parts = append(parts, "-")
+			aliases, help, ok := cutBlank(line)
+			if !ok {
+				help = "-"
 			}
-			parts[1] = strings.Trim(parts[1], " \t")
+			help = strings.Trim(help, " \t")
 
-			if parts[1] != "-" {
-				lines = append(lines, "  "+line)
+			c := &cmdspec{name: command, help: descHelp(help)}
+			spec.cmdlist = append(spec.cmdlist, c)
+
+			if help != "-" {
+				u := "  " + line
+				lines = append(lines, u)
+				c.usage = append(c.usage, u)
+				pending = command
 			}
 
-			parts = strings.Split(parts[0], ",")
-			for _, part := range parts {
+			c.aliases = make([]string, 0, strings.Count(aliases, ",")+1)
+			for part := range strings.SplitSeq(aliases, ",") {
 				spec.commands[part] = command
+				c.aliases = append(c.aliases, part)
 			}
 
 		case 4: // appendix
@@ -297,168 +991,1080 @@ func Parse(desc string) (spec *Spec, err error) {
 				continue
 			}
 
+			// "[epilog cmd]" starts the text for command cmd
+			if strings.HasPrefix(line, "[epilog ") && strings.HasSuffix(line, "]") {
+				nm := strings.TrimSpace(line[len("[epilog ") : len(line)-1])
+				if epilog = spec.findCommand(nm); epilog == nil {
+					err = fmt.Errorf("Invalid epilog: %s is not a known command", nm)
+					return
+				}
+				continue
+			}
+
+			if epilog != nil {
+				epilog.epilog = append(epilog.epilog, line)
+				continue
+			}
+
 			lines = append(lines, line)
+			spec.appendix = append(spec.appendix, line)
 
 		}
 	}
 
+	lineno = 0
+	flush()
+	lines = append(lines, spec.metaFooter()...)
 	spec.usage = strings.Join(lines, "\n") + "\n"
 	spec.usage = strings.Trim(spec.usage, " \t\n")
+	spec.prog = progName(spec.about)
+	if err = spec.checkProfiles(); err != nil {
+		return
+	}
+	if err = spec.checkPresets(); err != nil {
+		return
+	}
+	if err = spec.checkRules(); err != nil {
+		return
+	}
+	if err = spec.checkDefaultCommand(); err != nil {
+		return
+	}
+	if err = spec.checkDeprecated(); err != nil {
+		return
+	}
+	if err = spec.checkMigrations(); err != nil {
+		return
+	}
+	err = spec.checkScopes()
 	//fmt.Printf("Parsed data:\n%+v\n", spec)
 	return
 }
 
-// Parse a spec string and die if it fails
-func MustParse(desc string) *Spec {
-	var p *Spec
-	var err error
-
-	if p, err = Parse(desc); err != nil {
-		fmt.Fprintf(os.Stderr, "Spec parse error for\n'%.80s' ..\n%s\n", desc, err)
-		os.Exit(1)
+// Remove up to 'n' leading blanks from 'line'. Lines that are indented
+// less than the rest of the spec are not cut short.
+func unindent(line string, n int) string {
+	i := 0
+	for i < n && i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+		i++
 	}
-
-	return p
+	return line[i:]
 }
 
-// Parse the command line arguments in 'args' and the environment
-// variables in 'environ'. This expects the parsing to succeed and
-// exits with usage string and error if the parsing fails.
-func (this *Spec) MustInterpret(args []string, environ []string) *Options {
-	opts, err := this.Interpret(args, environ)
-	if err != nil {
-		this.PrintUsageWithError(err)
+// Split 's' at its first blank (space or tab)
+func cutBlank(s string) (string, string, bool) {
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		return s[:i], s[i+1:], true
 	}
-
-	return opts
+	return s, "", false
 }
 
-// Parse the command line arguments in 'args' and the environment
-// variables in 'environ'. Return the resulting, parsed options in
-// 'o' and any error in 'err'.
-func (spec *Spec) Interpret(args []string, environ []string) (o *Options, err error) {
-	opts := new(Options)
-	opts.options = make(map[string]string, 0)
-	opts.optionv = make(map[string][]string, 0)
-	opts.defaults = spec.defaults
-	opts.Args = []string{}
-
-	for _, env := range environ {
-		parts := strings.SplitN(env, "=", 2)
-		if option, present := spec.environment[parts[0]]; present {
-			opts.options[option] = parts[1]
+// Append the continuation line 'line' to the description of the most
+// recent entry in 'section'; an empty line starts a new paragraph of
+// the description. Return true if the entry is a scoped
+// option whose usage lines are shown under its commands.
+func (spec *Spec) continueHelp(section int, line string) bool {
+	var help *string
+	var scoped bool
+
+	switch section {
+	case 1, 2:
+		if n := len(spec.optlist); n > 0 {
+			o := spec.optlist[n-1]
+			o.usage = append(o.usage, line)
+			help = &o.help
+			scoped = len(o.cmds) > 0
+		}
+	case 3:
+		if n := len(spec.cmdlist); n > 0 {
+			c := spec.cmdlist[n-1]
+			c.usage = append(c.usage, line)
+			help = &c.help
 		}
 	}
 
-	//fmt.Printf("Options: %+v\n", spec.options)
-
-	for i := 1; i < len(args); i++ {
-		arg := args[i]
+	if help == nil {
+		return false
+	}
 
-		// A lone "--" terminates option parsing
-		if arg == "--" {
-			if i+1 < len(args) {
-				opts.Args = append(opts.Args, args[i+1:]...)
-			}
-			break
+	// an empty line separates paragraphs
+	switch {
+	case len(line) == 0:
+		if len(*help) > 0 {
+			*help += "\n\n"
 		}
+		return scoped
+	case len(*help) > 0 && !strings.HasSuffix(*help, "\n"):
+		*help += " "
+	}
+	*help += strings.TrimLeft(line, " \t")
+	return scoped
+}
+
+// Record the option or env var declaration 'o'
+func (spec *Spec) addOpt(o *optspec) {
+	if spec.optinfo == nil {
+		spec.optinfo = make(map[string]*optspec)
+	}
+	spec.optlist = append(spec.optlist, o)
+	spec.optinfo[o.name] = o
+}
+
+// Return the usage lines of the options scoped to command 'cmd'
+func (spec *Spec) scopedUsage(cmd string) []string {
+	var rv []string
+	for _, o := range spec.optlist {
+		for _, c := range o.cmds {
+			if c == cmd {
+				for _, l := range o.usage {
+					rv = append(rv, "  "+l)
+				}
+				break
+			}
+		}
+	}
+	return rv
+}
+
+// Return the description part of a spec line; a lone "-" denotes an
+// undocumented entry.
+func descHelp(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
+
+// Extract the program name from the first line of the usage section
+// ("usage: prog ...").
+func progName(about []string) string {
+	if len(about) == 0 {
+		return ""
+	}
 
-		if strings.HasPrefix(arg, "--") || strings.HasPrefix(arg, "-") {
-			option := "-"
-			value := "true"
-
-			parts := strings.SplitN(arg, "=", 2)
+	words := strings.Fields(about[0])
+	if len(words) > 1 && strings.EqualFold(words[0], "usage:") {
+		return words[1]
+	}
+	return ""
+}
+
+// Parse a spec string and die if it fails
+func MustParse(desc string) *Spec {
+	var p *Spec
+	var err error
+
+	if p, err = Parse(desc); err != nil {
+		fmt.Fprintf(os.Stderr, "Spec parse error for\n'%.80s' ..\n%s\n", desc, err)
+		exit(1)
+	}
+	return p
+}
+
+// Parse the command line arguments in 'args' and the environment
+// variables in 'environ'. This expects the parsing to succeed and
+// exits with usage string and error if the parsing fails (see
+// SetExitPolicy() for the exit codes and the output). The hidden
+// CompleteCmd is answered here on behalf of the completion scripts, as
+// are the built-in help and version options (see SetAutoHelp()). If
+// the exit function set with SetExit() returns, so does this, with nil
+// options.
+func (this *Spec) MustInterpret(args []string, environ []string) *Options {
+	opts, _ := this.mustInterpret(args, environ)
+	return opts
+}
+
+// Do the work of MustInterpret() and return the error that was
+// reported, if any; the options are nil if the program was to exit.
+func (spec *Spec) mustInterpret(args []string, environ []string) (*Options, error) {
+	if len(args) > 1 && args[1] == CompleteCmd {
+		for _, c := range spec.Complete(args[2:]) {
+			fmt.Fprintf(spec.outw(), "%s\n", c)
+		}
+		spec.terminate(0)
+		return nil, nil
+	}
+
+	opts, err := spec.Interpret(args, environ)
+	if err != nil {
+		if spec.handleAutoHelp(err) {
+			return nil, nil
+		}
+		spec.PrintUsageWithError(err)
+		return nil, err
+	}
+	return opts, nil
+}
+
+// Control how a required option ("!name=default") with a default
+// value is treated. Normally a required option must be given on the
+// command line or in the environment and its default only matters to
+// the getters. With 'on' set, a default (from the spec, a profile or
+// InterpretWithDefaults()) counts as having provided the option.
+func (spec *Spec) SetDefaultSatisfiesRequired(on bool) {
+	spec.default_required = on
+}
+
+// Name an environment variable (e.g. "MYTOOL_FLAGS") whose contents
+// are split with SplitPOSIX() and parsed as options ahead of the
+// command line, so that sites can set persistent default flags. Options
+// given on the command line replace the ones from the variable. The
+// variable should only contain options and their values.
+func (spec *Spec) SetFlagsEnv(name string) {
+	spec.flags_env = name
+}
 
-			//fmt.Printf("<< arg %d: %s >>: parts = %v\n", i, arg, parts)
+// Call 'fn' with the option name, env var and value of each option
+// set in 'environ': the first of its variables (in declaration order)
+// that is set. In legacy mode every bound variable is passed in the
+// order of 'environ', so the last one wins.
+func (spec *Spec) eachEnv(environ []string, fn func(name, env, value string) error) error {
+	if spec.legacy {
+		return spec.eachLegacyEnv(environ, fn)
+	}
+
+	for _, o := range spec.optlist {
+		for _, env := range o.env {
+			if value, ok := envFind(environ, env, spec.env_fold); ok {
+				if err := fn(o.name, env, value); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// Split the cluster of short options 'arg' ("-vd", "-n5") into its
+// options ("-v", "-d", "-n=5"): the flags can be grouped and the last
+// option can take the rest of the cluster as its value. Return false if
+// 'arg' isn't such a cluster.
+func (spec *Spec) splitCluster(arg string) ([]string, bool) {
+	if spec.legacy || len(arg) < 3 || arg[0] != '-' || arg[1] == '-' {
+		return nil, false
+	}
+	nm, _, _ := strings.Cut(arg, "=")
+	if _, ok := spec.options[nm]; ok {
+		return nil, false
+	}
+
+	var words []string
+	for j := 1; j < len(arg); j++ {
+		f := "-" + arg[j:j+1]
+		nm, ok := spec.options[f]
+		if !ok {
+			return nil, false
+		}
+		if !spec.flags[nm] && j+1 < len(arg) {
+			return append(words, f+"="+arg[j+1:]), true
+		}
+		words = append(words, f)
+	}
+	return words, true
+}
+
+// Return the option that the command line spelling 'arg' names and
+// whether it is a negated flag ("--no-verbose")
+func (spec *Spec) findOption(arg string) (string, bool, bool) {
+	if nm, ok := spec.options[arg]; ok {
+		return nm, false, true
+	}
+	nm, ok := spec.negatedFlag(arg)
+	return nm, ok, ok
+}
+
+// Return the flag that the long option 'arg' negates ("--no-verbose"
+// for "--verbose"), if any. A "--no-" spelling declared in the spec
+// takes precedence.
+func (spec *Spec) negatedFlag(arg string) (string, bool) {
+	if spec.legacy || !strings.HasPrefix(arg, "--no-") {
+		return "", false
+	}
+
+	nm, ok := spec.options["--"+arg[len("--no-"):]]
+	if !ok || !spec.flags[nm] {
+		return "", false
+	}
+	return nm, true
+}
+
+// Return the value of env var 'name' in 'environ'
+func envLookup(environ []string, name string) (string, bool) {
+	return envFind(environ, name, false)
+}
+
+// Return the value of env var 'name' in 'environ'; names are matched
+// without regard to case if 'fold' is true.
+func envFind(environ []string, name string, fold bool) (string, bool) {
+	if len(name) == 0 {
+		return "", false
+	}
+
+	n := len(name)
+	for _, env := range environ {
+		if len(env) <= n || env[n] != '=' {
+			continue
+		}
+		if env[:n] == name || (fold && strings.EqualFold(env[:n], name)) {
+			return env[n+1:], true
+		}
+	}
+	return "", false
+}
+
+// Match the names of the environment variables bound to options
+// without regard to case, so that "Path" finds the "PATH" binding. This
+// is the default on Windows, where environment variable names are case
+// insensitive.
+func (spec *Spec) SetEnvIgnoreCase(on bool) {
+	spec.env_fold = on
+}
+
+// Control whether options and arguments can be mixed when the spec
+// accepts arguments ("*" in the commands section or SetArgs()). By
+// default they can, like GNU getopt: "tool file.txt --verbose" sets
+// "verbose" and collects "file.txt" in opts.Args. Passing false selects
+// the strict POSIX behavior: the first argument ends the options, and
+// it and everything after it (even "--verbose" or "--") go to
+// opts.Args as is.
+func (spec *Spec) SetInterspersed(on bool) {
+	spec.posix_args = !on
+}
+
+// Continue parsing options after the command is recognized, so that
+// "tool exec -v ls" sets "verbose" just like "tool -v exec ls". Tokens
+// after the command that aren't options of this spec are left in
+// opts.Args for the command; a "--" stops the parsing and is passed on
+// to the command as well.
+func (spec *Spec) SetOptionsAfterCommand(on bool) {
+	spec.opts_after_cmd = on
+}
+
+// Parse the command line arguments in 'args' and the environment
+// variables in 'environ'. Return the resulting, parsed options in
+// 'o' and any error in 'err'.
+//
+// Interpret doesn't modify the spec or the process environment (unless
+// SetSetenv(true) is called), so once the spec is set up it can be used
+// by several goroutines at once, e.g. by a server that runs commands on
+// behalf of its clients. The methods that configure the spec (Set*(),
+// Func(), SetCommandSpec() and so on) must not be called concurrently
+// with it.
+func (spec *Spec) Interpret(args []string, environ []string) (o *Options, err error) {
+	return spec.interpret(context.Background(), nil, args, environ, nil, nil)
+}
+
+// Interpret 'args' and 'environ' like Interpret() but stop with the
+// error of 'ctx' once it is cancelled or its deadline passes. The
+// context is checked between arguments and before each callback, and
+// is passed to the callbacks registered with FuncContext(); this lets
+// slow callbacks (prompts, remote lookups, file reads) honor the
+// cancellation of the server request they are part of.
+func (spec *Spec) InterpretContext(ctx context.Context, args []string, environ []string) (*Options, error) {
+	return spec.interpret(ctx, nil, args, environ, nil, nil)
+}
 
-			if len(parts) == 2 {
-				option = parts[0]
+// Interpret 'args' and 'environ' like Interpret() but with 'defaults'
+// overriding the spec defaults for this call only. This is useful for
+// site specific defaults computed at startup; the spec itself is not
+// modified. Every key in 'defaults' must name an option.
+func (spec *Spec) InterpretWithDefaults(args []string, environ []string, defaults map[string]string) (*Options, error) {
+	return spec.interpret(context.Background(), nil, args, environ, defaults, nil)
+}
+
+// Interpret into 'opts' if it isn't nil, otherwise into a new Options.
+// 'defs' overrides the defaults and 'conf' holds the values from a
+// config file, by option name.
+func (spec *Spec) interpret(ctx context.Context, opts *Options, args []string, environ []string, defs map[string]string, conf map[string][]string) (o *Options, err error) {
+	if fn := spec.metrics.Attempt; fn != nil {
+		fn()
+	}
+
+	// where the input being processed came from; recorded in errors.
+	// No input, however malformed, may crash the program: a panic
+	// (e.g. in a callback) becomes an error.
+	src, index := SourceNone, -1
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(*ExitError); ok {
+				panic(e)
+			}
+			o, err = nil, fmt.Errorf("Invalid input: %v", r)
+		}
+		if err != nil && !errors.Is(err, ErrHelp) && !errors.Is(err, ErrVersion) {
+			locate(err, src, index)
+			spec.countFailure(err)
+		}
+	}()
+
+	if opts == nil {
+		opts = new(Options)
+	}
+	opts.Reset()
+	opts.defaults = spec.defaults
+	opts.Args = []string{}
+	if cap(opts.order) == 0 {
+		opts.order = make([]Occurrence, 0, len(args))
+	}
+	opts.numfmt = spec.numberFormat(environ)
+	opts.spec = spec
+
+	if len(defs) > 0 {
+		opts.defaults = make(map[string]string, len(spec.defaults)+len(defs))
+		for k, v := range spec.defaults {
+			opts.defaults[k] = v
+		}
+		for k, v := range defs {
+			if _, ok := spec.flags[k]; !ok {
+				err = spec.errorf(MsgUnknownDefault, k)
+				return
+			}
+			opts.defaults[k] = v
+		}
+	}
+	if err = spec.computeDefaults(opts, defs); err != nil {
+		return
+	}
+
+	// values from the config file are replaced by the environment and
+	// the command line, just like those from the environment
+	fromenv := make(map[string]bool)
+
+	src = SourceConfig
+	if err = spec.applyConfig(ctx, opts, conf, environ, fromenv); err != nil {
+		return
+	}
+
+	src = SourceEnv
+
+	// an option takes one value from the environment: that of the
+	// first of its variables (in declaration order) that is set. The
+	// command line replaces it. A variable that is set but empty
+	// turns a flag on and clears an option (overriding its default).
+	err = spec.eachEnv(environ, func(name, env, value string) error {
+		if spec.strip_quotes {
+			value = StripQuotes(value)
+		}
+		if len(value) == 0 && spec.flags[name] && !spec.legacy {
+			value = "true"
+		}
+		if err := spec.checkValueSize(name, env, value); err != nil {
+			return err
+		}
+		value, err := spec.expand(name, env, value, environ)
+		if err != nil {
+			return err
+		}
+		value = spec.normalize(name, value)
+		value = spec.migrate(opts, name, env, value)
+		if err := spec.checkType(name, env, value); err != nil {
+			return err
+		}
+		opts.options[name] = value
+		opts.optionv[name] = []string{value}
+		opts.sources[name] = SourceEnv
+		fromenv[name] = !spec.legacy
+		if err := spec.noteDeprecated(opts, name, env); err != nil {
+			return err
+		}
+		spec.debug("option from env", "option", name, "env", env, "value", spec.redact(name, value))
+		return spec.callFunc(ctx, name, env, value)
+	})
+	if err != nil {
+		return
+	}
+
+	// options from the flags env var are parsed ahead of the command
+	// line; options given on the command line replace them.
+	nflags := 0
+	layered := make(map[string]bool)
+	if extra, ok := envFind(environ, spec.flags_env, spec.env_fold); ok && len(args) > 0 {
+		words, e := SplitPOSIX(extra)
+		if e != nil {
+			err = spec.optError(MsgBadFlagsEnv, "", spec.flags_env, spec.flags_env, e)
+			return
+		}
+		args = append(append([]string{args[0]}, words...), args[1:]...)
+		nflags = len(words)
+		spec.debug("options from flags env", "env", spec.flags_env, "args", spec.redactWords(words))
+	}
+
+	src = SourceCommandLine
+	if err = spec.checkArgs(args); err != nil {
+		return
+	}
+
+	// Presets, clusters, response files and aliases are expanded in
+	// place; 'pos' maps each word of args to the word of 'input' it
+	// came from, so that indexes and tokens refer to what the user
+	// typed.
+	input := args
+	pos := make([]int, len(args))
+	for j := range pos {
+		pos[j] = j
+	}
+	expand := func(i int, words []string) {
+		args = expandPreset(args, i, words)
+		pos = expandPos(pos, i, len(words))
+	}
+
+	// the index in the args given to Interpret() of args[j]; -1 for
+	// the words of the flags env var
+	argIndex := func(j int) int {
+		switch k := pos[j]; {
+		case k == 0:
+			return 0
+		case k <= nflags:
+			return -1
+		default:
+			return k - nflags
+		}
+	}
+
+	//fmt.Printf("Options: %+v\n", spec.options)
+
+	// command scoped options seen on the command line
+	var scoped []scopedArg
+
+	// set once the command is seen in opts_after_cmd mode
+	incmd := false
+
+	// the index in the args given to Interpret() of each of the
+	// command's args (opts.Args), to locate the errors of its sub-spec
+	var cmdpos []int
+	cmdArgs := func(from int) {
+		for j := from; j < len(args); j++ {
+			cmdpos = append(cmdpos, argIndex(j))
+		}
+	}
+
+	// set once a command alias is expanded
+	aliased := false
+
+	// the number of response files read
+	argfiles := 0
+
+	// report the arguments to the Walk() callback, if any
+	walk, _ := ctx.Value(walkKey{}).(func(Event) error)
+	walkEvent := func(kind EventKind, name, value string, at int) error {
+		if walk == nil {
+			return nil
+		}
+		return walk(Event{kind, name, value, argIndex(at)})
+	}
+	walkArgs := func(from int) error {
+		for j := from; j < len(args); j++ {
+			if err := walkEvent(EventArg, "", args[j], j); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for i := 1; i < len(args); i++ {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		arg := args[i]
+		at := i
+
+		// from the flags env var
+		layer := argIndex(at) < 0
+
+		src, index = SourceCommandLine, argIndex(at)
+		if layer {
+			src = SourceEnv
+		}
+
+		// A lone "--" terminates option parsing; the command (if any)
+		// sees it as well.
+		if arg == "--" {
+			if incmd {
+				opts.Args = append(opts.Args, args[i:]...)
+				cmdArgs(i)
 			} else {
-				option = arg
+				opts.ArgGroups = argGroups(opts.Args, args[i+1:])
+				if i+1 < len(args) {
+					opts.Args = append(opts.Args, args[i+1:]...)
+				}
+			}
+			if err = walkArgs(i + 1); err != nil {
+				return
 			}
+			break
+		}
+
+		if a, ok := spec.windowsArg(arg); ok {
+			expand(i, []string{a})
+			i--
+			continue
+		}
+
+		if words, ok := spec.presets[arg]; ok {
+			expand(i, words)
+			i--
+			continue
+		}
+
+		// after the command, anything that isn't one of our options
+		// belongs to the command
+		if incmd {
+			nm, _, _ := strings.Cut(arg, "=")
+			nm = spec.foldOption(nm)
+			_, ok := spec.options[nm]
+			if !ok {
+				_, ok = spec.negatedFlag(nm)
+			}
+			if !ok || !strings.HasPrefix(arg, "-") {
+				opts.Args = append(opts.Args, arg)
+				cmdpos = append(cmdpos, argIndex(i))
+				if err = walkEvent(EventArg, "", arg, i); err != nil {
+					return
+				}
+				continue
+			}
+		}
+
+		if spec.isArgFile(arg) {
+			words, e := spec.readArgFile(arg, argfiles)
+			if err = e; err != nil {
+				return
+			}
+			argfiles++
+			expand(i, words)
+			if err = spec.checkArgs(args); err != nil {
+				return
+			}
+			i--
+			continue
+		}
+
+		if words, ok := spec.splitCluster(arg); ok {
+			expand(i, words)
+			i--
+			continue
+		}
+
+		// a lone "-" (conventionally stdin) is an argument
+		if strings.HasPrefix(arg, "-") && (len(arg) > 1 || spec.legacy) {
+			option := "-"
+			value := "true"
 
-			if opt, present := spec.options[option]; present {
+			spelling, attached, hasval := strings.Cut(arg, "=")
+
+			//fmt.Printf("<< arg %d: %s >>: spelling = %s\n", i, arg, spelling)
+
+			option = spec.foldOption(spelling)
+			opt, negated, present := spec.findOption(option)
+			if err = spec.autoHelp(arg, opt); err != nil {
+				return
+			}
+			if !present && spec.opt_prefix {
+				if opt, err = spec.matchOption(option); err != nil {
+					return
+				}
+				present = len(opt) > 0
+			}
+			if present {
 				option = opt
+			} else if spec.pass_unknown {
+				opts.Unknown = append(opts.Unknown, arg)
+				continue
+			} else if v, ok := spec.removed[option]; ok {
+				err = spec.optError(MsgRemovedOption, "", arg, option, v)
+				return
+			} else if s := spec.suggestOption(option); len(s) > 0 && !spec.legacy {
+				err = spec.optError(MsgUnknownOptHint, "", arg, arg, orList(s))
+				err.(*Error).Suggestions = s
+				return
 			} else {
-				err = fmt.Errorf("Invalid option: %s was not recognized", arg)
+				err = spec.optError(MsgUnknownOption, "", arg, arg)
 				return
 			}
 
 			if spec.flags[option] {
-				if len(parts) == 2 {
-					err = fmt.Errorf("Invalid option: %s was not recognized (doesn't take a value)", arg)
+				if hasval {
+					err = spec.optError(MsgNoValue, option, arg, arg)
 					return
 				}
+				if negated {
+					value = "false"
+				}
 			} else {
-				if len(parts) == 2 {
-					value = parts[1]
+				if hasval {
+					value = attached
+				} else if e := spec.optinfo[option]; e != nil && len(e.implicit) > 0 {
+					value = e.implicit
 				} else if len(args) > i+1 {
 					value = args[i+1]
 					i++
 				} else {
-					err = fmt.Errorf("Invalid option: %s was not recognized (requires a value)", arg)
+					err = spec.optError(MsgNeedsValue, option, arg, arg)
 					return
 				}
 			}
 
-			// second and subsequent options go in optionv
-			if _, ok := opts.options[option]; ok {
-				opts.optionv[option] = append(opts.optionv[option], value)
-			} else {
+			if err = spec.checkValueSize(option, arg, value); err != nil {
+				return
+			}
+			if value, err = spec.expand(option, arg, value, environ); err != nil {
+				return
+			}
+			value = spec.normalize(option, value)
+			value = spec.migrate(opts, option, arg, value)
+			if err = spec.checkType(option, arg, value); err != nil {
+				return
+			}
+
+			if o := spec.optinfo[option]; o != nil && len(o.cmds) > 0 {
+				scoped = append(scoped, scopedArg{option, arg})
+			}
+
+			if fromenv[option] {
+				spec.debug("option overrides env", "option", option, "arg", spec.redactArg(option, arg))
+				delete(opts.options, option)
+				delete(opts.optionv, option)
+				delete(fromenv, option)
+			}
+
+			if layer {
+				layered[option] = true
+			} else if layered[option] {
+				spec.debug("option overrides flags env", "option", option, "arg", spec.redactArg(option, arg))
+				delete(opts.options, option)
+				delete(opts.optionv, option)
+				delete(layered, option)
+				opts.dropOccurrences(option)
+			}
+
+			opts.order = append(opts.order, Occurrence{
+				Name:   option,
+				Value:  value,
+				Index:  index,
+				Tokens: input[pos[at] : pos[i]+1 : pos[i]+1],
+			})
+			if err = walkEvent(EventOption, option, value, at); err != nil {
+				return
+			}
+
+			// second and subsequent options are only in optionv
+			if _, ok := opts.options[option]; !ok {
 				opts.options[option] = value
+				opts.sources[option] = src
+			}
+			opts.optionv[option] = append(opts.optionv[option], value)
+			if err = spec.checkRepeat(option, arg, len(opts.optionv[option])); err != nil {
+				return
+			}
+			spec.debug("option from command line", "option", option, "arg", spec.redactArg(option, arg), "value", spec.redact(option, value))
+			if err = spec.noteDeprecated(opts, option, spelling); err != nil {
+				return
 			}
+
+			if err = spec.callFunc(ctx, option, arg, value); err != nil {
+				return
+			}
+			continue
+		}
+
+		command, present := spec.commands[spec.foldCommand(arg)]
+		if words, ok := spec.aliases[arg]; ok && !present && !aliased {
+			expand(i, words)
+			aliased = true
+			i--
 			continue
 		}
 
-		if command, present := spec.commands[arg]; present {
+		if !present && spec.cmd_prefix {
+			if command, err = spec.matchCommand(arg); err != nil {
+				return
+			}
+			present = len(command) > 0
+		}
+
+		if present {
 			opts.Command = command
-			opts.Args = args[i:]
-			opts.Args[0] = opts.Command
+			spec.debug("command", "command", command, "arg", arg)
+			if err = walkEvent(EventCommand, command, arg, i); err != nil {
+				return
+			}
+			if spec.opts_after_cmd {
+				opts.Args = []string{command}
+				cmdpos = []int{argIndex(i)}
+				incmd = true
+				continue
+			}
+			opts.Args = append([]string{opts.Command}, args[i+1:]...)
+			cmdArgs(i)
+			if err = walkArgs(i + 1); err != nil {
+				return
+			}
 			break
 		}
 
 		if spec.allow_unknown_args {
+			if spec.posix_args {
+				opts.Args = append(opts.Args, args[i:]...)
+				if err = walkArgs(i); err != nil {
+					return
+				}
+				break
+			}
 			opts.Args = append(opts.Args, arg)
+			if err = walkEvent(EventArg, "", arg, i); err != nil {
+				return
+			}
 			continue
 		}
 
-		err = fmt.Errorf("Invalid argument: %s was not recognized", arg)
+		if s := spec.suggestCommand(arg); len(s) > 0 && !spec.legacy {
+			err = spec.optError(MsgUnknownCommand, "", arg, arg, orList(s))
+			err.(*Error).Suggestions = s
+			return
+		}
+		if blk := spec.commandBlock(); len(blk) > 0 && !spec.legacy {
+			err = spec.optError(MsgNoCommand, "", arg, arg, blk)
+			return
+		}
+		err = spec.optError(MsgUnknownArg, "", arg, arg)
+		return
+	}
+
+	src, index = SourceNone, -1
+	if err = spec.applyDefaultCommand(opts); err != nil {
+		return
+	}
+
+	if err = spec.checkScoped(opts, scoped); err != nil {
+		return
+	}
+
+	if err = spec.applyProfile(opts); err != nil {
+		return
+	}
+	spec.normalizeDefaults(opts)
+	if err = spec.expandDefaults(opts, environ); err != nil {
+		return
+	}
+	if err = spec.checkPaths(opts); err != nil {
+		return
+	}
+
+	if err = spec.checkRequired(opts); err != nil {
+		return
+	}
+	if err = spec.checkRequiredEnv(opts); err != nil {
+		return
+	}
+	if err = spec.checkCounts(opts); err != nil {
+		return
+	}
+
+	if err = spec.checkDeps(opts); err != nil {
 		return
 	}
 
-	for option, required := range spec.required {
-		if _, present := opts.options[option]; required && !present {
-			err = fmt.Errorf("Missing option: %s", option)
+	if err = spec.checkPositional(opts); err != nil {
+		return
+	}
+
+	if sub := spec.subspecs[opts.Command]; sub != nil {
+		// the command's arguments were reported as such
+		sctx := context.WithValue(ctx, walkKey{}, nil)
+		if opts.Sub, err = sub.interpret(sctx, nil, opts.Args, environ, nil, nil); err != nil {
+			// the index is relative to the command's args
+			var e *Error
+			if errors.As(err, &e) && e.Source == SourceCommandLine && e.Index >= 0 && e.Index < len(cmdpos) {
+				e.Index = cmdpos[e.Index]
+			}
+			err = fmt.Errorf("%s: %w", opts.Command, err)
 			return
 		}
 	}
-
-	for env, option := range spec.environment {
-		if value, present := opts.options[option]; present {
-			os.Setenv(env, value)
+	if len(opts.Command) > 0 {
+		opts.CommandPath = []string{opts.Command}
+		if opts.Sub != nil {
+			opts.CommandPath = append(opts.CommandPath, opts.Sub.CommandPath...)
 		}
 	}
 
+	if spec.setenv && hostSetenv {
+		opts.Setenv()
+	}
+
 	o = opts
 	return
 }
 
+// Store the config file values 'conf' in 'opts' and mark them in
+// 'layered' as replaceable by the environment and the command line.
+// Options are set in declaration order; a key that isn't the name of
+// an option is an error.
+func (spec *Spec) applyConfig(ctx context.Context, opts *Options, conf map[string][]string, environ []string, layered map[string]bool) error {
+	keys := make([]string, 0, len(conf))
+	for k := range conf {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if spec.optinfo[k] == nil {
+			return spec.optError(MsgUnknownConfig, "", k, k)
+		}
+	}
+
+	for _, o := range spec.optlist {
+		vals, ok := conf[o.name]
+		if !ok || len(vals) == 0 {
+			continue
+		}
+
+		opts.optionv[o.name] = nil
+		for _, v := range vals {
+			if err := spec.checkValueSize(o.name, o.name, v); err != nil {
+				return err
+			}
+			v, err := spec.expand(o.name, o.name, v, environ)
+			if err != nil {
+				return err
+			}
+			v = spec.normalize(o.name, v)
+			v = spec.migrate(opts, o.name, o.name, v)
+			if err := spec.checkType(o.name, o.name, v); err != nil {
+				return err
+			}
+			opts.optionv[o.name] = append(opts.optionv[o.name], v)
+		}
+		opts.options[o.name] = opts.optionv[o.name][0]
+		opts.sources[o.name] = SourceConfig
+		layered[o.name] = true
+
+		if err := spec.noteDeprecated(opts, o.name, o.name); err != nil {
+			return err
+		}
+		spec.debug("option from config", "option", o.name, "values", opts.redactedValues(o.name))
+		for _, v := range opts.optionv[o.name] {
+			if err := spec.callFunc(ctx, o.name, o.name, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Split the arguments 'rest' that follow the first "--" into groups at
+// each further "--"; 'first' is the group before the first "--".
+func argGroups(first, rest []string) [][]string {
+	g := [][]string{append([]string{}, first...)}
+	cur := []string{}
+	for _, a := range rest {
+		if a == "--" {
+			g = append(g, cur)
+			cur = []string{}
+			continue
+		}
+		cur = append(cur, a)
+	}
+	return append(g, cur)
+}
+
+// Verify that all the required options are present; the error lists
+// every missing option with its spellings in declaration order. By
+// default a required option must be given on the command line or in
+// the environment; see SetDefaultSatisfiesRequired(). The required
+// entries of the environment section are checked by checkRequiredEnv().
+func (spec *Spec) checkRequired(opts *Options) error {
+	var missing, names []string
+
+	for _, o := range spec.optlist {
+		if !spec.required[o.name] || (o.isenv && !spec.legacy) {
+			continue
+		}
+
+		if _, present := opts.options[o.name]; present {
+			continue
+		}
+		if _, present := opts.defaults[o.name]; present && spec.default_required {
+			continue
+		}
+		if spec.legacy {
+			return spec.errorf(MsgMissingOption, o.name)
+		}
+		missing = append(missing, o.describe())
+		names = append(names, o.name)
+	}
+
+	switch len(missing) {
+	case 0:
+		return nil
+	case 1:
+		return spec.optError(MsgMissingOption, names[0], "", missing[0])
+	default:
+		return spec.errorf(MsgMissingOptions, strings.Join(missing, ", "))
+	}
+}
+
+// Return the command line spellings of the option with the value
+// placeholder, if any, attached: ["-o FILE", "--out=FILE"].
+func (o *optspec) flagTexts() []string {
+	flags := o.visibleFlags()
+	if len(o.metavar) == 0 {
+		return flags
+	}
+
+	w := make([]string, len(flags))
+	for i, f := range flags {
+		if strings.HasPrefix(f, "--") {
+			w[i] = f + "=" + o.metavar
+		} else {
+			w[i] = f + " " + o.metavar
+		}
+	}
+	return w
+}
+
+// Return the flagTexts() as a comma separated list
+func (o *optspec) flagText() string {
+	return strings.Join(o.flagTexts(), ", ")
+}
+
+// Return the option name followed by its command line spellings and
+// environment variables, e.g. "root (-r, --root, ROOT)".
+func (o *optspec) describe() string {
+	w := append(append([]string{}, o.flags...), o.env...)
+	if len(w) == 0 {
+		return o.name
+	}
+	return fmt.Sprintf("%s (%s)", o.name, strings.Join(w, ", "))
+}
+
 // Print the usage string to STDOUT
 func (spec *Spec) PrintUsage() {
-	fmt.Fprintf(os.Stdout, "%s\n", spec.usage)
+	fmt.Fprintf(spec.outw(), "%s\n", spec.usageText(spec.IsTerminal()))
 }
 
-// Print the usage string to STDOUT and exit with a non-zero code.
+// Print the usage string to STDOUT and exit with a non-zero code (see
+// ExitPolicy).
 func (spec *Spec) PrintUsageAndExit() {
 	spec.PrintUsage()
-	os.Exit(1)
+	spec.terminate(spec.exit_policy.code(nil))
 }
 
 // Print the error string corresponding to 'err' and then show the
-// usage string. Both are sent to STDERR. Exit with a non-zero code.
+// usage string. Both are sent to STDERR. Exit with a non-zero code
+// that depends on the kind of error (see ExitPolicy).
 func (spec *Spec) PrintUsageWithError(err error) {
-	fmt.Fprintf(os.Stderr, "error: %s\n%s\n", err, spec.usage)
-	os.Exit(1)
+	switch p := spec.exit_policy; {
+	case p.Quiet:
+	case p.NoUsage:
+		fmt.Fprintf(spec.errw(), "error: %s\n", err)
+	default:
+		w := spec.errw()
+		fmt.Fprintf(w, "error: %s\n%s\n", err, spec.usageText(isTerminal(w)))
+	}
+	spec.terminate(spec.exit_policy.code(err))
 }
 
 // Return the option corresponding to 'nm'. If the option is not set
@@ -477,65 +2083,218 @@ func (opts *Options) Get(nm string) (string, bool) {
 
 // For options that are providd multiple times, return all of them in a
 // slice. A nil slice implies the option was not set on the command line.
+// The slice is a copy that the caller is free to modify.
+//
+// The values come from a single source, the one with the highest
+// precedence that supplied any: the command line (in the order given,
+// after the options of the flags env var, see SetFlagsEnv()) or else
+// the environment. The environment supplies at most one value: that of
+// the first variable bound to the option (in declaration order) that
+// is set.
 func (opts *Options) GetMulti(nm string) []string {
-	var rv []string
-	var v string
-	var ok bool
-
-	if v, ok = opts.options[nm]; !ok {
-		return nil
-	}
-
-	rv = append(rv, v)
-	rv = append(rv, opts.optionv[nm]...)
-	return rv
+	return slices.Clone(opts.optionv[nm])
 }
 
 // Interpret the option corresponding to the key 'nm' as
 // a Bool and parse it. A failed parse defaults to False.
 func (opts *Options) GetBool(nm string) bool {
-	if v, ok := opts.Get(nm); ok {
-		switch strings.ToLower(v) {
-		case "true", "ok", "1", "yes", "on":
-			return true
+	b, _ := memo(opts, convBool, nm, func(v string) (bool, bool) {
+		b, _ := parseBool(v)
+		return b, true
+	})
 
-		default:
-			return false
-		}
+	return b
+}
+
+// The state of a boolean option: unset, false or true
+type Tristate int8
+
+const (
+	Unset Tristate = iota
+	False
+	True
+)
+
+func (t Tristate) String() string {
+	switch t {
+	case False:
+		return "false"
+	case True:
+		return "true"
 	}
+	return "unset"
+}
 
-	return false
+// Return the state of the boolean option 'nm': Unset if it wasn't given
+// on the command line or in the environment, otherwise True or False
+// (see GetBool()). Unlike GetBool(), this tells "the user said false"
+// apart from "the user said nothing", e.g. when layering config.
+func (opts *Options) GetTristate(nm string) Tristate {
+	if !opts.IsSet(nm) {
+		return Unset
+	}
+	if opts.GetBool(nm) {
+		return True
+	}
+	return False
+}
+
+// Interpret the option corresponding to the key 'nm' as a Bool like
+// GetBool(); 'set' is true if the option was given on the command line
+// or in the environment rather than coming from a default.
+func (opts *Options) GetBoolOpt(nm string) (value bool, set bool) {
+	return opts.GetBool(nm), opts.IsSet(nm)
+}
+
+// Interpret the option corresponding to the key 'nm' as a Bool like
+// GetBool() but return an error for a value other than true/false,
+// yes/no, on/off or 1/0 (in any case) instead of treating it as False.
+// An option without a value is False.
+func (opts *Options) GetBoolErr(nm string) (bool, error) {
+	v, ok := opts.Get(nm)
+	if !ok || len(v) == 0 {
+		return false, nil
+	}
+
+	b, ok := parseBool(v)
+	if !ok {
+		return false, fmt.Errorf("Invalid option: %s: %s is not a valid bool", nm, v)
+	}
+	return b, nil
 }
 
 // Interpret the option corresponding to the key 'nm' as a signed
 // integer (auto-detected base). The second retval will be false if
 // the parse fails or the key is not found.
 func (opts *Options) GetInt(nm string) (int64, bool) {
-	if v, ok := opts.Get(nm); ok {
-		if i, err := strconv.ParseInt(v, 0, 64); err == nil {
-			return i, true
-		}
-	}
-	return 0, false
+	return memo(opts, convInt, nm, func(v string) (int64, bool) {
+		i, err := strconv.ParseInt(opts.numfmt.normalize(v), 0, 64)
+		return i, err == nil
+	})
 }
 
 // Interpret the option corresponding to the key 'nm' as an unsigned
 // integer (auto-detected base). The second retval will be false if
 // the parse fails or the key is not found.
 func (opts *Options) GetUint(nm string) (uint64, bool) {
-	if v, ok := opts.Get(nm); ok {
-		if i, err := strconv.ParseUint(v, 0, 64); err == nil {
-			return i, true
-		}
-	}
-	return 0, false
+	return memo(opts, convUint, nm, func(v string) (uint64, bool) {
+		i, err := strconv.ParseUint(opts.numfmt.normalize(v), 0, 64)
+		return i, err == nil
+	})
+}
+
+// Interpret the option corresponding to the key 'nm' as a floating
+// point number. The second retval will be false if the parse fails or
+// the key is not found.
+func (opts *Options) GetFloat(nm string) (float64, bool) {
+	return memo(opts, convFloat, nm, func(v string) (float64, bool) {
+		f, err := strconv.ParseFloat(opts.numfmt.normalize(v), 64)
+		return f, err == nil
+	})
+}
+
+// Interpret the option corresponding to the key 'nm' as a duration in
+// the syntax of time.ParseDuration(), e.g. "30s" or "1h15m". The
+// second retval will be false if the parse fails or the key is not
+// found.
+func (opts *Options) GetDuration(nm string) (time.Duration, bool) {
+	return memo(opts, convDuration, nm, func(v string) (time.Duration, bool) {
+		d, err := time.ParseDuration(v)
+		return d, err == nil
+	})
+}
+
+// Interpret the option corresponding to the key 'nm' as a size in
+// bytes with an optional binary suffix, e.g. "512", "10k", "4M" or
+// "1.5G" (see parseSize()). The second retval will be false if the
+// parse fails or the key is not found.
+func (opts *Options) GetSize(nm string) (uint64, bool) {
+	return memo(opts, convSize, nm, func(v string) (uint64, bool) {
+		n, err := parseSize(opts.numfmt.normalize(v))
+		return n, err == nil
+	})
 }
 
 // Return true if the option with the key 'nm' is set (i.e., provided
-// on the command line).
+// on the command line or in the environment), even if it was set to an
+// empty value.
 func (opts *Options) IsSet(nm string) bool {
 	_, ok := opts.options[nm]
 	return ok
 }
 
+// Return true if the option with the key 'nm' is set to an empty value,
+// e.g. with "--root=" or "ROOT=" in the environment. This tells an
+// option that was cleared apart from one that wasn't given.
+func (opts *Options) IsSetEmpty(nm string) bool {
+	v, ok := opts.options[nm]
+	return ok && len(v) == 0
+}
+
+// Return the number of times option 'nm' was given on the command line
+// or in the environment, e.g. 3 for "-v -v -v" or "-vvv". For a flag,
+// a false value ("--no-verbose") resets the count, so "-vv
+// --no-verbose -v" counts 1.
+func (opts *Options) GetCount(nm string) int {
+	v := opts.optionv[nm]
+	if opts.spec == nil || !opts.spec.flags[nm] {
+		return len(v)
+	}
+
+	n := 0
+	for _, s := range v {
+		if b, _ := parseBool(s); b {
+			n++
+		} else {
+			n = 0
+		}
+	}
+	return n
+}
+
+// Return a deep copy of opts. The copy shares no internal state with
+// opts, so either can be modified without affecting the other.
+func (opts *Options) Clone() *Options {
+	c := &Options{
+		options:  make(map[string]string, len(opts.options)),
+		optionv:  make(map[string][]string, len(opts.optionv)),
+		defaults: make(map[string]string, len(opts.defaults)),
+		Command:  opts.Command,
+		Args:     append([]string{}, opts.Args...),
+		numfmt:   opts.numfmt,
+		order:    append([]Occurrence{}, opts.order...),
+		sources:  make(map[string]Source, len(opts.sources)),
+		spec:     opts.spec,
+		warnings: append([]error{}, opts.warnings...),
+	}
+
+	for k, v := range opts.options {
+		c.options[k] = v
+	}
+	for k, v := range opts.optionv {
+		c.optionv[k] = append([]string{}, v...)
+	}
+	for k, v := range opts.defaults {
+		c.defaults[k] = v
+	}
+	for k, v := range opts.sources {
+		c.sources[k] = v
+	}
+	for _, g := range opts.ArgGroups {
+		c.ArgGroups = append(c.ArgGroups, append([]string{}, g...))
+	}
+	if opts.named != nil {
+		c.named = make(map[string][]string, len(opts.named))
+		for k, v := range opts.named {
+			c.named[k] = append([]string{}, v...)
+		}
+	}
+	if opts.Sub != nil {
+		c.Sub = opts.Sub.Clone()
+	}
+	c.CommandPath = append([]string(nil), opts.CommandPath...)
+	c.Unknown = append([]string(nil), opts.Unknown...)
+	return c
+}
+
 // vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -1,6 +1,7 @@
 package options
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -184,3 +185,937 @@ func TestDefaults(t *testing.T) {
 		t.Error("-n != 5")
 	}
 }
+
+var benchSpec = `
+    usage: haraway <flags>... <command> <args>...
+    --
+    root=     -r,--root=,HARAWAY_ROOT     Path to the haraway data root
+    prefix=   -p,--prefix,HARAWAY_PREFIX  Path to the haraway install prefix.
+    include=  -I,--include=               Add dir to include search path
+    num=2     -n=                         Number of things
+    verbose   -v,--verbose                Show more info
+    debug     -d,--debug,HARAWAY_DEBUG    Show debug info
+    --
+    --
+    exec      c,exec                      Execute a command within the haraway sanbox
+    shell     sh,shell                    Open a shell within the haraway sanbox
+    --
+    `
+
+var benchArgs = []string{"haraway", "-p", "/usr/local", "-r=hello", "-v",
+	"-I", "/a", "-I", "/b", "-n", "5", "exec", "ls"}
+
+func benchOptions(b *testing.B) *Options {
+	spec, err := Parse(benchSpec)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	opts, err := spec.Interpret(benchArgs, []string{"HARAWAY_DEBUG=1"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return opts
+}
+
+func BenchmarkGet(b *testing.B) {
+	opts := benchOptions(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		opts.Get("root")
+		opts.Get("num")
+	}
+}
+
+func BenchmarkGetMulti(b *testing.B) {
+	opts := benchOptions(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		opts.GetMulti("include")
+	}
+}
+
+func BenchmarkGetInt(b *testing.B) {
+	opts := benchOptions(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		opts.GetInt("num")
+	}
+}
+
+func BenchmarkInterpret(b *testing.B) {
+	spec, err := Parse(benchSpec)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := spec.Interpret(benchArgs, []string{"HARAWAY_DEBUG=1"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGetNoAlloc(t *testing.T) {
+	spec, err := Parse(benchSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret(benchArgs, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts.GetInt("num")
+	n := testing.AllocsPerRun(100, func() {
+		opts.Get("root")
+		opts.Get("num")
+		opts.GetInt("num")
+		opts.IsSet("verbose")
+	})
+	if n != 0 {
+		t.Errorf("expected zero allocations, saw %v", n)
+	}
+
+	// GetMulti() returns a copy
+	v := opts.GetMulti("include")
+	v[0] = "/x"
+	_ = append(v[:1], "/y")
+	if w := opts.GetMulti("include"); len(w) != 2 || w[0] != "/a" || w[1] != "/b" {
+		t.Errorf("GetMulti result is shared: %q", w)
+	}
+}
+
+func TestClone(t *testing.T) {
+	spec, err := Parse(`
+    usage: multi <flags>...
+    --
+    include=  -I,--include=               Add dir to include search path
+    num=2     -n=                         Number of things
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"multi", "-I", "a", "-I", "b", "x"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := opts.Clone()
+	if !c.Equal(opts) {
+		t.Fatalf("clone differs: %v", c.Diff(opts))
+	}
+
+	c.optionv["include"][0] = "z"
+	c.defaults["num"] = "9"
+	c.Args[0] = "y"
+
+	if v := opts.GetMulti("include"); v[0] != "a" {
+		t.Errorf("clone shares values: %q", v)
+	}
+	if v, _ := opts.GetInt("num"); v != 2 {
+		t.Errorf("clone shares defaults: %d", v)
+	}
+	if opts.Args[0] != "x" {
+		t.Errorf("clone shares args: %q", opts.Args)
+	}
+}
+
+func TestInterpretWithDefaults(t *testing.T) {
+	spec, err := Parse(`
+    usage: haraway <flags>...
+    --
+    root=XYZ  -r,--root=                  Path to the haraway data root
+    num=      -n=                         Number of things
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.InterpretWithDefaults([]string{"haraway"}, []string{}, map[string]string{"num": "4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("root"); v != "XYZ" {
+		t.Errorf("expected spec default XYZ, saw %q", v)
+	}
+	if v, _ := opts.GetInt("num"); v != 4 {
+		t.Errorf("expected num 4, saw %d", v)
+	}
+
+	opts, err = spec.InterpretWithDefaults([]string{"haraway", "-n", "6"}, []string{}, map[string]string{"num": "4", "root": "/site"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.GetInt("num"); v != 6 {
+		t.Errorf("expected num 6, saw %d", v)
+	}
+	if v, _ := opts.Get("root"); v != "/site" {
+		t.Errorf("expected /site, saw %q", v)
+	}
+
+	// the spec is unchanged
+	opts, _ = spec.Interpret([]string{"haraway"}, []string{})
+	if _, ok := opts.Get("num"); ok {
+		t.Error("per call defaults leaked into the spec")
+	}
+
+	if _, err := spec.InterpretWithDefaults([]string{"haraway"}, []string{}, map[string]string{"bogus": "1"}); err == nil {
+		t.Error("expected error for unknown default")
+	}
+}
+
+func TestOptionsAfterCommand(t *testing.T) {
+	spec, err := Parse(`
+    usage: haraway <flags>... <command> <args>...
+    --
+    root=     -r,--root=                  Path to the haraway data root
+    verbose   -v,--verbose                Show more info
+    --
+    --
+    exec      c,exec                      Execute a command within the haraway sanbox
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	argv := []string{"haraway", "exec", "-v", "ls", "-l", "--root=/x", "--", "-v"}
+
+	opts, err := spec.Interpret(argv, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.IsSet("verbose") {
+		t.Error("options after the command are the command's by default")
+	}
+
+	spec.SetOptionsAfterCommand(true)
+	opts, err = spec.Interpret(argv, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !opts.GetBool("verbose") {
+		t.Error("expected verbose")
+	}
+	if v, _ := opts.Get("root"); v != "/x" {
+		t.Errorf("expected root /x, saw %q", v)
+	}
+	if opts.Command != "exec" || strings.Join(opts.Args, " ") != "exec ls -l -- -v" {
+		t.Errorf("bad command args: %q", opts.Args)
+	}
+}
+
+func TestMissingRequired(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    !root=    -r,--root=,TOOL_ROOT        Data root
+    verbose   -v                          Show more info
+    !user=    -u,--user=                  User name
+    --
+    !TOKEN=   TOKEN                       Access token
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = spec.Interpret([]string{"tool"}, []string{})
+	want := "Missing options: root (-r, --root, TOOL_ROOT), user (-u, --user)"
+	if err == nil || err.Error() != want {
+		t.Errorf("expected %q, saw %v", want, err)
+	}
+
+	// required env vars must come from the environment
+	_, err = spec.Interpret([]string{"tool", "-u", "me"}, []string{"TOOL_ROOT=/x"})
+	want = "Missing environment variable: TOKEN"
+	if err == nil || err.Error() != want || !errors.Is(err, ErrMissingRequired) {
+		t.Errorf("expected %q, saw %v", want, err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-u", "me"}, []string{"TOOL_ROOT=/x", "TOKEN=t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := opts.GetEnv("TOKEN"); !ok || v != "t" {
+		t.Errorf("expected TOKEN from the env, saw %q", v)
+	}
+	if v, ok := opts.GetEnv("root"); !ok || v != "/x" {
+		t.Errorf("expected root from the env, saw %q", v)
+	}
+	if _, ok := opts.GetEnv("user"); ok {
+		t.Error("user came from the command line")
+	}
+}
+
+func TestArgGroups(t *testing.T) {
+	spec, err := Parse(`
+    usage: cmp [options] <a>... -- <b>...
+    --
+    verbose   -v,--verbose                Show more info
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"cmp", "-v", "x", "--", "a", "-v", "--", "b", "c"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := opts.ArgGroups
+	if len(g) != 3 {
+		t.Fatalf("expected 3 groups, saw %q", g)
+	}
+	if fmt.Sprint(g) != "[[x] [a -v] [b c]]" {
+		t.Errorf("bad groups: %q", g)
+	}
+	if strings.Join(opts.Args, " ") != "x a -v -- b c" {
+		t.Errorf("bad args: %q", opts.Args)
+	}
+
+	opts, err = spec.Interpret([]string{"cmp", "x", "y"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.ArgGroups != nil {
+		t.Errorf("no groups expected without --: %q", opts.ArgGroups)
+	}
+}
+
+func TestFlagsEnv(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command>
+    --
+    root=     -r,--root=                  Data root
+    include=  -I=                         Include dirs
+    verbose   -v,--verbose                Show more info
+    --
+    --
+    exec      exec                        Execute
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetFlagsEnv("TOOL_FLAGS")
+
+	env := []string{"TOOL_FLAGS=-v -r '/site root' -I /a"}
+	opts, err := spec.Interpret([]string{"tool", "-I", "/b", "-I", "/c", "exec", "ls"}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !opts.GetBool("verbose") {
+		t.Error("expected verbose from TOOL_FLAGS")
+	}
+	if v, _ := opts.Get("root"); v != "/site root" {
+		t.Errorf("expected root from TOOL_FLAGS, saw %q", v)
+	}
+	if v := opts.GetMulti("include"); strings.Join(v, " ") != "/b /c" {
+		t.Errorf("the command line must replace TOOL_FLAGS: %q", v)
+	}
+	if strings.Join(opts.Args, " ") != "exec ls" {
+		t.Errorf("bad args: %q", opts.Args)
+	}
+
+	if _, err := spec.Interpret([]string{"tool"}, []string{"TOOL_FLAGS=-r 'x"}); err == nil {
+		t.Error("expected error for a malformed TOOL_FLAGS")
+	}
+}
+
+func TestRequiredWithDefault(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    !root=/x  -r,--root=                  Data root
+    !user=    -u,--user=                  User name
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = spec.Interpret([]string{"tool", "-u", "me"}, []string{})
+	if err == nil || !strings.Contains(err.Error(), "root") {
+		t.Errorf("expected root to be missing, saw %v", err)
+	}
+
+	spec.SetDefaultSatisfiesRequired(true)
+	opts, err := spec.Interpret([]string{"tool", "-u", "me"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("root"); v != "/x" {
+		t.Errorf("expected default /x, saw %q", v)
+	}
+
+	_, err = spec.Interpret([]string{"tool"}, []string{})
+	if err == nil || err.Error() != "Missing option: user (-u, --user)" {
+		t.Errorf("expected user to be missing, saw %v", err)
+	}
+}
+
+func TestMultiValueOrder(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    tag=      -t,--tag=,TOOL_TAG,TAG      Tags
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetFlagsEnv("TOOL_FLAGS")
+
+	tests := []struct {
+		args []string
+		env  []string
+		want []string
+	}{
+		{nil, []string{"TAG=b", "TOOL_TAG=a"}, []string{"a"}},
+		{nil, []string{"TAG=b"}, []string{"b"}},
+		{[]string{"-t", "x", "-t", "y"}, []string{"TOOL_TAG=a"}, []string{"x", "y"}},
+		{nil, []string{"TOOL_TAG=a", "TOOL_FLAGS=-t f"}, []string{"f"}},
+		{[]string{"-t", "x"}, []string{"TOOL_TAG=a", "TOOL_FLAGS=-t f"}, []string{"x"}},
+	}
+
+	for i, tc := range tests {
+		opts, err := spec.Interpret(append([]string{"tool"}, tc.args...), tc.env)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		v := opts.GetMulti("tag")
+		if !sameStrings(v, tc.want) {
+			t.Errorf("%d: expected %v, saw %v", i, tc.want, v)
+		}
+		if s, _ := opts.Get("tag"); s != tc.want[0] {
+			t.Errorf("%d: expected Get() to return %s, saw %s", i, tc.want[0], s)
+		}
+	}
+}
+
+func TestEnvIgnoreCase(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    path=     -p,--path=,PATH             Search path
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	env := []string{"Path=/a:/b"}
+
+	spec.SetEnvIgnoreCase(false)
+	opts, err := spec.Interpret([]string{"tool"}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.IsSet("path") {
+		t.Error("env var matched without ignoring case")
+	}
+
+	spec.SetEnvIgnoreCase(true)
+	opts, err = spec.Interpret([]string{"tool"}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("path"); v != "/a:/b" {
+		t.Errorf("expected /a:/b, saw %q", v)
+	}
+}
+
+func TestEmptyEnv(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=/x   -r,--root=,TOOL_ROOT        Data root
+    debug     -d,--debug,TOOL_DEBUG       Debug
+    name=     -n,--name=,TOOL_NAME        Name
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	opts, err := spec.Interpret([]string{"tool"}, []string{"TOOL_ROOT=", "TOOL_DEBUG="})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, ok := opts.Get("root"); !ok || v != "" || !opts.IsSet("root") || !opts.IsSetEmpty("root") {
+		t.Errorf("empty env var didn't clear root: %q %v", v, ok)
+	}
+	if !opts.GetBool("debug") || opts.IsSetEmpty("debug") {
+		t.Error("empty env var didn't turn debug on")
+	}
+	if opts.IsSet("name") || opts.IsSetEmpty("name") {
+		t.Error("name wasn't given")
+	}
+}
+
+func TestTristate(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    sync=yes  --sync=                     Sync writes
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		val  bool
+		set  bool
+		tri  Tristate
+	}{
+		{nil, true, false, Unset},
+		{[]string{"--sync=no"}, false, true, False},
+		{[]string{"--sync=on"}, true, true, True},
+	}
+
+	for _, tc := range tests {
+		opts, err := spec.Interpret(append([]string{"tool"}, tc.args...), []string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		v, set := opts.GetBoolOpt("sync")
+		if v != tc.val || set != tc.set {
+			t.Errorf("%v: expected %v, %v; saw %v, %v", tc.args, tc.val, tc.set, v, set)
+		}
+		if tri := opts.GetTristate("sync"); tri != tc.tri {
+			t.Errorf("%v: expected %s, saw %s", tc.args, tc.tri, tri)
+		}
+	}
+}
+
+func TestNegatedFlags(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] [cmd]
+    --
+    verbose      -v,--verbose            Verbose
+    color=true:bool --color,TOOL_COLOR   Colorize the output
+    no-cache     --no-cache              Skip the cache
+    cache        --cache                 Use the cache
+    name=        -n,--name=              Name
+    --
+    --
+    run          run                     Run
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	if !spec.flags["color"] || spec.defaults["color"] != "true" {
+		t.Fatalf("color isn't a flag that is on by default")
+	}
+
+	tests := []struct {
+		args    []string
+		verbose Tristate
+		color   Tristate
+		colorOn bool
+	}{
+		{nil, Unset, Unset, true},
+		{[]string{"-v", "--no-color"}, True, False, false},
+		{[]string{"--no-verbose", "--color"}, False, True, true},
+		{[]string{"-v", "--no-verbose"}, True, Unset, true},
+	}
+
+	for _, tc := range tests {
+		opts, err := spec.Interpret(append([]string{"tool"}, tc.args...), []string{})
+		if err != nil {
+			t.Fatalf("%v: %s", tc.args, err)
+		}
+		if tri := opts.GetTristate("verbose"); tri != tc.verbose {
+			t.Errorf("%v: expected verbose %s, saw %s", tc.args, tc.verbose, tri)
+		}
+		if tri := opts.GetTristate("color"); tri != tc.color {
+			t.Errorf("%v: expected color %s, saw %s", tc.args, tc.color, tri)
+		}
+		if opts.GetBool("color") != tc.colorOn {
+			t.Errorf("%v: expected color %v", tc.args, tc.colorOn)
+		}
+	}
+
+	// a declared --no- spelling wins
+	opts, err := spec.Interpret([]string{"tool", "--cache", "--no-cache"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.GetBool("cache") || !opts.GetBool("no-cache") {
+		t.Error("--no-cache didn't set the declared flag")
+	}
+
+	opts, err = spec.Interpret([]string{"tool"}, []string{"TOOL_COLOR=off"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.GetTristate("color") != False {
+		t.Error("env var didn't turn color off")
+	}
+
+	for _, args := range [][]string{{"--no-name"}, {"--no-color=yes"}, {"--no-v"}} {
+		if _, err = spec.Interpret(append([]string{"tool"}, args...), []string{}); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+
+	spec.SetOptionsAfterCommand(true)
+	opts, err = spec.Interpret([]string{"tool", "run", "--no-color", "x"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.GetBool("color") || len(opts.Args) != 2 {
+		t.Errorf("--no-color after the command: %v", opts.Args)
+	}
+}
+
+func TestBoolDefaultValues(t *testing.T) {
+	// a boolean-looking default doesn't make an option a flag
+	spec, err := Parse(`
+    usage: tool [options] [args]
+    --
+    workers=1      -w,--workers        Number of workers
+    compress=true  -z,--compress       Compress the output
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetLegacy(true)
+
+	opts, err := spec.Interpret([]string{"tool", "-w", "8", "-z", "false", "f"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("workers"); v != "8" {
+		t.Errorf("workers: expected 8, saw %q", v)
+	}
+	if v, _ := opts.Get("compress"); v != "false" {
+		t.Errorf("compress: expected false, saw %q", v)
+	}
+	if len(opts.Args) != 1 || opts.Args[0] != "f" {
+		t.Errorf("expected args [f], saw %v", opts.Args)
+	}
+}
+
+func TestInterspersed(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] [files...]
+    --
+    verbose   -v,--verbose                Verbose
+    out=      -o,--out=                   Output
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"tool", "-o", "x", "a.txt", "--verbose", "b.txt", "--", "-c"}
+
+	opts, err := spec.Interpret(args, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.GetBool("verbose") || strings.Join(opts.Args, " ") != "a.txt b.txt -c" {
+		t.Errorf("interspersed: verbose %v, args %q", opts.GetBool("verbose"), opts.Args)
+	}
+
+	spec.SetInterspersed(false)
+	opts, err = spec.Interpret(args, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.GetBool("verbose") || strings.Join(opts.Args, " ") != "a.txt --verbose b.txt -- -c" {
+		t.Errorf("posix: verbose %v, args %q", opts.GetBool("verbose"), opts.Args)
+	}
+	if v, _ := opts.Get("out"); v != "x" {
+		t.Errorf("posix: bad out %q", v)
+	}
+}
+
+func TestImplicitValue(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] [files...]
+    --
+    color[=auto]=never -c,--color=WHEN  Colorize the output
+    level[=1]          -l,--level=      Compression level
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	tests := []struct {
+		args  []string
+		color string
+		level string
+		rest  []string
+	}{
+		{[]string{"tool", "a"}, "never", "", []string{"a"}},
+		{[]string{"tool", "--color", "a"}, "auto", "", []string{"a"}},
+		{[]string{"tool", "-c", "--level"}, "auto", "1", []string{}},
+		{[]string{"tool", "--color=always", "-l=9", "a"}, "always", "9", []string{"a"}},
+	}
+	for _, tc := range tests {
+		opts, err := spec.Interpret(tc.args, nil)
+		if err != nil {
+			t.Fatalf("%v: %s", tc.args, err)
+		}
+		color, _ := opts.Get("color")
+		level, _ := opts.Get("level")
+		if color != tc.color || level != tc.level || strings.Join(opts.Args, " ") != strings.Join(tc.rest, " ") {
+			t.Errorf("%v: saw %q %q %v", tc.args, color, level, opts.Args)
+		}
+		if argv := opts.BuildArgv(nil, nil); tc.level != "" && argv[len(argv)-1] != "--level="+tc.level {
+			t.Errorf("%v: bad argv %v", tc.args, argv)
+		}
+	}
+
+	if syn := spec.Synopsis(); syn != "tool [-c[=WHEN]] [-l[=LEVEL]] [ARGS...]" {
+		t.Errorf("bad synopsis %q", syn)
+	}
+
+	if _, err = Parse("usage: x\n--\ncolor[=] -c\n--\n--\n--\n"); err == nil {
+		t.Error("expected an error for an empty implicit value")
+	}
+
+	spec, err = Parse(`
+    usage: tool [options] [files...]
+    --
+    color?=auto        -c,--color=WHEN  Colorize the output
+    level?=1=3         -l,--level=      Compression level
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	opts, err := spec.Interpret([]string{"tool", "--color", "a"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	color, _ := opts.Get("color")
+	level, _ := opts.Get("level")
+	if color != "auto" || level != "3" || strings.Join(opts.Args, " ") != "a" {
+		t.Errorf("bad shorthand: %q %q %v", color, level, opts.Args)
+	}
+	if _, ok := spec.defaults["color"]; ok {
+		t.Error("color has no default")
+	}
+}
+
+func TestShortClusters(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] [files...]
+    --
+    verbose   -v                          Verbose
+    debug     -d                          Debug
+    num=      -n=                         Count
+    include=  -I=                         Include dirs
+    color[=auto] -c                       Colorize
+    extra     -vd                         Not a cluster
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"tool", "-vd"}, "extra=true"},
+		{[]string{"tool", "-dv", "a"}, "verbose=true debug=true args=a"},
+		{[]string{"tool", "-vn5", "a"}, "verbose=true num=5 args=a"},
+		{[]string{"tool", "-dn", "7"}, "debug=true num=7"},
+		{[]string{"tool", "-I/usr/include", "-n=3"}, "num=3 include=/usr/include"},
+		{[]string{"tool", "-vc"}, "verbose=true color=auto"},
+		{[]string{"tool", "-cnever"}, "color=never"},
+		{[]string{"tool", "-vx"}, "error"},
+	}
+	for _, tc := range tests {
+		opts, err := spec.Interpret(tc.args, nil)
+		var saw []string
+		if err != nil {
+			saw = append(saw, "error")
+		} else {
+			for _, o := range spec.optlist {
+				if v, ok := opts.Get(o.name); ok {
+					saw = append(saw, o.name+"="+v)
+				}
+			}
+			if len(opts.Args) > 0 {
+				saw = append(saw, "args="+strings.Join(opts.Args, ","))
+			}
+		}
+		if s := strings.Join(saw, " "); s != tc.want {
+			t.Errorf("%v: expected %q, saw %q", tc.args, tc.want, s)
+		}
+	}
+
+	spec.SetLegacy(true)
+	if _, err = spec.Interpret([]string{"tool", "-dv"}, nil); err == nil {
+		t.Error("expected an error for a cluster in legacy mode")
+	}
+}
+
+func TestStdinArg(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] [files...]
+    --
+    verbose   -v,--verbose                Show more info
+    --
+    --
+    exec      exec                        Run a program
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	opts, err := spec.Interpret([]string{"tool", "-v", "-", "a"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.GetBool("verbose") || len(opts.Args) != 2 || opts.Args[0] != "-" {
+		t.Errorf("bad parse: %v", opts.Args)
+	}
+
+	// nothing after "--" is interpreted, not even a command
+	opts, err = spec.Interpret([]string{"tool", "--", "exec", "-v", "--", "-"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.Command) > 0 || opts.GetBool("verbose") {
+		t.Errorf("arguments after -- were interpreted: command %q", opts.Command)
+	}
+	if s := strings.Join(opts.Args, " "); s != "exec -v -- -" {
+		t.Errorf("bad args: %s", s)
+	}
+
+	spec.SetInterspersed(false)
+	opts, err = spec.Interpret([]string{"tool", "-", "-v"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.GetBool("verbose") || len(opts.Args) != 2 {
+		t.Errorf("expected - to end the options: %v", opts.Args)
+	}
+
+	spec, err = Parse(`
+    usage: tool [options]
+    --
+    verbose   -v,--verbose                Show more info
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = spec.Interpret([]string{"tool", "-"}, []string{}); !errors.Is(err, ErrUnknownCommand) {
+		t.Errorf("expected - to be an unexpected argument, saw %v", err)
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(benchSpec); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// a spec with many options, as in busybox-style multicall tools
+func largeSpec() string {
+	var b strings.Builder
+	b.WriteString("usage: tool [options] command\n--\n")
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&b, "opt%d=%d   -o%d,--opt%d=N,TOOL_OPT%d   Option number %d\n", i, i, i, i, i, i)
+	}
+	b.WriteString("--\n--\n")
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&b, "cmd%d   c%d,cmd%d   Command number %d\n", i, i, i, i)
+	}
+	b.WriteString("--\n")
+	return b.String()
+}
+
+func BenchmarkParseLarge(b *testing.B) {
+	desc := largeSpec()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(desc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// output.go - Output streams and program exit
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// The panic value of the Must* and Print*Exit functions on platforms
+// without a process to terminate (js/wasm); 'Code' is the exit code
+// the program would have exited with.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Send the usage and error output of the Print* and Must* functions to
+// 'stdout' and 'stderr' instead of os.Stdout and os.Stderr; a nil
+// writer selects the default.
+func (spec *Spec) SetOutput(stdout, stderr io.Writer) {
+	spec.stdout = stdout
+	spec.stderr = stderr
+}
+
+// Call 'fn' with the exit code instead of terminating the program in
+// MustInterpret(), PrintUsageAndExit() and PrintUsageWithError(), e.g.
+// to return an error from an embedded command shell or to check the
+// code in tests. A nil 'fn' selects the default. If 'fn' returns, so
+// do those functions. The output can be captured with SetOutput() and
+// the usage string is available as Usage().
+func (spec *Spec) SetExit(fn func(code int)) {
+	spec.exit_fn = fn
+}
+
+// Exit with 'code' or call the function set with SetExit()
+func (spec *Spec) terminate(code int) {
+	if spec.exit_fn != nil {
+		spec.exit_fn(code)
+		return
+	}
+	exit(code)
+}
+
+// Return the writer for normal output
+func (spec *Spec) outw() io.Writer {
+	if spec.stdout != nil {
+		return spec.stdout
+	}
+	return os.Stdout
+}
+
+// Return the writer for errors
+func (spec *Spec) errw() io.Writer {
+	if spec.stderr != nil {
+		return spec.stderr
+	}
+	return os.Stderr
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
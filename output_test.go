@@ -0,0 +1,70 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetOutput(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    +verbose  -v,--verbose                Verbose
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errs strings.Builder
+	spec.SetOutput(&out, &errs)
+
+	spec.PrintUsage()
+	spec.PrintShortUsage()
+	if !strings.HasPrefix(out.String(), "usage: tool") || strings.Count(out.String(), "--verbose") != 2 {
+		t.Errorf("bad output:\n%s", out.String())
+	}
+	if errs.Len() > 0 {
+		t.Errorf("unexpected error output:\n%s", errs.String())
+	}
+}
+
+func TestSetExit(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    !root=    -r,--root=                  Root dir
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errs strings.Builder
+	var codes []int
+	spec.SetOutput(&out, &errs)
+	spec.SetExit(func(code int) { codes = append(codes, code) })
+
+	if opts := spec.MustInterpret([]string{"tool"}, []string{}); opts != nil {
+		t.Error("expected nil options")
+	}
+	if !strings.HasPrefix(errs.String(), "error: Missing option") {
+		t.Errorf("bad error output:\n%s", errs.String())
+	}
+
+	spec.PrintUsageAndExit()
+	if !strings.HasPrefix(out.String(), "usage: tool") {
+		t.Errorf("bad output:\n%s", out.String())
+	}
+
+	if opts := spec.MustInterpret([]string{"tool", "-r", "/x"}, []string{}); opts == nil {
+		t.Error("expected options")
+	}
+	if len(codes) != 2 || codes[0] != 1 || codes[1] != 1 {
+		t.Errorf("bad exit codes %v", codes)
+	}
+}
@@ -0,0 +1,26 @@
+// passthru.go - Unknown options passed through to another program
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+// Make Interpret() collect the options it doesn't recognize in
+// opts.Unknown, in the order they were given, instead of failing. This
+// suits wrapper tools that handle a few options of their own and
+// forward the rest to the program they run:
+//
+//	wrap --trace -it --name=web image
+//
+// yields the "trace" option, Unknown [-it --name=web] and Args [image].
+// Since nothing is known about the unknown options, a value must be
+// attached with "=" to stay with its option; a separate value ends up
+// in Args. This is off by default.
+func (spec *Spec) SetPassUnknown(on bool) {
+	spec.pass_unknown = on
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,56 @@
+package options
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPassUnknown(t *testing.T) {
+	spec, err := Parse(`
+    usage: wrap [options] image
+    --
+    trace        -t,--trace           Trace the run
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"wrap", "--trace", "-it", "--name=web", "image", "-p", "80"}
+	if _, err := spec.Interpret(args, []string{}); err == nil {
+		t.Fatal("expected an error for unknown options")
+	}
+
+	spec.SetPassUnknown(true)
+	opts, err := spec.Interpret(args, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.GetBool("trace") {
+		t.Error("trace not set")
+	}
+	if want := []string{"-it", "--name=web", "-p"}; !slices.Equal(opts.Unknown, want) {
+		t.Errorf("unknown: expected %q, saw %q", want, opts.Unknown)
+	}
+	if want := []string{"image", "80"}; !slices.Equal(opts.Args, want) {
+		t.Errorf("args: expected %q, saw %q", want, opts.Args)
+	}
+
+	c := opts.Clone()
+	c.Unknown[0] = "-x"
+	if opts.Unknown[0] != "-it" {
+		t.Error("clone shares Unknown")
+	}
+
+	// "--" still ends the options
+	opts, err = spec.Interpret([]string{"wrap", "--", "--name=web"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.Unknown) != 0 || !slices.Equal(opts.Args, []string{"--name=web"}) {
+		t.Errorf("after --: unknown %q, args %q", opts.Unknown, opts.Args)
+	}
+}
@@ -0,0 +1,131 @@
+// path.go - Path valued options
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Interpret the option corresponding to the key 'nm' as a file system
+// path and return it as a cleaned, absolute path; a relative path is
+// taken relative to the current directory. The second retval will be
+// false if the key is not found, the value is empty or the current
+// directory is unknown.
+func (opts *Options) GetPath(nm string) (string, bool) {
+	v, ok := opts.Get(nm)
+	if !ok || len(v) == 0 {
+		return "", false
+	}
+
+	p, err := filepath.Abs(v)
+	if err != nil {
+		return "", false
+	}
+	return p, true
+}
+
+// Return the path of option 'nm' like GetPath() with the symbolic
+// links resolved. The second retval will also be false if the path
+// doesn't exist.
+func (opts *Options) GetRealPath(nm string) (string, bool) {
+	p, ok := opts.GetPath(nm)
+	if !ok {
+		return "", false
+	}
+
+	p, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return "", false
+	}
+	return p, true
+}
+
+// Return the path of option 'nm' like GetPath() if it names an existing
+// file that isn't a directory. The second retval will be false
+// otherwise.
+func (opts *Options) GetExistingFile(nm string) (string, bool) {
+	p, ok := opts.GetPath(nm)
+	if !ok || checkPath("file", p) != nil {
+		return "", false
+	}
+	return p, true
+}
+
+// Return the path of option 'nm' like GetPath() if it names an existing
+// directory. The second retval will be false otherwise.
+func (opts *Options) GetExistingDir(nm string) (string, bool) {
+	p, ok := opts.GetPath(nm)
+	if !ok || checkPath("dir", p) != nil {
+		return "", false
+	}
+	return p, true
+}
+
+// Report whether 'typ' is one of the path value types
+func isPathType(typ string) bool {
+	switch typ {
+	case "path", "file", "dir", "dir!":
+		return true
+	}
+	return false
+}
+
+// Verify that 'p' is what the value type 'typ' asks for: an existing
+// file ("file") or directory ("dir"), or a directory that is created
+// if it doesn't exist ("dir!").
+func checkPath(typ, p string) error {
+	if typ == "dir!" {
+		return os.MkdirAll(p, 0755)
+	}
+
+	fi, err := os.Stat(p)
+	switch {
+	case err != nil:
+		return err
+	case typ == "dir" && !fi.IsDir():
+		return fmt.Errorf("%s is not a directory", p)
+	case typ == "file" && fi.IsDir():
+		return fmt.Errorf("%s is a directory", p)
+	}
+	return nil
+}
+
+// Verify the values (or the defaults) of the options of the "file",
+// "dir" and "dir!" types once all the options are read, so that a value
+// that is overridden isn't checked (or created).
+func (spec *Spec) checkPaths(opts *Options) error {
+	for _, o := range spec.optlist {
+		if o.vtype != "file" && o.vtype != "dir" && o.vtype != "dir!" {
+			continue
+		}
+
+		vals := opts.optionv[o.name]
+		if len(vals) == 0 {
+			if v, ok := opts.Get(o.name); ok {
+				vals = []string{v}
+			}
+		}
+
+		for _, v := range vals {
+			if len(v) == 0 {
+				continue
+			}
+			if err := checkPath(o.vtype, v); err != nil {
+				err = spec.optError(MsgBadPath, o.name, o.name, o.name, err)
+				locate(err, opts.Source(o.name), opts.SourceIndex(o.name))
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
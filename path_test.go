@@ -0,0 +1,130 @@
+package options
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetPath(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=     -r,--root=                  Data root
+    link=     -l,--link=                  Link to the root
+    out=.     -o,--out=                   Output dir
+    none=     --none=                     Nothing
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err = os.Symlink(dir, link); err != nil {
+		t.Skip(err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-r", dir + "/a/../b/", "-l", link, "--none="}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p, ok := opts.GetPath("root"); !ok || p != filepath.Join(dir, "b") {
+		t.Errorf("root: bad path %q", p)
+	}
+	if p, ok := opts.GetPath("link"); !ok || p != link {
+		t.Errorf("link: bad path %q", p)
+	}
+	if p, ok := opts.GetRealPath("link"); !ok || p != dir {
+		t.Errorf("link: bad real path %q", p)
+	}
+	if _, ok := opts.GetRealPath("root"); ok {
+		t.Error("root: expected no real path for a missing directory")
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := opts.GetPath("out"); !ok || p != cwd {
+		t.Errorf("out: expected %q, saw %q", cwd, p)
+	}
+	if _, ok := opts.GetPath("none"); ok {
+		t.Error("none: expected no path for an empty value")
+	}
+	if _, ok := opts.GetPath("nope"); ok {
+		t.Error("expected no path for an unknown option")
+	}
+}
+
+func TestPathTypes(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    conf=:file     -c,--conf=FILE     Config file
+    root=:dir      -r,--root=DIR      Data root
+    cache=:dir!    --cache=DIR        Cache dir
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "tool.conf")
+	if err = os.WriteFile(conf, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	cache := filepath.Join(dir, "cache", "tool")
+
+	opts, err := spec.Interpret([]string{"tool", "-c", conf, "-r", "$TMP", "--cache=" + cache}, []string{"TMP=" + dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := opts.GetExistingFile("conf"); !ok || p != conf {
+		t.Errorf("conf: saw %q %v", p, ok)
+	}
+	if p, ok := opts.GetExistingDir("root"); !ok || p != dir {
+		t.Errorf("root: saw %q %v", p, ok)
+	}
+	if fi, err := os.Stat(cache); err != nil || !fi.IsDir() {
+		t.Errorf("cache dir not created: %v", err)
+	}
+	if _, ok := opts.GetExistingFile("root"); ok {
+		t.Errorf("a directory is not a file")
+	}
+	if _, ok := opts.GetExistingDir("conf"); ok {
+		t.Errorf("a file is not a directory")
+	}
+
+	bad := [][]string{
+		{"-c", dir},
+		{"-c", filepath.Join(dir, "nope")},
+		{"-r", conf},
+		{"--cache", filepath.Join(conf, "x")},
+	}
+	for _, args := range bad {
+		_, err := spec.Interpret(append([]string{"tool"}, args...), nil)
+		var e *Error
+		if !errors.As(err, &e) || e.Key != MsgBadPath || !errors.Is(err, ErrBadValue) || e.Source != SourceCommandLine || e.Index != 1 {
+			t.Errorf("%q: expected a bad path, saw %#v", args, err)
+		}
+	}
+
+	// every value of a repeated option is checked
+	if _, err = spec.Interpret([]string{"tool", "-r", conf, "-r", dir}, nil); err == nil {
+		t.Errorf("expected an error for the first of two values")
+	}
+}
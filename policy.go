@@ -0,0 +1,72 @@
+// policy.go - Exit codes and error output
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"errors"
+)
+
+// How MustInterpret(), Run(), PrintUsageWithError() and
+// PrintUsageAndExit() report failures. The zero value is the default:
+// the error and the usage go to STDERR and every failure exits with 1.
+type ExitPolicy struct {
+	// exit code of a usage error: an unknown option, command or
+	// argument, a missing value, and any error not covered below;
+	// 0 means 1
+	Usage int
+
+	// exit code when a required option or command is missing
+	// (ErrMissingRequired); 0 means Usage
+	MissingRequired int
+
+	// exit code of an invalid option value (ErrBadValue); 0 means
+	// Usage
+	BadValue int
+
+	// exit code after the built-in help or version (see SetAutoHelp())
+	Help int
+
+	// exit code when a command handler fails (see Run()); 0 means 1
+	Failure int
+
+	// print nothing on failure; the program explains the error itself
+	// (e.g. through the function set with SetExit())
+	Quiet bool
+
+	// print the error without the usage
+	NoUsage bool
+}
+
+// The exit codes of sysexits(3): EX_USAGE (64) for usage errors and
+// EX_DATAERR (65) for invalid values
+var Sysexits = ExitPolicy{Usage: 64, BadValue: 65}
+
+// Set the exit codes and the error output of the Must* and Print*Exit
+// functions, e.g. SetExitPolicy(options.Sysexits).
+func (spec *Spec) SetExitPolicy(p ExitPolicy) {
+	spec.exit_policy = p
+}
+
+// Return the exit code for 'err'; a nil 'err' is a usage error
+func (p ExitPolicy) code(err error) int {
+	usage := p.Usage
+	if usage == 0 {
+		usage = 1
+	}
+
+	switch {
+	case errors.Is(err, ErrMissingRequired) && p.MissingRequired != 0:
+		return p.MissingRequired
+	case errors.Is(err, ErrBadValue) && p.BadValue != 0:
+		return p.BadValue
+	}
+	return usage
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,68 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExitPolicy(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    !root=       -r,--root=           Root dir
+    level=1      -l,--level=          Level {1,2,3}
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+	spec.SetAutoHelp(true)
+
+	var out, errs strings.Builder
+	var codes []int
+	spec.SetOutput(&out, &errs)
+	spec.SetExit(func(code int) { codes = append(codes, code) })
+	spec.SetExitPolicy(Sysexits)
+
+	spec.MustInterpret([]string{"tool", "--bogus"}, []string{})
+	spec.MustInterpret([]string{"tool"}, []string{})
+	spec.MustInterpret([]string{"tool", "-r", "/", "-l", "9"}, []string{})
+	spec.MustInterpret([]string{"tool", "--help"}, []string{})
+	spec.PrintUsageAndExit()
+
+	want := []int{64, 64, 65, 0, 64}
+	if len(codes) != len(want) {
+		t.Fatalf("expected %v, saw %v", want, codes)
+	}
+	for i := range want {
+		if codes[i] != want[i] {
+			t.Errorf("%d: expected %d, saw %d", i, want[i], codes[i])
+		}
+	}
+	if strings.Count(errs.String(), "usage: tool") != 3 {
+		t.Errorf("bad error output:\n%s", errs.String())
+	}
+
+	errs.Reset()
+	codes = nil
+	spec.SetExitPolicy(ExitPolicy{MissingRequired: 3, Help: 2, NoUsage: true})
+	spec.MustInterpret([]string{"tool"}, []string{})
+	spec.MustInterpret([]string{"tool", "-h"}, []string{})
+	spec.MustInterpret([]string{"tool", "-r", "/", "-l", "9"}, []string{})
+	if len(codes) != 3 || codes[0] != 3 || codes[1] != 2 || codes[2] != 1 {
+		t.Errorf("bad exit codes: %v", codes)
+	}
+	if strings.Contains(errs.String(), "usage:") || strings.Count(errs.String(), "error: ") != 2 {
+		t.Errorf("bad error output:\n%s", errs.String())
+	}
+
+	errs.Reset()
+	spec.SetExitPolicy(ExitPolicy{Quiet: true})
+	spec.MustInterpret([]string{"tool"}, []string{})
+	if errs.Len() > 0 {
+		t.Errorf("unexpected error output:\n%s", errs.String())
+	}
+}
@@ -0,0 +1,53 @@
+// pool.go - Reuse of parsed options
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"context"
+)
+
+// Interpret 'args' and 'environ' like Interpret() but store the result
+// in 'opts', reusing its maps and slices instead of allocating new
+// ones. Servers that parse many command strings can keep a few Options
+// around (e.g. in a sync.Pool) and interpret into them. Any values
+// previously obtained from opts (e.g. slices returned by GetMulti() or
+// Order()) must not be used after this call.
+func (spec *Spec) InterpretInto(opts *Options, args []string, environ []string) error {
+	_, err := spec.interpret(context.Background(), opts, args, environ, nil, nil)
+	return err
+}
+
+// Clear opts for reuse; the allocated storage is retained.
+func (opts *Options) Reset() {
+	if opts.options == nil {
+		opts.options = make(map[string]string)
+		opts.optionv = make(map[string][]string)
+		opts.sources = make(map[string]Source)
+	} else {
+		clear(opts.options)
+		clear(opts.optionv)
+		clear(opts.sources)
+	}
+
+	opts.defaults = nil
+	opts.Command = ""
+	opts.Args = nil
+	opts.Sub = nil
+	opts.CommandPath = nil
+	opts.Unknown = nil
+	opts.ArgGroups = nil
+	opts.named = nil
+	opts.numfmt = nil
+	opts.order = opts.order[:0]
+	opts.spec = nil
+	opts.warnings = opts.warnings[:0]
+	opts.forget()
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
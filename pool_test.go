@@ -0,0 +1,47 @@
+package options
+
+import (
+	"testing"
+)
+
+func TestInterpretInto(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=/x   -r,--root=                  Data root
+    count=    -n,--count=                 Count
+    --
+    --
+    run       run                         Run it
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := new(Options)
+	if err = spec.InterpretInto(opts, []string{"tool", "-n", "3", "-r", "/y", "run", "a"}, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := opts.GetInt("count"); n != 3 || opts.Command != "run" {
+		t.Errorf("bad options: %d %s", n, opts.Command)
+	}
+
+	if err = spec.InterpretInto(opts, []string{"tool", "-n", "4"}, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := opts.GetInt("count"); n != 4 {
+		t.Errorf("stale memoized value: %d", n)
+	}
+	if v, _ := opts.Get("root"); v != "/x" || len(opts.Command) > 0 || len(opts.Args) > 0 || len(opts.Order()) != 1 {
+		t.Errorf("stale options: %s %s %v %v", v, opts.Command, opts.Args, opts.Order())
+	}
+
+	args := []string{"tool", "-n", "5"}
+	allocs := testing.AllocsPerRun(100, func() {
+		spec.InterpretInto(opts, args, nil)
+	})
+	if allocs > 4 {
+		t.Errorf("too many allocations: %v", allocs)
+	}
+}
@@ -0,0 +1,138 @@
+// preset.go - Options that expand to other options
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Parse a "[preset --flag,-f] --opt=value ..." line; the preset flags
+// are replaced by the options that follow when they are given on the
+// command line. The usage lists the preset like an option, described
+// by its expansion.
+func (spec *Spec) parsePreset(line string) error {
+	i := strings.IndexByte(line, ']')
+	if i < 0 {
+		return fmt.Errorf("Invalid preset spec: %s", line)
+	}
+
+	names := strings.Split(strings.TrimSpace(line[len("[preset "):i]), ",")
+	words, err := SplitPOSIX(line[i+1:])
+	if err != nil {
+		return fmt.Errorf("Invalid preset spec: %s: %s", line, err)
+	}
+	if len(words) == 0 {
+		return fmt.Errorf("Invalid preset spec: %s: empty expansion", line)
+	}
+
+	if spec.presets == nil {
+		spec.presets = make(map[string][]string)
+	}
+
+	for _, nm := range names {
+		if !strings.HasPrefix(nm, "-") || len(nm) < 2 {
+			return fmt.Errorf("Invalid preset spec: %s: %s is not a flag", line, nm)
+		}
+		spec.presets[nm] = words
+	}
+	spec.presetlist = append(spec.presetlist, names)
+	return nil
+}
+
+// Return the description of the preset with spellings 'names' in the
+// usage, e.g. "Same as --jobs=8 --cache=on"
+func (spec *Spec) presetHelp(names []string) string {
+	words := spec.presets[names[0]]
+	w := make([]string, len(words))
+	for i, s := range words {
+		w[i] = specWord(s)
+	}
+	return spec.text(MsgPresetHelp, strings.Join(w, " "))
+}
+
+// Return the usage line of the i'th preset with its description at
+// column 'col' (if it fits)
+func (spec *Spec) presetUsage(i, col int) string {
+	names := spec.presetlist[i]
+	head := "  " + strings.Join(names, ",")
+	if displayWidth(head)+2 > col {
+		col = displayWidth(head) + 2
+	}
+	return padRight(head, col) + spec.presetHelp(names)
+}
+
+// Verify that the presets don't clash with the options and only
+// expand to declared options
+func (spec *Spec) checkPresets() error {
+	for nm, words := range spec.presets {
+		if _, ok := spec.options[nm]; ok {
+			return fmt.Errorf("Invalid preset spec: %s is already an option", nm)
+		}
+
+		if err := spec.checkPresetWords(nm, words); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify that the expansion 'words' of preset 'nm' is made of options
+// as Interpret() reads them: "--opt=value", "--opt value", "--no-flag"
+// and clusters of short flags.
+func (spec *Spec) checkPresetWords(nm string, words []string) error {
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if c, ok := spec.splitCluster(w); ok {
+			if err := spec.checkPresetWords(nm, c); err != nil {
+				return err
+			}
+			continue
+		}
+
+		spelling, _, hasval := strings.Cut(w, "=")
+		opt, _, ok := spec.findOption(spelling)
+		switch {
+		case !ok:
+			return fmt.Errorf("Invalid preset spec: %s: %s is not a known option", nm, w)
+		case spec.flags[opt]:
+			if hasval {
+				return fmt.Errorf("Invalid preset spec: %s: %s doesn't take a value", nm, w)
+			}
+		case hasval || len(spec.optinfo[opt].implicit) > 0:
+		case i+1 < len(words):
+			i++
+		default:
+			return fmt.Errorf("Invalid preset spec: %s: %s needs a value", nm, w)
+		}
+	}
+	return nil
+}
+
+// Return a copy of args with the preset args[i] replaced by its
+// expansion 'words'
+func expandPreset(args []string, i int, words []string) []string {
+	rv := make([]string, 0, len(args)+len(words)-1)
+	rv = append(rv, args[:i]...)
+	rv = append(rv, words...)
+	return append(rv, args[i+1:]...)
+}
+
+// Return a copy of pos, the origins of the words of args, with that of
+// args[i] repeated for each of the 'n' words of its expansion
+func expandPos(pos []int, i, n int) []int {
+	rv := make([]int, 0, len(pos)+n-1)
+	rv = append(rv, pos[:i]...)
+	for range n {
+		rv = append(rv, pos[i])
+	}
+	return append(rv, pos[i+1:]...)
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
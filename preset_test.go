@@ -0,0 +1,82 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    jobs=1    -j,--jobs=                  Parallel jobs
+    cache=off --cache=                    Cache mode
+    sync      --sync                      Sync writes
+    [preset --fast,-F] --jobs=8 --cache=on
+    --
+    --
+    run       run                         Run it
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(spec.Usage(), "--fast,-F                   Same as --jobs=8 --cache=on") {
+		t.Errorf("preset missing from usage:\n%s", spec.Usage())
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-F", "--jobs", "4", "run", "--fast"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := opts.GetMulti("jobs"); len(v) != 2 || v[0] != "8" || v[1] != "4" {
+		t.Errorf("bad jobs: %v", v)
+	}
+	if v, _ := opts.Get("cache"); v != "on" {
+		t.Errorf("bad cache: %s", v)
+	}
+	if len(opts.Args) != 2 || opts.Args[1] != "--fast" {
+		t.Errorf("preset after the command was expanded: %v", opts.Args)
+	}
+
+	// presets take the same forms as the command line
+	spec, err = Parse(`
+    usage: tool [options]
+    --
+    jobs=1    -j,--jobs=                  Parallel jobs
+    sync=true:bool --sync                 Sync writes
+    verbose   -v                          Verbose
+    quiet     -q                          Quiet
+    [preset --quick,-Q] --jobs 2 --no-sync -vq
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err = spec.Interpret([]string{"tool", "-Q"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("jobs"); v != "2" || opts.GetBool("sync") || !opts.GetBool("verbose") || !opts.GetBool("quiet") {
+		t.Errorf("bad preset expansion: jobs %s sync %v", v, opts.GetBool("sync"))
+	}
+
+	bad := []string{
+		"[preset --fast] --nope=1",
+		"[preset fast] --jobs=8",
+		"[preset --jobs] --cache=on",
+		"[preset --fast]",
+		"[preset --fast] --jobs",
+		"[preset --fast] --no-jobs",
+	}
+	for _, p := range bad {
+		_, err := Parse("usage: tool\n--\njobs=1 -j,--jobs= Jobs\ncache= --cache= Cache\n" + p + "\n--\n--\n--\n")
+		if err == nil {
+			t.Errorf("%s: expected an error", p)
+		}
+	}
+}
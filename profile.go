@@ -0,0 +1,154 @@
+// profile.go - Named default profiles
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Set the name of the option that selects a defaults profile; it is
+// "profile" unless changed. The option is declared like any other and
+// can be given on the command line, via its environment variables or
+// have a default of its own:
+//
+//	profile=dev  --profile=,MYTOOL_PROFILE  Select a defaults profile
+//	[profile dev]  root=/tmp/data workers=1
+//	[profile prod] root=/srv/data workers=16
+//
+// The values of the selected profile replace the spec defaults (and
+// any defaults passed to InterpretWithDefaults()); values given on the
+// command line or in the environment still take precedence.
+func (spec *Spec) SetProfileOption(nm string) {
+	spec.profile_opt = nm
+}
+
+// Return the names of the profiles declared in the spec
+func (spec *Spec) Profiles() []string {
+	var rv []string
+	for nm := range spec.profiles {
+		rv = append(rv, nm)
+	}
+	return sortedStrings(rv)
+}
+
+// Parse a "[profile NAME] opt=value ..." line
+func (spec *Spec) parseProfile(line string) error {
+	i := strings.IndexByte(line, ']')
+	if i < 0 {
+		return fmt.Errorf("Invalid profile spec: %s", line)
+	}
+
+	name := strings.TrimSpace(line[len("[profile "):i])
+	if len(name) == 0 {
+		return fmt.Errorf("Invalid profile spec: %s", line)
+	}
+
+	words, err := SplitPOSIX(line[i+1:])
+	if err != nil {
+		return fmt.Errorf("Invalid profile spec: %s: %s", line, err)
+	}
+
+	if spec.profiles == nil {
+		spec.profiles = make(map[string]map[string]string)
+	}
+
+	p, ok := spec.profiles[name]
+	if !ok {
+		p = make(map[string]string)
+		spec.profiles[name] = p
+	}
+
+	for _, w := range words {
+		kv := strings.SplitN(w, "=", 2)
+		if len(kv) != 2 || len(kv[0]) == 0 {
+			return fmt.Errorf("Invalid profile spec: %s: %s is not of the form opt=value", line, w)
+		}
+		p[kv[0]] = kv[1]
+	}
+	return nil
+}
+
+// Verify that the profiles only name declared options and that their
+// values are valid for those options, as the spec defaults are
+func (spec *Spec) checkProfiles() error {
+	for _, name := range sortedKeys(spec.profiles) {
+		p := spec.profiles[name]
+		for _, k := range sortedKeys(p) {
+			if _, ok := spec.flags[k]; !ok {
+				return fmt.Errorf("Invalid profile spec: %s: %s is not a known option", name, k)
+			}
+			if err := spec.checkProfileValue(k, p[k]); err != nil {
+				return fmt.Errorf("Invalid profile spec: %s: %s", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Verify that 'v' is a valid value of option 'nm' for a profile
+func (spec *Spec) checkProfileValue(nm, v string) error {
+	o := spec.optinfo[nm]
+	if o == nil || len(v) == 0 {
+		return nil
+	}
+
+	if spec.flags[nm] {
+		if _, ok := parseBool(v); !ok {
+			return fmt.Errorf("%s=%s is not a valid bool", nm, v)
+		}
+	}
+	if len(o.vtype) > 0 && spec.validType(o.vtype, v) != nil {
+		return fmt.Errorf("%s=%s is not a valid %s", nm, v, o.vtype)
+	}
+	if len(o.choices) > 0 && !slices.Contains(o.choices, v) {
+		return fmt.Errorf("%s=%s is not one of %s", nm, v, orList(o.choices))
+	}
+	if o.constraint != nil && !o.constraint.match(v) {
+		return fmt.Errorf("%s=%s does not satisfy {%s}", nm, v, o.constraint.text)
+	}
+	return nil
+}
+
+// Overlay the defaults of the profile selected in 'opts'
+func (spec *Spec) applyProfile(opts *Options) error {
+	if len(spec.profiles) == 0 {
+		return nil
+	}
+
+	name, ok := opts.Get(spec.profile_opt)
+	if !ok || len(name) == 0 {
+		return nil
+	}
+
+	p, ok := spec.profiles[name]
+	if !ok {
+		return spec.optError(MsgUnknownProfile, spec.profile_opt, name, name, strings.Join(spec.Profiles(), ", "))
+	}
+
+	defs := make(map[string]string, len(opts.defaults)+len(p))
+	for k, v := range opts.defaults {
+		defs[k] = v
+	}
+	for k, v := range p {
+		defs[k] = v
+	}
+	opts.defaults = defs
+	spec.debug("profile applied", "profile", name)
+	return nil
+}
+
+func sortedStrings(v []string) []string {
+	sort.Strings(v)
+	return v
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
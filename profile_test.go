@@ -0,0 +1,122 @@
+package options
+
+import (
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    profile=  --profile=,TOOL_PROFILE     Select a defaults profile
+    root=/var -r,--root=                  Data root
+    workers=4 -w,--workers=               Number of workers
+    [profile dev]  root=/tmp workers=1
+    [profile prod] root="/srv/data"
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p := spec.Profiles(); len(p) != 2 || p[0] != "dev" {
+		t.Errorf("bad profiles: %q", p)
+	}
+
+	tests := []struct {
+		args    []string
+		env     []string
+		root    string
+		workers int64
+	}{
+		{[]string{"tool"}, nil, "/var", 4},
+		{[]string{"tool", "--profile=dev"}, nil, "/tmp", 1},
+		{[]string{"tool", "-w", "9"}, []string{"TOOL_PROFILE=dev"}, "/tmp", 9},
+		{[]string{"tool", "--profile", "prod"}, nil, "/srv/data", 4},
+	}
+
+	for i, tc := range tests {
+		opts, err := spec.Interpret(tc.args, tc.env)
+		if err != nil {
+			t.Fatalf("%d: %s", i, err)
+		}
+
+		if v, _ := opts.Get("root"); v != tc.root {
+			t.Errorf("%d: expected root %s, saw %s", i, tc.root, v)
+		}
+		if v, _ := opts.GetInt("workers"); v != tc.workers {
+			t.Errorf("%d: expected workers %d, saw %d", i, tc.workers, v)
+		}
+	}
+
+	if _, err := spec.Interpret([]string{"tool", "--profile=qa"}, nil); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+
+	_, err = Parse(`
+    usage: tool [options]
+    --
+    root=/var -r,--root=                  Data root
+    [profile dev]  bogus=1
+    --
+    `)
+	if err == nil {
+		t.Error("expected error for a profile with an unknown option")
+	}
+}
+
+func TestProfileValues(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    profile=   --profile=                 Select a defaults profile
+    verbose    -v,--verbose               Show more info
+    workers=4  -w,--workers=              Number of workers
+    [profile ci] verbose=true workers=8
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "--profile=ci"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.GetBool("verbose") {
+		t.Error("verbose not set by the profile")
+	}
+	if v, _ := opts.GetInt("workers"); v != 8 {
+		t.Errorf("expected 8 workers, saw %d", v)
+	}
+
+	bad := []string{
+		"[profile ci] verbose=sure",
+		"[profile ci] zone=Nowhere",
+		"[profile ci] format=xml",
+		"[profile ci] port=99999",
+		"[requires port=99999] verbose",
+	}
+	for _, p := range bad {
+		_, err := Parse(`
+    usage: tool [options]
+    --
+    profile=   --profile=                 Select a defaults profile
+    verbose    -v,--verbose               Show more info
+    zone=:tz   -z,--zone=                 Time zone
+    format=    -f,--format=               Output format {text,json}
+    port={1..1000} -p,--port=             Port
+    ` + p + `
+    --
+    --
+    --
+    `)
+		if err == nil {
+			t.Errorf("%s: expected an error", p)
+		}
+	}
+}
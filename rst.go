@@ -0,0 +1,134 @@
+// rst.go - reStructuredText documentation generator
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Generate a reStructuredText page for the spec, e.g. for a Sphinx
+// site: the same content as Markdown() with the tables written as
+// "list-table" directives.
+func (spec *Spec) ReST() string {
+	var b strings.Builder
+
+	rstHeading(&b, spec.title(), '=')
+	if len(spec.about) > 0 {
+		b.WriteString("::\n\n")
+		for _, l := range spec.about {
+			fmt.Fprintf(&b, "    %s\n", l)
+		}
+		b.WriteString("\n")
+	}
+	spec.rstOptions(&b, "", '-')
+
+	if len(spec.cmdlist) > 0 {
+		rstHeading(&b, "Commands", '-')
+		rows := [][]string{{"Command", "Aliases", "Description"}}
+		for _, c := range spec.cmdlist {
+			rows = append(rows, []string{rstLiteral(c.name), rstLiteralList(c.aliases), c.help})
+		}
+		rstTable(&b, rows)
+
+		for _, c := range spec.cmdlist {
+			var sub strings.Builder
+			spec.rstOptions(&sub, c.name, '^')
+			if sub.Len() > 0 {
+				rstHeading(&b, c.name, '~')
+				b.WriteString(sub.String())
+			}
+		}
+	}
+
+	if len(spec.appendix) > 0 {
+		for _, l := range spec.appendix {
+			fmt.Fprintf(&b, "%s\n", l)
+		}
+		b.WriteString("\n")
+	}
+	for _, m := range spec.meta {
+		fmt.Fprintf(&b, ":%s: %s\n", metaTitle(m.key), m.value)
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// Write the options and environment sections as tables under headings
+// underlined with 'u'. An empty 'cmd' selects the global options,
+// otherwise the options scoped to 'cmd'.
+func (spec *Spec) rstOptions(b *strings.Builder, cmd string, u byte) {
+	opts := [][]string{{"Option", "Environment", "Default", "Description"}}
+	envs := [][]string{{"Variable", "Default", "Description"}}
+
+	for _, o := range spec.optlist {
+		if !o.scopedTo(cmd) {
+			continue
+		}
+
+		help := o.help
+		if spec.required[o.name] {
+			help = strings.TrimSpace(help + " (required)")
+		}
+		def := rstLiteral(spec.redact(o.name, spec.defaults[o.name]))
+
+		if o.isenv {
+			envs = append(envs, []string{rstLiteralList(o.env), def, help})
+		} else {
+			opts = append(opts, []string{rstLiteralList(o.flagTexts()), rstLiteralList(o.env), def, help})
+		}
+	}
+
+	if len(opts) > 1 {
+		rstHeading(b, "Options", u)
+		rstTable(b, opts)
+	}
+	if len(envs) > 1 {
+		rstHeading(b, "Environment", u)
+		rstTable(b, envs)
+	}
+}
+
+// Write the section title 't' underlined with 'u'
+func rstHeading(b *strings.Builder, t string, u byte) {
+	fmt.Fprintf(b, "%s\n%s\n\n", t, strings.Repeat(string(u), displayWidth(t)))
+}
+
+// Write 'rows' as a list-table; the first row is the header. The
+// paragraphs of a cell are indented to stay inside it.
+func rstTable(b *strings.Builder, rows [][]string) {
+	b.WriteString(".. list-table::\n   :header-rows: 1\n\n")
+	for _, r := range rows {
+		for i, cell := range r {
+			lead := "     - "
+			if i == 0 {
+				lead = "   * - "
+			}
+			cell = strings.ReplaceAll(cell, "\n\n", "\n\n       ")
+			fmt.Fprintf(b, "%s\n", strings.TrimRight(lead+cell, " "))
+		}
+	}
+	b.WriteString("\n")
+}
+
+func rstLiteral(s string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return "``" + s + "``"
+}
+
+func rstLiteralList(v []string) string {
+	w := make([]string, 0, len(v))
+	for _, s := range v {
+		w = append(w, rstLiteral(s))
+	}
+	return strings.Join(w, ", ")
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
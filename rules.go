@@ -0,0 +1,404 @@
+// rules.go - Dependencies between options
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Parse a "[requires OPT] OPT...", "[conflicts OPT] OPT..." or
+// "[oneof GROUP] OPT..." line. The options are named by their names or
+// any of their spellings and are resolved by checkRules() once all of
+// them are declared.
+func (spec *Spec) parseRule(line string) error {
+	i := strings.IndexByte(line, ']')
+	if i < 0 {
+		return fmt.Errorf("Invalid rule spec: %s", line)
+	}
+
+	head := strings.Fields(line[1:i])
+	others := strings.Fields(line[i+1:])
+	if len(head) != 2 || len(others) == 0 {
+		return fmt.Errorf("Invalid rule spec: %s", line)
+	}
+
+	if spec.rules == nil {
+		spec.rules = make(map[string]map[string][]string)
+	}
+	r, ok := spec.rules[head[0]]
+	if !ok {
+		r = make(map[string][]string)
+		spec.rules[head[0]] = r
+	}
+	r[head[1]] = append(r[head[1]], others...)
+	return nil
+}
+
+// Declare that option 'nm' requires each of 'others' when it is given,
+// like a "[requires nm] others..." line in the spec. Options are named
+// by their names or any of their spellings. The rules are checked as
+// when the spec is parsed; on error the spec is left unchanged.
+func (spec *Spec) Requires(nm string, others ...string) error {
+	return spec.addRules("requires", map[string][]string{nm: others})
+}
+
+// Declare that at most one of the options 'names' can be given, e.g.
+// spec.Exclusive("json", "yaml", "text"). Options are named by their
+// names or any of their spellings. The rules are checked as when the
+// spec is parsed; on error the spec is left unchanged.
+func (spec *Spec) Exclusive(names ...string) error {
+	if len(names) < 2 {
+		return fmt.Errorf("Invalid rule: Exclusive needs at least two options")
+	}
+
+	add := make(map[string][]string)
+	for i, nm := range names {
+		add[nm] = append(add[nm], names[i+1:]...)
+	}
+	return spec.addRules("conflicts", add)
+}
+
+// Declare that option 'nm' requires each of 'others' when it is given
+// with a particular value: 'cond' is "OPT=VALUE", as in
+// spec.RequireIf("format=json", "schema"), or just the option, which
+// is the same as Requires(). A flag's value is "true" or "false".
+func (spec *Spec) RequireIf(cond string, others ...string) error {
+	return spec.addRules("requires", map[string][]string{cond: others})
+}
+
+// Declare that exactly one of the options 'names' must be given, e.g.
+// spec.RequireOneOf("file", "stdin", "url"), like a "[oneof GROUP]
+// names..." line in the spec. The rules are checked as when the spec
+// is parsed; on error the spec is left unchanged.
+func (spec *Spec) RequireOneOf(names ...string) error {
+	if len(names) < 2 {
+		return fmt.Errorf("Invalid rule: RequireOneOf needs at least two options")
+	}
+	return spec.addRules("oneof", map[string][]string{strings.Join(names, "/"): names})
+}
+
+// Add the rules 'add' of 'kind' and resolve them
+func (spec *Spec) addRules(kind string, add map[string][]string) error {
+	if spec.rules == nil {
+		spec.rules = make(map[string]map[string][]string)
+	}
+	r, ok := spec.rules[kind]
+	if !ok {
+		r = make(map[string][]string)
+		spec.rules[kind] = r
+	}
+
+	saved := make(map[string][]string, len(r))
+	for k, v := range r {
+		saved[k] = v
+	}
+	for k, v := range add {
+		if len(v) == 0 && kind == "requires" {
+			return fmt.Errorf("Invalid rule: %s requires no options", k)
+		}
+		if kind == "oneof" && len(r[k]) > 0 {
+			return fmt.Errorf("Invalid rule: duplicate group %s", k)
+		}
+		r[k] = append(r[k][:len(r[k]):len(r[k])], v...)
+	}
+
+	if err := spec.checkRules(); err != nil {
+		spec.rules[kind] = saved
+		spec.checkRules()
+		return err
+	}
+	return nil
+}
+
+// A requirement that applies when option 'name' is given 'value'
+type condRule struct {
+	name   string
+	value  string
+	others []string
+}
+
+// A group of options of which exactly one must be given
+type oneofGroup struct {
+	label string
+	names []string
+}
+
+// Resolve the option names in the requires, conflicts and oneof rules
+// and verify that they form a consistent graph: the requirements have
+// no cycles and no option requires (directly or indirectly) two
+// options that conflict with each other or belong to the same group.
+func (spec *Spec) checkRules() error {
+	if len(spec.rules) == 0 {
+		return nil
+	}
+
+	resolve := func(kind string, r map[string][]string) (map[string][]string, error) {
+		m := make(map[string][]string)
+		for k, v := range r {
+			a := spec.lookupOpt(k)
+			if a == nil {
+				return nil, fmt.Errorf("Invalid rule spec: [%s %s]: %s is not a known option", kind, k, k)
+			}
+			for _, nm := range v {
+				b := spec.lookupOpt(nm)
+				if b == nil {
+					return nil, fmt.Errorf("Invalid rule spec: [%s %s]: %s is not a known option", kind, k, nm)
+				}
+				if a == b {
+					return nil, fmt.Errorf("Invalid rule spec: [%s %s]: %s names itself", kind, k, nm)
+				}
+				m[a.name] = append(m[a.name], b.name)
+			}
+		}
+		return m, nil
+	}
+
+	// the requirements conditional on a value are kept apart
+	plain := make(map[string][]string)
+	spec.requiresIf = nil
+	for _, k := range sortedKeys(spec.rules["requires"]) {
+		v := spec.rules["requires"][k]
+		nm, val, ok := strings.Cut(k, "=")
+		if !ok {
+			plain[k] = v
+			continue
+		}
+
+		m, err := resolve("requires", map[string][]string{nm: v})
+		if err != nil {
+			return err
+		}
+		o := spec.lookupOpt(nm)
+		if err = spec.checkProfileValue(o.name, val); err != nil {
+			return fmt.Errorf("Invalid rule spec: [requires %s]: %s", k, err)
+		}
+		spec.requiresIf = append(spec.requiresIf, condRule{o.name, val, m[o.name]})
+	}
+
+	var err error
+	if spec.requires, err = resolve("requires", plain); err != nil {
+		return err
+	}
+	cf, err := resolve("conflicts", spec.rules["conflicts"])
+	if err != nil {
+		return err
+	}
+	// conflicts are symmetric
+	spec.conflicts = make(map[string]map[string]bool)
+	for a, v := range cf {
+		for _, b := range v {
+			spec.addConflict(a, b)
+			spec.addConflict(b, a)
+		}
+	}
+
+	spec.oneof = nil
+	for _, k := range sortedKeys(spec.rules["oneof"]) {
+		g := oneofGroup{label: k}
+		for _, nm := range spec.rules["oneof"][k] {
+			o := spec.lookupOpt(nm)
+			if o == nil {
+				return fmt.Errorf("Invalid rule spec: [oneof %s]: %s is not a known option", k, nm)
+			}
+			if slices.Contains(g.names, o.name) {
+				return fmt.Errorf("Invalid rule spec: [oneof %s]: %s is named twice", k, nm)
+			}
+			g.names = append(g.names, o.name)
+		}
+		if len(g.names) < 2 {
+			return fmt.Errorf("Invalid rule spec: [oneof %s]: a group needs at least two options", k)
+		}
+		spec.oneof = append(spec.oneof, g)
+	}
+
+	// detect requirement cycles
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var visit func(nm string, path []string) error
+	visit = func(nm string, path []string) error {
+		switch state[nm] {
+		case visiting:
+			return fmt.Errorf("Invalid rule spec: requirement cycle %s", strings.Join(append(path, nm), " -> "))
+		case done:
+			return nil
+		}
+
+		state[nm] = visiting
+		for _, r := range spec.requires[nm] {
+			if err := visit(r, append(path, nm)); err != nil {
+				return err
+			}
+		}
+		state[nm] = done
+		return nil
+	}
+
+	for _, o := range spec.optlist {
+		if err := visit(o.name, nil); err != nil {
+			return err
+		}
+	}
+
+	// an option can't pull in options that conflict
+	for _, o := range spec.optlist {
+		deps := spec.requiredBy(o.name)
+		for _, a := range deps {
+			for _, b := range deps {
+				if spec.conflicts[a][b] {
+					return fmt.Errorf("Invalid rule spec: %s requires both %s and %s, which conflict", o.name, a, b)
+				}
+				if g := spec.groupOf(a, b); g != nil {
+					return fmt.Errorf("Invalid rule spec: %s requires both %s and %s, which are in group %s", o.name, a, b, g.label)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (spec *Spec) addConflict(a, b string) {
+	m, ok := spec.conflicts[a]
+	if !ok {
+		m = make(map[string]bool)
+		spec.conflicts[a] = m
+	}
+	m[b] = true
+}
+
+// Return 'nm' and all the options it requires, directly or indirectly
+func (spec *Spec) requiredBy(nm string) []string {
+	seen := map[string]bool{nm: true}
+	rv := []string{nm}
+	for i := 0; i < len(rv); i++ {
+		for _, r := range spec.requires[rv[i]] {
+			if !seen[r] {
+				seen[r] = true
+				rv = append(rv, r)
+			}
+		}
+	}
+	return rv
+}
+
+// Return the oneof group that has both options 'a' and 'b', if any
+func (spec *Spec) groupOf(a, b string) *oneofGroup {
+	if a == b {
+		return nil
+	}
+	for i := range spec.oneof {
+		g := &spec.oneof[i]
+		if slices.Contains(g.names, a) && slices.Contains(g.names, b) {
+			return g
+		}
+	}
+	return nil
+}
+
+// Verify that the options given in 'opts' satisfy the rules
+func (spec *Spec) checkDeps(opts *Options) error {
+	for _, o := range spec.optlist {
+		if !spec.given(opts, o.name) {
+			continue
+		}
+
+		for _, r := range spec.requires[o.name] {
+			if !spec.given(opts, r) {
+				return spec.optError(MsgRequiresOption, o.name, "", spec.optDisplay(o.name), spec.optDisplay(r))
+			}
+		}
+		for _, c := range spec.conflictsOf(o.name) {
+			if spec.given(opts, c) {
+				return spec.optError(MsgConflictsOption, o.name, "", spec.optDisplay(o.name), spec.optDisplay(c))
+			}
+		}
+	}
+
+	for _, c := range spec.requiresIf {
+		if !opts.IsSet(c.name) || !spec.condMatches(opts, c) {
+			continue
+		}
+		for _, r := range c.others {
+			if !spec.given(opts, r) {
+				return spec.optError(MsgRequiresOption, c.name, "", spec.optDisplay(c.name)+"="+c.value, spec.optDisplay(r))
+			}
+		}
+	}
+
+	for _, g := range spec.oneof {
+		var given []string
+		for _, nm := range g.names {
+			if spec.given(opts, nm) {
+				given = append(given, nm)
+			}
+		}
+
+		group := make([]string, len(g.names))
+		for i, nm := range g.names {
+			group[i] = spec.optDisplay(nm)
+		}
+		switch {
+		case len(given) == 0:
+			return spec.optError(MsgMissingOneOf, g.names[0], "", orList(group))
+		case len(given) > 1:
+			return spec.optError(MsgTooManyOneOf, given[1], "", spec.optDisplay(given[0]), spec.optDisplay(given[1]), orList(group))
+		}
+	}
+	return nil
+}
+
+// Return true if option 'nm' counts as given in 'opts' for the rules:
+// it is set and, for a flag, not turned off (e.g. with "--no-x")
+func (spec *Spec) given(opts *Options, nm string) bool {
+	if !opts.IsSet(nm) {
+		return false
+	}
+	return !spec.flags[nm] || opts.GetBool(nm)
+}
+
+// Return true if the value of option 'c.name' in 'opts' is 'c.value'
+func (spec *Spec) condMatches(opts *Options, c condRule) bool {
+	if spec.flags[c.name] {
+		b, _ := parseBool(c.value)
+		return opts.GetBool(c.name) == b
+	}
+	return slices.Contains(opts.GetMulti(c.name), c.value)
+}
+
+// Return the options that conflict with 'nm' in declaration order
+func (spec *Spec) conflictsOf(nm string) []string {
+	var rv []string
+	if m := spec.conflicts[nm]; len(m) > 0 {
+		for _, o := range spec.optlist {
+			if m[o.name] {
+				rv = append(rv, o.name)
+			}
+		}
+	}
+	return rv
+}
+
+// Return the name of option 'nm' as the user would type it
+func (spec *Spec) optDisplay(nm string) string {
+	if o := spec.optinfo[nm]; o != nil {
+		if f := o.canonicalFlag(); len(f) > 0 {
+			return f
+		}
+		if len(o.env) > 0 {
+			return o.env[0]
+		}
+	}
+	return nm
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
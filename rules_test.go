@@ -0,0 +1,245 @@
+package options
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    tls       --tls                       Use TLS
+    cert=     --cert=                     Certificate
+    key=      --key=                      Key
+    quiet     -q,--quiet                  Quiet
+    verbose   -v,--verbose                Verbose
+    debug     --debug                     Debug
+    [requires tls] cert --key
+    [requires debug] verbose
+    [conflicts quiet] verbose
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"--tls --cert=a --key=b": "",
+		"--cert=a":               "",
+		"--tls --cert=a":         "Invalid option: --tls requires --key",
+		"-q":                     "",
+		"-v -q":                  "Invalid option: --quiet can't be used with --verbose",
+
+		// a flag turned off doesn't count as given
+		"-q --no-verbose":       "",
+		"--no-quiet -v":         "",
+		"--no-tls --cert=a":     "",
+		"--debug --no-verbose":  "Invalid option: --debug requires --verbose",
+		"--debug -v --no-quiet": "",
+	}
+	for args, want := range tests {
+		_, err := spec.Interpret(append([]string{"tool"}, strings.Fields(args)...), []string{})
+		switch {
+		case len(want) == 0 && err != nil:
+			t.Errorf("%s: unexpected error: %s", args, err)
+		case len(want) > 0 && (err == nil || err.Error() != want):
+			t.Errorf("%s: expected %q, saw %v", args, want, err)
+		}
+	}
+}
+
+func TestBadRules(t *testing.T) {
+	bad := map[string]string{
+		"[requires a] nope": "nope is not a known option",
+		"[requires a] a":    "names itself",
+		"[requires a] b\n[requires b] c\n[requires c] a":  "requirement cycle",
+		"[requires a] b c\n[conflicts b] c":               "requires both",
+		"[requires a] b\n[requires b] c\n[conflicts a] c": "requires both",
+		"[requires a]": "Invalid rule spec",
+	}
+
+	for rules, want := range bad {
+		_, err := Parse("usage: tool\n--\na -a A\nb -b B\nc -c C\n" + rules + "\n--\n--\n--\n")
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected %q, saw %v", rules, want, err)
+		}
+	}
+}
+
+func TestRulesAPI(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    json      --json                      JSON output
+    yaml      --yaml                      YAML output
+    text      --text                      Text output
+    tls-key=  --tls-key=                  TLS key
+    tls-cert= --tls-cert=                 TLS certificate
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = spec.Exclusive("json", "--yaml", "text"); err != nil {
+		t.Fatal(err)
+	}
+	if err = spec.Requires("tls-key", "tls-cert"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"--json":                          "",
+		"--yaml --text":                   "Invalid option: --yaml can't be used with --text",
+		"--text --json":                   "Invalid option: --json can't be used with --text",
+		"--tls-key=k --tls-cert=c":        "",
+		"--tls-cert=c":                    "",
+		"--tls-key=k":                     "Invalid option: --tls-key requires --tls-cert",
+		"--tls-key=k --tls-cert=c --yaml": "",
+	}
+	for args, want := range tests {
+		_, err := spec.Interpret(append([]string{"tool"}, strings.Fields(args)...), []string{})
+		switch {
+		case len(want) == 0 && err != nil:
+			t.Errorf("%s: unexpected error: %s", args, err)
+		case len(want) > 0 && (err == nil || err.Error() != want):
+			t.Errorf("%s: expected %q, saw %v", args, want, err)
+		}
+	}
+
+	// rejected rules leave the spec as it was
+	if err = spec.Requires("tls-cert", "tls-key"); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected a cycle error, saw %v", err)
+	}
+	if err = spec.Requires("json", "nope"); err == nil {
+		t.Error("expected an unknown option error")
+	}
+	if err = spec.Exclusive("json"); err == nil {
+		t.Error("expected an error for a single option")
+	}
+	if _, err = spec.Interpret([]string{"tool", "--tls-cert=c", "--json"}, []string{}); err != nil {
+		t.Errorf("rejected rules were kept: %s", err)
+	}
+}
+
+func TestOneOfRules(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    file=     -f,--file=                  Read from a file
+    stdin     --stdin                     Read from stdin
+    url=      --url=                      Read from a URL
+    format=   --format=[json,text]        Output format
+    schema=   --schema=                   JSON schema
+    tls       --tls                       Use TLS
+    cert=     --cert=                     Certificate
+    [oneof input] file stdin --url
+    [requires format=json] schema
+    [requires tls=false] url
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"-f a":                             "",
+		"--stdin --format=text":            "",
+		"--stdin --format=json --schema=s": "",
+		"":                                 "Missing option: exactly one of --file, --stdin or --url is required",
+		"--stdin --url=u":                  "Invalid option: --stdin can't be used with --url (exactly one of --file, --stdin or --url is allowed)",
+		"--stdin --format=json":            "Invalid option: --format=json requires --schema",
+		"--stdin --no-tls":                 "Invalid option: --tls=false requires --url",
+		"--url=u --no-tls":                 "",
+		"--no-stdin -f a":                  "",
+		"--no-stdin":                       "Missing option: exactly one of --file, --stdin or --url is required",
+	}
+	for args, want := range tests {
+		_, err := spec.Interpret(append([]string{"tool"}, strings.Fields(args)...), []string{})
+		switch {
+		case len(want) == 0 && err != nil:
+			t.Errorf("%s: unexpected error: %s", args, err)
+		case len(want) > 0 && (err == nil || err.Error() != want):
+			t.Errorf("%s: expected %q, saw %v", args, want, err)
+		}
+	}
+
+	_, err = spec.Interpret([]string{"tool"}, []string{})
+	if !errors.Is(err, ErrMissingRequired) {
+		t.Errorf("expected ErrMissingRequired, saw %v", err)
+	}
+
+	bad := map[string]string{
+		"[oneof g] a":                     "at least two options",
+		"[oneof g] a -a":                  "named twice",
+		"[oneof g] a nope":                "nope is not a known option",
+		"[oneof g] b c\n[requires a] b c": "in group g",
+		"[requires a=x] b":                "not a valid bool",
+		"[requires nope=x] b":             "nope is not a known option",
+	}
+	for rules, want := range bad {
+		_, err := Parse("usage: tool\n--\na -a A\nb -b B\nc -c C\n" + rules + "\n--\n--\n--\n")
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected %q, saw %v", rules, want, err)
+		}
+	}
+}
+
+func TestOneOfAPI(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    file=     --file=                     Read from a file
+    stdin     --stdin                     Read from stdin
+    format=   --format=                   Output format
+    schema=   --schema=                   JSON schema
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = spec.RequireOneOf("file", "--stdin"); err != nil {
+		t.Fatal(err)
+	}
+	if err = spec.RequireIf("format=json", "schema"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"--stdin":                           "",
+		"--file=a --format=yaml":            "",
+		"--file=a --format=json":            "Invalid option: --format=json requires --schema",
+		"--format=json --schema=s":          "Missing option: exactly one of --file or --stdin is required",
+		"--file=a --format=json --schema=s": "",
+	}
+	for args, want := range tests {
+		_, err := spec.Interpret(append([]string{"tool"}, strings.Fields(args)...), []string{})
+		switch {
+		case len(want) == 0 && err != nil:
+			t.Errorf("%s: unexpected error: %s", args, err)
+		case len(want) > 0 && (err == nil || err.Error() != want):
+			t.Errorf("%s: expected %q, saw %v", args, want, err)
+		}
+	}
+
+	if err = spec.RequireOneOf("file"); err == nil {
+		t.Error("expected an error for a single option")
+	}
+	if err = spec.RequireOneOf("file", "nope"); err == nil {
+		t.Error("expected an unknown option error")
+	}
+	if _, err = spec.Interpret([]string{"tool", "--stdin"}, []string{}); err != nil {
+		t.Errorf("rejected rules were kept: %s", err)
+	}
+}
@@ -0,0 +1,97 @@
+// run.go - Command handlers
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Make Run() call 'fn' with the parsed options when the command line
+// has command 'cmd'; an empty 'cmd' registers the handler for a command
+// line without a command. A handler on a sub-spec (see
+// SetCommandSpec()) handles the commands of that level and takes
+// precedence; every handler gets the options of the top level spec,
+// with the sub-spec's options in opts.Sub.
+func (spec *Spec) Handle(cmd string, fn func(opts *Options) error) error {
+	if len(cmd) > 0 && !spec.hasCommand(cmd) {
+		return fmt.Errorf("Unknown command: %s", cmd)
+	}
+
+	if spec.handlers == nil {
+		spec.handlers = make(map[string]func(*Options) error)
+	}
+	spec.handlers[cmd] = fn
+	return nil
+}
+
+// Parse 'args' and 'environ' like MustInterpret() and call the handler
+// of the command (see Handle()), which replaces a switch on
+// opts.Command:
+//
+//	spec.Handle("exec", runExec)
+//	spec.Handle("shell", runShell)
+//	spec.Run(os.Args, os.Environ())
+//
+// A parse error is reported like MustInterpret() does. A command
+// without a handler prints the usage and exits with the usage code. If
+// the handler fails, its error is printed to STDERR and the program
+// exits with the Failure code of the ExitPolicy; a handler that
+// returns an *ExitError exits with its code and prints nothing. If
+// the exit function set with SetExit() returns, the error is returned.
+func (spec *Spec) Run(args []string, environ []string) error {
+	opts, err := spec.mustInterpret(args, environ)
+	if opts == nil {
+		return err
+	}
+
+	fn := spec.handler(opts)
+	if fn == nil {
+		err = fmt.Errorf("Missing handler: %s", opts.Command)
+		spec.PrintUsageAndExit()
+		return err
+	}
+
+	if err = fn(opts); err != nil {
+		spec.fail(err)
+	}
+	return err
+}
+
+// Return the handler for the command in 'opts' at the deepest level
+// of sub-specs that has one
+func (spec *Spec) handler(opts *Options) func(*Options) error {
+	if sub := spec.subspecs[opts.Command]; sub != nil && opts.Sub != nil {
+		if fn := sub.handler(opts.Sub); fn != nil {
+			return fn
+		}
+	}
+	return spec.handlers[opts.Command]
+}
+
+// Report the failure 'err' of a command handler and exit
+func (spec *Spec) fail(err error) {
+	var x *ExitError
+	if errors.As(err, &x) {
+		spec.terminate(x.Code)
+		return
+	}
+
+	if !spec.exit_policy.Quiet {
+		fmt.Fprintf(spec.errw(), "error: %s\n", err)
+	}
+
+	code := spec.exit_policy.Failure
+	if code == 0 {
+		code = 1
+	}
+	spec.terminate(code)
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
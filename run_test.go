@@ -0,0 +1,113 @@
+package options
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] command
+    --
+    verbose      -v,--verbose         Verbose
+    --
+    --
+    exec         x,exec               Run a program
+    remote       remote               Manage remotes
+    shell        sh,shell             Open a shell
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := spec.SetCommandSpecText("remote", `
+    usage: remote [options] command
+    --
+    --
+    --
+    add          add                  Add a remote
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errs strings.Builder
+	code := -1
+	spec.SetOutput(&out, &errs)
+	spec.SetExit(func(c int) { code = c })
+	spec.SetExitPolicy(ExitPolicy{Failure: 3, NoUsage: true})
+
+	var ran []string
+	handle := func(nm string, err error) func(*Options) error {
+		return func(opts *Options) error {
+			if opts.GetBool("verbose") {
+				nm += " -v"
+			}
+			ran = append(ran, nm)
+			return err
+		}
+	}
+	if err := spec.Handle("exec", handle("exec", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.Handle("", handle("none", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.Handle("remote", handle("remote", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.Handle("add", handle("remote add", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.Handle("bogus", handle("bogus", nil)); err == nil {
+		t.Error("expected an error for an unknown command")
+	}
+
+	for _, args := range [][]string{
+		{"tool", "-v", "x"},
+		{"tool"},
+		{"tool", "remote", "add"},
+		{"tool", "remote"},
+	} {
+		if err := spec.Run(args, []string{}); err != nil {
+			t.Errorf("%q: %s", args, err)
+		}
+	}
+	want := []string{"exec -v", "none", "remote add", "remote"}
+	if strings.Join(ran, ",") != strings.Join(want, ",") {
+		t.Errorf("expected handlers %q, saw %q", want, ran)
+	}
+	if code != -1 {
+		t.Errorf("unexpected exit %d", code)
+	}
+
+	// a failing handler
+	boom := errors.New("boom")
+	spec.Handle("exec", handle("exec", boom))
+	if err := spec.Run([]string{"tool", "exec"}, []string{}); err != boom {
+		t.Errorf("expected boom, saw %v", err)
+	}
+	if code != 3 || errs.String() != "error: boom\n" {
+		t.Errorf("bad failure: exit %d, stderr %q", code, errs.String())
+	}
+
+	// a handler that picks the exit code
+	errs.Reset()
+	spec.Handle("exec", handle("exec", &ExitError{Code: 7}))
+	spec.Run([]string{"tool", "exec"}, []string{})
+	if code != 7 || errs.Len() != 0 {
+		t.Errorf("bad exit: %d, stderr %q", code, errs.String())
+	}
+
+	// a parse error and a command without a handler
+	code = -1
+	if err := spec.Run([]string{"tool", "-x"}, []string{}); err == nil || code != 1 {
+		t.Errorf("parse error: %v, exit %d", err, code)
+	}
+	code = -1
+	if err := spec.Run([]string{"tool", "shell"}, []string{}); err == nil || code != 1 {
+		t.Errorf("no handler: %v, exit %d", err, code)
+	}
+}
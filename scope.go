@@ -0,0 +1,71 @@
+// scope.go - Command scoped options
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Verify that scoped options only name declared commands
+func (spec *Spec) checkScopes() error {
+	for _, o := range spec.optlist {
+		for _, c := range o.cmds {
+			if !spec.hasCommand(c) {
+				return fmt.Errorf("Invalid option spec: %s is scoped to unknown command %s", o.name, c)
+			}
+		}
+	}
+	return nil
+}
+
+// A command scoped option seen on the command line
+type scopedArg struct {
+	name string // the canonical name of the option
+	arg  string // the argument as given
+}
+
+// Verify that the scoped options in 'args' are valid with the command
+// in 'opts'
+func (spec *Spec) checkScoped(opts *Options, args []scopedArg) error {
+	for _, a := range args {
+		o := spec.optinfo[a.name]
+		if !o.scopedTo(opts.Command) || len(opts.Command) == 0 {
+			return spec.optError(MsgScopedOption, o.name, a.arg, a.arg, strings.Join(o.cmds, " or "))
+		}
+	}
+	return nil
+}
+
+// Return true if the option belongs with command 'cmd'; an empty 'cmd'
+// matches the unscoped options.
+func (o *optspec) scopedTo(cmd string) bool {
+	if len(cmd) == 0 {
+		return len(o.cmds) == 0
+	}
+
+	for _, c := range o.cmds {
+		if c == cmd {
+			return true
+		}
+	}
+	return false
+}
+
+// Return true if 'nm' is the canonical name of a command
+func (spec *Spec) hasCommand(nm string) bool {
+	for _, c := range spec.cmdlist {
+		if c.name == nm {
+			return true
+		}
+	}
+	return false
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
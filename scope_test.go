@@ -0,0 +1,93 @@
+package options
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestScopedOptions(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command>
+    --
+    verbose       -v,--verbose            Show more info
+    force@delete  -f,--force              Delete without asking
+    mode@delete,purge=soft --mode=        Deletion mode
+    --
+    --
+    delete    delete,rm                   Delete things
+    purge     purge                       Purge things
+    list      ls,list                     List things
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usage := spec.Usage()
+	i, j, k := strings.Index(usage, "Delete things"), strings.Index(usage, "--force"), strings.Index(usage, "Purge things")
+	if !(i < j && j < k) {
+		t.Errorf("scoped option not listed under its command:\n%s", usage)
+	}
+	if strings.Count(usage, "--mode=") != 2 {
+		t.Errorf("expected --mode under both commands:\n%s", usage)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-f", "rm", "x"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.GetBool("force") {
+		t.Error("expected force")
+	}
+	if v, _ := opts.Get("mode"); v != "soft" {
+		t.Errorf("expected default mode soft, saw %q", v)
+	}
+
+	if _, err := spec.Interpret([]string{"tool", "--mode=hard", "purge"}, []string{}); err != nil {
+		t.Error(err)
+	}
+
+	_, err = spec.Interpret([]string{"tool", "-f", "list"}, []string{})
+	if err == nil || !strings.Contains(err.Error(), "delete") {
+		t.Errorf("expected scope error, saw %v", err)
+	}
+
+	if _, err = spec.Interpret([]string{"tool", "-f"}, []string{}); err == nil {
+		t.Error("expected scope error without a command")
+	}
+
+	// the scope is checked whatever spelling matched the option
+	spec.SetIgnoreCase(true)
+	spec.SetOptionPrefixes(true)
+	for _, arg := range []string{"--no-force", "--FORCE", "--forc"} {
+		if _, err = spec.Interpret([]string{"tool", arg, "delete"}, []string{}); err != nil {
+			t.Errorf("%s: %v", arg, err)
+		}
+		_, err = spec.Interpret([]string{"tool", arg, "list"}, []string{})
+		var e *Error
+		if !errors.As(err, &e) || e.Key != MsgScopedOption || e.Option != "force" {
+			t.Errorf("%s: expected scope error, saw %v", arg, err)
+		}
+	}
+	spec.SetIgnoreCase(false)
+	spec.SetOptionPrefixes(false)
+
+	pages := spec.MarkdownPages()
+	if !strings.Contains(pages["tool_delete.md"], "`--force`") || strings.Contains(pages["tool.md"], "`--force`") {
+		t.Errorf("scoped options belong on the command page:\n%s", pages["tool.md"])
+	}
+
+	_, err = Parse(`
+    usage: tool [options] <command>
+    --
+    force@nuke  -f,--force                Delete without asking
+    --
+    --
+    delete    delete                      Delete things
+    --
+    `)
+	if err == nil {
+		t.Error("expected error for an unknown scope")
+	}
+}
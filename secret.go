@@ -0,0 +1,115 @@
+// secret.go - Redacting secret option values
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"strings"
+)
+
+// What a secret value is shown as
+const redactedValue = "<redacted>"
+
+// Return the value of every option that has one (as returned by Get())
+// by canonical name, with the values of secret options replaced by
+// "<redacted>"; the result is safe to log. An option is secret if its
+// name is prefixed with '^' in the spec, as in "^token=", or if it has
+// the "secret" value type.
+func (opts *Options) Redacted() map[string]string {
+	rv := make(map[string]string, len(opts.options)+len(opts.defaults))
+	for k, v := range opts.defaults {
+		rv[k] = v
+	}
+	for k, v := range opts.options {
+		rv[k] = v
+	}
+	for k, v := range rv {
+		rv[k] = opts.spec.redact(k, v)
+	}
+	return rv
+}
+
+// Return true if the values of option 'nm' must not be shown
+func (spec *Spec) isSecret(nm string) bool {
+	if spec == nil {
+		return false
+	}
+	o := spec.optinfo[nm]
+	return o != nil && (o.secret || o.vtype == "secret")
+}
+
+// Return 'value' of option 'nm' fit for display
+func (spec *Spec) redact(nm, value string) string {
+	if len(value) > 0 && spec.isSecret(nm) {
+		return redactedValue
+	}
+	return value
+}
+
+// Return the argument 'arg' that supplied option 'nm' fit for display:
+// e.g. "--token=<redacted>" for "--token=abc".
+func (spec *Spec) redactArg(nm, arg string) string {
+	if i := strings.IndexByte(arg, '='); i >= 0 && spec.isSecret(nm) {
+		return arg[:i+1] + redactedValue
+	}
+	return arg
+}
+
+// Return the effective values of option 'nm' fit for display
+func (opts *Options) redactedValues(nm string) []string {
+	v := opts.GetMulti(nm)
+	if len(v) == 0 {
+		if d, ok := opts.defaults[nm]; ok {
+			v = []string{d}
+		}
+	}
+	if !opts.spec.isSecret(nm) {
+		return v
+	}
+
+	w := make([]string, len(v))
+	for i, s := range v {
+		w[i] = opts.spec.redact(nm, s)
+	}
+	return w
+}
+
+// Return the command line words 'words' fit for display: the values of
+// secret options, attached ("--token=X", "-tX") or in the next word, are
+// redacted.
+func (spec *Spec) redactWords(words []string) []string {
+	rv := make([]string, len(words))
+	copy(rv, words)
+	for i, w := range rv {
+		if !strings.HasPrefix(w, "-") {
+			continue
+		}
+
+		// only the last option of a cluster of short flags can take a
+		// value: "-vtX" is "-v -t=X"
+		c, cluster := spec.splitCluster(w)
+		if cluster {
+			w = c[len(c)-1]
+		}
+
+		spelling, value, hasval := strings.Cut(w, "=")
+		nm := spec.options[spec.foldOption(spelling)]
+		switch {
+		case !spec.isSecret(nm):
+		case hasval && cluster:
+			rv[i] = rv[i][:len(rv[i])-len(value)] + redactedValue
+		case hasval:
+			rv[i] = spec.redactArg(nm, w)
+		case i+1 < len(rv) && !spec.flags[nm]:
+			rv[i+1] = redactedValue
+		}
+	}
+	return rv
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
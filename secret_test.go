@@ -0,0 +1,65 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedacted(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    ^token=s3cret  -t,--token=TOKEN    API token {s3cret,hunter2}
+    key=:secret    --key=              Signing key
+    user=admin     -u,--user=          User name
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	opts, err := spec.Interpret([]string{"tool", "--key=abc", "-u", "me"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"token": redactedValue, "key": redactedValue, "user": "me"}
+	got := opts.Redacted()
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, saw %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: expected %q, saw %q", k, v, got[k])
+		}
+	}
+	if v, _ := opts.Get("token"); v != "s3cret" {
+		t.Errorf("token: expected the real value, saw %q", v)
+	}
+
+	_, err = spec.Interpret([]string{"tool", "--token=letmein"}, []string{})
+	if err == nil || strings.Contains(err.Error(), "letmein") {
+		t.Errorf("secret leaked: %v", err)
+	}
+	if e, ok := err.(*Error); !ok || e.Token != "--token="+redactedValue {
+		t.Errorf("bad token: %#v", err)
+	}
+
+	help, err := spec.OptionHelp("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{spec.FormatUsage(), spec.Markdown(), help} {
+		if strings.Contains(s, "(default: s3cret)") || strings.Contains(s, "`s3cret`") ||
+			strings.Contains(s, "Default:     s3cret") {
+			t.Errorf("secret default leaked: %s", s)
+		}
+	}
+
+	if !strings.Contains(spec.String(), "^token=s3cret") {
+		t.Errorf("bad spec text: %s", spec.String())
+	}
+}
@@ -0,0 +1,112 @@
+// set.go - Modify parsed options
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Return the options of the spec with 'values' (by option name) set as
+// if by Set() on top of the defaults, without interpreting a command
+// line or the environment. This makes Options fixtures for tests and
+// for code that computes its settings; Command and Args can be filled
+// in directly. Required options aren't checked.
+func (spec *Spec) NewOptions(values map[string]string) (*Options, error) {
+	opts := new(Options)
+	opts.Reset()
+	opts.defaults = spec.defaults
+	opts.Args = []string{}
+	opts.numfmt = spec.numberFormat(nil)
+	opts.spec = spec
+
+	for _, k := range sortedKeys(values) {
+		if err := opts.Set(k, values[k]); err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// Set option 'nm' to 'val', replacing any values it had. The option
+// must be declared in the spec that produced opts and a flag only
+// accepts boolean values (true/false, yes/no, on/off, 1/0). This lets
+// interactive tools adjust options after the initial parse.
+func (opts *Options) Set(nm, val string) error {
+	if opts.spec != nil {
+		val = opts.spec.normalize(nm, val)
+	}
+
+	if err := opts.checkSet(nm, val); err != nil {
+		return err
+	}
+
+	if opts.options == nil {
+		opts.options = make(map[string]string)
+		opts.optionv = make(map[string][]string)
+	}
+
+	opts.options[nm] = val
+	opts.optionv[nm] = []string{val}
+	delete(opts.sources, nm)
+	opts.forget()
+	return nil
+}
+
+// Remove the value of option 'nm' so that it reverts to its default
+// (if any).
+func (opts *Options) Unset(nm string) error {
+	if err := opts.checkSet(nm, ""); err != nil {
+		return err
+	}
+
+	delete(opts.options, nm)
+	delete(opts.optionv, nm)
+	delete(opts.sources, nm)
+	opts.forget()
+	return nil
+}
+
+// Verify that 'nm' is a known option that accepts 'val'; an empty
+// 'val' is always accepted.
+func (opts *Options) checkSet(nm, val string) error {
+	if opts.spec == nil {
+		return fmt.Errorf("Unknown option: %s", nm)
+	}
+
+	isflag, ok := opts.spec.flags[nm]
+	if !ok {
+		return fmt.Errorf("Unknown option: %s", nm)
+	}
+
+	if isflag && len(val) > 0 {
+		if _, ok := parseBool(val); !ok {
+			return fmt.Errorf("Invalid value for %s: %s is not a boolean", nm, val)
+		}
+	}
+
+	if len(val) > 0 {
+		return opts.spec.checkType(nm, nm, val)
+	}
+	return nil
+}
+
+// Parse a boolean value; the second retval is false if 's' isn't one
+// of the recognized spellings.
+func parseBool(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "true", "ok", "1", "yes", "on":
+		return true, true
+	case "false", "0", "no", "off":
+		return false, true
+	}
+	return false, false
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,93 @@
+package options
+
+import (
+	"testing"
+)
+
+func TestSetUnset(t *testing.T) {
+	spec, err := Parse(`
+    usage: repl [options]
+    --
+    num=2     -n=                         Number of things
+    verbose   -v,--verbose                Show more info
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"repl", "-n", "5"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := opts.GetInt("num"); v != 5 {
+		t.Fatalf("expected 5, saw %d", v)
+	}
+
+	if err := opts.Set("num", "7"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.GetInt("num"); v != 7 {
+		t.Errorf("expected 7 after Set, saw %d", v)
+	}
+	if v := opts.GetMulti("num"); len(v) != 1 {
+		t.Errorf("Set must replace all values: %q", v)
+	}
+
+	if err := opts.Unset("num"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.GetInt("num"); v != 2 || opts.IsSet("num") {
+		t.Errorf("expected the default after Unset, saw %d", v)
+	}
+
+	if err := opts.Set("verbose", "yes"); err != nil || !opts.GetBool("verbose") {
+		t.Errorf("expected verbose: %v", err)
+	}
+	if err := opts.Set("verbose", "maybe"); err == nil {
+		t.Error("expected error for a non-boolean flag value")
+	}
+	if err := opts.Set("bogus", "1"); err == nil {
+		t.Error("expected error for an unknown option")
+	}
+	if err := opts.Unset("bogus"); err == nil {
+		t.Error("expected error for an unknown option")
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	spec, err := Parse(`
+    usage: repl [options]
+    --
+    num=2     -n=                         Number of things
+    !root=    -r,--root=                  Data root
+    verbose   -v,--verbose                Show more info
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.NewOptions(map[string]string{"verbose": "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.GetInt("num"); v != 2 || !opts.GetBool("verbose") || opts.IsSet("root") {
+		t.Errorf("bad fixture: %d %v", v, opts.GetBool("verbose"))
+	}
+	if err := opts.Set("root", "/tmp"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := opts.Get("root"); v != "/tmp" {
+		t.Errorf("expected /tmp, saw %q", v)
+	}
+
+	if _, err := spec.NewOptions(map[string]string{"bogus": "1"}); err == nil {
+		t.Error("expected error for an unknown option")
+	}
+}
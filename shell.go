@@ -0,0 +1,136 @@
+// shell.go - Shell output of parsed options
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Passing true makes a successful Interpret() set the environment
+// variables bound to the options that were given in the process
+// environment (except under js/wasm, which has no process
+// environment). This is process global state, so it is off by default
+// and best left off by programs that interpret several command lines;
+// use Options.Setenv() to export the variables later or WriteExports()
+// to hand them to a shell instead.
+func (spec *Spec) SetSetenv(setenv bool) {
+	spec.setenv = setenv
+}
+
+// Set every environment variable bound to an option that was given
+// to the value of the option in the process environment, so that child
+// processes see it. This is what Interpret() does if SetSetenv(true) is
+// called. The first error is returned.
+func (opts *Options) Setenv() error {
+	if opts.spec == nil {
+		return nil
+	}
+
+	var err error
+	for env, option := range opts.spec.environment {
+		value, present := opts.options[option]
+		if !present || len(env) == 0 {
+			continue
+		}
+		if e := os.Setenv(env, value); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Write an "export VAR='value'" line for every environment variable
+// bound to an option that was given, sorted by variable name. This
+// enables eval "$(mytool env ...)" style wrappers.
+func (opts *Options) WriteExports(w io.Writer) error {
+	if opts.spec == nil {
+		return nil
+	}
+
+	var vars []string
+	for env, option := range opts.spec.environment {
+		if _, ok := opts.options[option]; ok && len(env) > 0 {
+			vars = append(vars, env)
+		}
+	}
+	sort.Strings(vars)
+
+	for _, env := range vars {
+		v := opts.options[opts.spec.environment[env]]
+		if _, err := fmt.Fprintf(w, "export %s=%s\n", env, shellQuote(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write the effective value of every option (including the defaults)
+// as a shell variable assignment, in declaration order, followed by
+// the command (if the spec has commands). The variable names are the
+// option names upper cased with 'prefix' prepended and any character
+// that isn't valid in a shell variable name replaced by '_'; e.g. the
+// option "log-file" with the prefix "TOOL_" becomes TOOL_LOG_FILE.
+// Flags are written as true or false and the values are safely quoted,
+// so that shell wrappers can eval the output instead of re-parsing the
+// command line.
+func (opts *Options) WriteShellVars(w io.Writer, prefix string) error {
+	if opts.spec == nil {
+		return nil
+	}
+
+	put := func(nm, v string) error {
+		_, err := fmt.Fprintf(w, "%s=%s\n", shellName(prefix+nm), shellQuote(v))
+		return err
+	}
+
+	for _, o := range opts.spec.optlist {
+		v, ok := opts.Get(o.name)
+		if opts.spec.flags[o.name] {
+			b, _ := parseBool(v)
+			v = fmt.Sprintf("%v", ok && b)
+		}
+		if err := put(o.name, v); err != nil {
+			return err
+		}
+	}
+
+	if len(opts.spec.cmdlist) > 0 {
+		return put("command", opts.Command)
+	}
+	return nil
+}
+
+// Return 's' as a valid (upper case) shell variable name
+func shellName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+
+	if len(s) == 0 || (s[0] >= '0' && s[0] <= '9') {
+		s = "_" + s
+	}
+	return s
+}
+
+// Quote 's' for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
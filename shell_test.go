@@ -0,0 +1,129 @@
+package options
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteExports(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=     -r,--root=,TOOL_ROOT        Data root
+    name=     -n,--name=,TOOL_NAME        Name
+    debug     -d,--debug,TOOL_DEBUG       Debug
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Unsetenv("TOOL_ROOT")
+	spec.SetSetenv(false)
+
+	opts, err := spec.Interpret([]string{"tool", "-r", "/a b", "-n", "it's"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := os.LookupEnv("TOOL_ROOT"); ok {
+		t.Error("environment was modified")
+	}
+
+	var b strings.Builder
+	if err = opts.WriteExports(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "export TOOL_NAME='it'\\''s'\nexport TOOL_ROOT='/a b'\n"
+	if b.String() != want {
+		t.Errorf("expected\n%s\nsaw\n%s", want, b.String())
+	}
+}
+
+func TestSetenv(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=     -r,--root=,TOOL_SETENV_ROOT   Data root
+    name=     -n,--name=,TOOL_SETENV_NAME   Name
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TOOL_SETENV_ROOT", "")
+	os.Unsetenv("TOOL_SETENV_ROOT")
+	os.Unsetenv("TOOL_SETENV_NAME")
+	spec.SetSetenv(false)
+
+	opts, err := spec.Interpret([]string{"tool", "-r", "/data"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := os.LookupEnv("TOOL_SETENV_ROOT"); ok {
+		t.Fatal("environment was modified")
+	}
+
+	if err = opts.Setenv(); err != nil {
+		t.Fatal(err)
+	}
+	if v := os.Getenv("TOOL_SETENV_ROOT"); v != "/data" {
+		t.Errorf("expected /data, saw %q", v)
+	}
+	if _, ok := os.LookupEnv("TOOL_SETENV_NAME"); ok {
+		t.Error("name wasn't given")
+	}
+
+	spec.SetSetenv(true)
+	if _, err = spec.Interpret([]string{"tool", "-r", "/srv", "-n", "x"}, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	if v := os.Getenv("TOOL_SETENV_NAME"); v != "x" {
+		t.Errorf("expected x, saw %q", v)
+	}
+	os.Unsetenv("TOOL_SETENV_NAME")
+}
+
+func TestWriteShellVars(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    log-file=/tmp/x  -l,--log-file=       Log file
+    name=            -n,--name=           Name
+    debug            -d,--debug           Debug
+    quiet            -q,--quiet           Quiet
+    --
+    --
+    run       run                         Run it
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-n", "a'b", "-d", "run", "x"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err = opts.WriteShellVars(&b, "t_"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `T_LOG_FILE='/tmp/x'
+T_NAME='a'\''b'
+T_DEBUG='true'
+T_QUIET='false'
+T_COMMAND='run'
+`
+	if b.String() != want {
+		t.Errorf("expected\n%s\nsaw\n%s", want, b.String())
+	}
+}
@@ -0,0 +1,68 @@
+// slice.go - List valued options
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Set the separator that GetSlice() splits the values of option 'nm'
+// at; the default is ",". An empty 'sep' doesn't split the values.
+func (spec *Spec) SetSeparator(nm, sep string) error {
+	o := spec.optinfo[nm]
+	if o == nil || spec.flags[nm] {
+		return fmt.Errorf("Unknown option: %s", nm)
+	}
+
+	if spec.separators == nil {
+		spec.separators = make(map[string]string)
+	}
+	spec.separators[nm] = sep
+	return nil
+}
+
+// Return the values of option 'nm' split at its separator (see
+// SetSeparator()) and merged across repeated occurrences, so that
+// "-I a,b -I c" yields [a b c]. Blanks around the items are removed
+// and empty items are dropped. If the option isn't given, its default
+// is split instead; a nil slice implies neither is set.
+func (opts *Options) GetSlice(nm string) []string {
+	vals := opts.optionv[nm]
+	if len(vals) == 0 {
+		v, ok := opts.defaults[nm]
+		if !ok {
+			return nil
+		}
+		vals = []string{v}
+	}
+
+	sep := ","
+	if opts.spec != nil {
+		if s, ok := opts.spec.separators[nm]; ok {
+			sep = s
+		}
+	}
+
+	rv := []string{}
+	for _, v := range vals {
+		items := []string{v}
+		if len(sep) > 0 {
+			items = strings.Split(v, sep)
+		}
+		for _, s := range items {
+			if s = strings.TrimSpace(s); len(s) > 0 {
+				rv = append(rv, s)
+			}
+		}
+	}
+	return rv
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
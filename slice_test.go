@@ -0,0 +1,63 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetSlice(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    include=     -I,--include=             Include dirs
+    path=a:b     -p,--path=                Search path
+    tag=         -t,--tag=                 Tags
+    verbose      -v                        Verbose
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	if err = spec.SetSeparator("path", ":"); err != nil {
+		t.Fatal(err)
+	}
+	if err = spec.SetSeparator("verbose", ":"); err == nil {
+		t.Error("expected an error for a flag")
+	}
+	if err = spec.SetSeparator("nope", ":"); err == nil {
+		t.Error("expected an error for an unknown option")
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-I", "a,b", "-I", " c ,", "--include=d"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"include": "a|b|c|d",
+		"path":    "a|b",
+	}
+	for nm, want := range tests {
+		if v := strings.Join(opts.GetSlice(nm), "|"); v != want {
+			t.Errorf("%s: expected %q, saw %q", nm, want, v)
+		}
+	}
+	if v := opts.GetSlice("tag"); v != nil {
+		t.Errorf("tag: expected nil, saw %q", v)
+	}
+
+	opts, err = spec.Interpret([]string{"tool", "-p", "x:y,z", "--tag="}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := strings.Join(opts.GetSlice("path"), "|"); v != "x|y,z" {
+		t.Errorf("path: expected x|y,z, saw %q", v)
+	}
+	if v := opts.GetSlice("tag"); v == nil || len(v) != 0 {
+		t.Errorf("tag: expected an empty slice, saw %q", v)
+	}
+}
@@ -0,0 +1,41 @@
+// source.go - Where option values came from
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+// Return the layer that supplied the value of option 'nm' returned by
+// Get(): SourceCommandLine, SourceEnv (which includes the flags env
+// var, see SetFlagsEnv()), SourceConfig or SourceDefault. SourceNone
+// implies the option has no value or was changed with Set(). See
+// SourceIndex() for the position of a command line value.
+func (opts *Options) Source(nm string) Source {
+	if _, ok := opts.options[nm]; ok {
+		return opts.sources[nm]
+	}
+	if _, ok := opts.defaults[nm]; ok {
+		return SourceDefault
+	}
+	return SourceNone
+}
+
+// Return the index in the args given to Interpret() of the option that
+// supplied the value of 'nm' returned by Get(); -1 if the value didn't
+// come from the command line.
+func (opts *Options) SourceIndex(nm string) int {
+	if opts.Source(nm) != SourceCommandLine {
+		return -1
+	}
+	for _, o := range opts.order {
+		if o.Name == nm {
+			return o.Index
+		}
+	}
+	return -1
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,72 @@
+package options
+
+import (
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=/x   -r,--root=,TOOL_ROOT      Data root
+    level=    -l,--level=,TOOL_LEVEL    Level
+    tag=      -t,--tag=                 Tags
+    jobs=     -j,--jobs=                Jobs
+    verbose   -v,--verbose              Verbose
+    quiet     -q,--quiet                Quiet
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+	spec.SetFlagsEnv("TOOL_FLAGS")
+
+	env := []string{"TOOL_LEVEL=2", "TOOL_FLAGS=-j 4"}
+	opts, err := spec.Interpret([]string{"tool", "-v", "--tag", "a", "-t", "b"}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		nm    string
+		src   Source
+		index int
+	}{
+		{"root", SourceDefault, -1},
+		{"level", SourceEnv, -1},
+		{"jobs", SourceEnv, -1},
+		{"tag", SourceCommandLine, 2},
+		{"verbose", SourceCommandLine, 1},
+		{"quiet", SourceNone, -1},
+	}
+	for _, tt := range tests {
+		if s := opts.Source(tt.nm); s != tt.src {
+			t.Errorf("%s: expected source %s, saw %s", tt.nm, tt.src, s)
+		}
+		if i := opts.SourceIndex(tt.nm); i != tt.index {
+			t.Errorf("%s: expected index %d, saw %d", tt.nm, tt.index, i)
+		}
+	}
+
+	// the command line replaces the environment
+	opts, err = spec.Interpret([]string{"tool", "-l", "5"}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := opts.Source("level"); s != SourceCommandLine {
+		t.Errorf("expected level from the command line, saw %s", s)
+	}
+
+	if err = opts.Set("level", "7"); err != nil {
+		t.Fatal(err)
+	}
+	if s := opts.Source("level"); s != SourceNone {
+		t.Errorf("expected no source after Set, saw %s", s)
+	}
+	if s := opts.Clone().Source("jobs"); s != SourceEnv {
+		t.Errorf("bad clone source %s", s)
+	}
+}
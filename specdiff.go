@@ -0,0 +1,156 @@
+// specdiff.go - Compare two specs for CLI compatibility
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// A difference between two versions of a spec. 'Kind' describes the
+// change (e.g. "option removed" or "default changed"), 'Name' is the
+// option, flag, env var or command it applies to. 'Breaking' is true
+// if command lines or environments that worked with the old spec may
+// fail or behave differently with the new one.
+type SpecChange struct {
+	Kind     string
+	Name     string
+	Old      string
+	New      string
+	Breaking bool
+}
+
+func (c SpecChange) String() string {
+	s := fmt.Sprintf("%s: %s", c.Kind, c.Name)
+	if len(c.Old) > 0 || len(c.New) > 0 {
+		s += fmt.Sprintf(" (%q -> %q)", c.Old, c.New)
+	}
+	if c.Breaking {
+		s += " [breaking]"
+	}
+	return s
+}
+
+// Compare spec against an older version 'old' and return the options,
+// spellings, env vars, defaults and commands that were added, removed
+// or changed. An option whose name changed but that kept one of its
+// flags or env vars is reported as renamed (Old and New are the
+// names) and compared under its new name. The result is sorted by name
+// and is meant for release checks that catch accidental breaking CLI
+// changes and for changelogs.
+func (spec *Spec) Diff(old *Spec) []SpecChange {
+	var rv []SpecChange
+
+	add := func(kind, name, o, n string, breaking bool) {
+		rv = append(rv, SpecChange{kind, name, o, n, breaking})
+	}
+
+	renamed := make(map[string]bool)
+	for _, o := range old.optlist {
+		n := spec.optinfo[o.name]
+		if n == nil {
+			if n = spec.renamedFrom(old, o); n == nil {
+				add("option removed", o.name, "", "", true)
+				continue
+			}
+			renamed[n.name] = true
+			add("option renamed", o.name, o.name, n.name, false)
+		}
+
+		if old.flags[o.name] != spec.flags[n.name] {
+			add("option type changed", o.name, optType(old, o.name), optType(spec, n.name), true)
+		}
+		if !old.required[o.name] && spec.required[n.name] {
+			add("option now required", o.name, "", "", true)
+		}
+
+		ov, ook := old.defaults[o.name]
+		nv, nok := spec.defaults[n.name]
+		if ook != nok || ov != nv {
+			add("default changed", o.name, ov, nv, false)
+		}
+	}
+
+	for _, o := range spec.optlist {
+		if old.optinfo[o.name] == nil && !renamed[o.name] {
+			add("option added", o.name, "", "", spec.required[o.name])
+		}
+	}
+
+	diffNames := func(what string, a, b map[string]string) {
+		for k, v := range a {
+			if _, ok := b[k]; !ok {
+				add(what+" removed", k, v, "", true)
+			}
+		}
+		for k, v := range b {
+			if _, ok := a[k]; !ok {
+				add(what+" added", k, "", v, false)
+			}
+		}
+	}
+
+	diffNames("flag", old.options, spec.options)
+	diffNames("env var", old.environment, spec.environment)
+	diffNames("command", old.commands, spec.commands)
+
+	if old.allow_unknown_args && !spec.allow_unknown_args {
+		add("unknown args disallowed", "*", "", "", true)
+	}
+
+	sort.SliceStable(rv, func(i, j int) bool {
+		return rv[i].Name < rv[j].Name
+	})
+	return rv
+}
+
+// Return the new option that option 'o' of 'old' was renamed to: one
+// that 'old' doesn't have and that has one of the flags or env vars of
+// 'o'.
+func (spec *Spec) renamedFrom(old *Spec, o *optspec) *optspec {
+	for _, k := range append(slices.Clone(o.flags), o.env...) {
+		nm, ok := spec.options[k]
+		if !ok {
+			nm, ok = spec.environment[k]
+		}
+		if ok && old.optinfo[nm] == nil {
+			return spec.optinfo[nm]
+		}
+	}
+	return nil
+}
+
+// Remember the flags of the older spec 'old' that spec no longer has,
+// so that Interpret() reports their use as removed in 'version' (e.g.
+// "Invalid option: --debug was removed in version 2.0") instead of as
+// unknown options. An empty 'version' is that of the program, see
+// SetVersion().
+func (spec *Spec) SetRemoved(old *Spec, version string) {
+	if len(version) == 0 {
+		version = spec.Version()
+	}
+
+	spec.removed = make(map[string]string)
+	for k := range old.options {
+		if _, ok := spec.options[k]; !ok {
+			spec.removed[k] = version
+		}
+	}
+}
+
+// Return the kind of option 'nm' in 'spec'
+func optType(spec *Spec, nm string) string {
+	if spec.flags[nm] {
+		return "flag"
+	}
+	return "value"
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,141 @@
+package options
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSpecDiff(t *testing.T) {
+	old, err := Parse(`
+    usage: tool [options]
+    --
+    root=/x   -r,--root=                  Root
+    verbose   -v,--verbose                Verbose
+    debug     -d,--debug                  Debug
+    --
+    --
+    exec      e,exec                      Run
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=/y   --root=                     Root
+    !verbose= -v,--verbose=               Verbose
+    quiet     -q,--quiet                  Quiet
+    --
+    --
+    exec      exec                        Run
+    shell     shell                       Shell
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{
+		"option removed debug":        true,
+		"flag removed -d":             true,
+		"flag removed --debug":        true,
+		"flag removed -r":             true,
+		"default changed root":        false,
+		"option type changed verbose": true,
+		"option now required verbose": true,
+		"option added quiet":          false,
+		"flag added -q":               false,
+		"flag added --quiet":          false,
+		"command removed e":           true,
+		"command added shell":         false,
+	}
+
+	changes := spec.Diff(old)
+	for _, c := range changes {
+		k := c.Kind + " " + c.Name
+		b, ok := want[k]
+		if !ok {
+			t.Errorf("unexpected change: %s", c)
+			continue
+		}
+		if b != c.Breaking {
+			t.Errorf("%s: expected breaking=%v", c, b)
+		}
+		delete(want, k)
+	}
+	for k := range want {
+		t.Errorf("missing change: %s", k)
+	}
+
+	if d := spec.Diff(spec); len(d) != 0 {
+		t.Errorf("expected no changes, saw %v", d)
+	}
+}
+
+func TestSpecDiffRenamed(t *testing.T) {
+	old, err := Parse(`
+    usage: tool [options]
+    --
+    out=a     -o,--out=                   Output
+    debug     -d,--debug                  Debug
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old.SetVersion("1.0")
+
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    output=b  -o,--output=                Output
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetVersion("2.0")
+
+	want := []string{
+		`default changed: out ("a" -> "b")`,
+		`option removed: debug [breaking]`,
+		`flag added: --output ("" -> "output")`,
+		`flag removed: --debug ("debug" -> "") [breaking]`,
+		`flag removed: --out ("out" -> "") [breaking]`,
+		`flag removed: -d ("debug" -> "") [breaking]`,
+		`option renamed: out ("out" -> "output")`,
+	}
+	var saw []string
+	for _, c := range spec.Diff(old) {
+		saw = append(saw, c.String())
+	}
+	slices.Sort(want)
+	slices.Sort(saw)
+	if !slices.Equal(saw, want) {
+		t.Errorf("expected\n%s\nsaw\n%s", strings.Join(want, "\n"), strings.Join(saw, "\n"))
+	}
+
+	spec.SetRemoved(old, "")
+	tests := map[string]string{
+		"-o x":      "",
+		"--debug":   "Invalid option: --debug was removed in version 2.0",
+		"--out=x":   "Invalid option: --out was removed in version 2.0",
+		"--nothing": "Invalid option: --nothing was not recognized",
+	}
+	for args, want := range tests {
+		_, err := spec.Interpret(append([]string{"tool"}, strings.Fields(args)...), []string{})
+		switch {
+		case len(want) == 0 && err != nil:
+			t.Errorf("%s: unexpected error: %s", args, err)
+		case len(want) > 0 && (err == nil || err.Error() != want):
+			t.Errorf("%s: expected %q, saw %v", args, want, err)
+		}
+	}
+}
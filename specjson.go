@@ -0,0 +1,128 @@
+// specjson.go - JSON description of a spec
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+//go:build !tinygo
+
+package options
+
+import (
+	"encoding/json"
+)
+
+// The JSON form of an option or environment variable
+type jsonOption struct {
+	Name       string   `json:"name"`
+	Flags      []string `json:"flags,omitempty"`
+	Secondary  []string `json:"secondary,omitempty"`
+	Env        []string `json:"env,omitempty"`
+	Help       string   `json:"help,omitempty"`
+	Flag       bool     `json:"flag"`
+	Required   bool     `json:"required,omitempty"`
+	Default    *string  `json:"default,omitempty"`
+	Implicit   string   `json:"implicit,omitempty"`
+	Metavar    string   `json:"metavar,omitempty"`
+	Type       string   `json:"type,omitempty"`
+	Choices    []string `json:"choices,omitempty"`
+	Constraint string   `json:"constraint,omitempty"`
+	Commands   []string `json:"commands,omitempty"`
+	Group      string   `json:"group,omitempty"`
+	Secret     bool     `json:"secret,omitempty"`
+	MinCount   int      `json:"min_count,omitempty"`
+	MaxCount   int      `json:"max_count,omitempty"`
+}
+
+// The JSON form of a command
+type jsonCommand struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+	Help    string   `json:"help,omitempty"`
+	Default bool     `json:"default,omitempty"`
+}
+
+// The JSON form of a spec
+type jsonSpec struct {
+	Prog            string            `json:"prog,omitempty"`
+	Usage           []string          `json:"usage,omitempty"`
+	Meta            map[string]string `json:"meta,omitempty"`
+	Options         []jsonOption      `json:"options"`
+	Environment     []jsonOption      `json:"environment"`
+	Commands        []jsonCommand     `json:"commands"`
+	CommandRequired bool              `json:"command_required,omitempty"`
+	Args            bool              `json:"args"`
+}
+
+// Describe the command line interface of the spec as JSON: the program
+// name, the usage text, the metadata, and the options, environment
+// variables and commands in declaration order with their spellings,
+// defaults (absent if there is none), whether they are required, and
+// so on. This is meant for tools such as documentation and completion
+// generators written in other languages.
+func (spec *Spec) MarshalJSON() ([]byte, error) {
+	js := jsonSpec{
+		Prog:            spec.prog,
+		Usage:           spec.about,
+		Options:         []jsonOption{},
+		Environment:     []jsonOption{},
+		Commands:        []jsonCommand{},
+		CommandRequired: spec.cmd_required,
+		Args:            spec.allow_unknown_args,
+	}
+
+	if len(spec.meta) > 0 {
+		js.Meta = make(map[string]string, len(spec.meta))
+		for _, m := range spec.meta {
+			js.Meta[m.key] = m.value
+		}
+	}
+
+	for _, o := range spec.optlist {
+		jo := jsonOption{
+			Name:      o.name,
+			Flags:     o.flags,
+			Secondary: o.secondary,
+			Env:       o.env,
+			Help:      o.help,
+			Flag:      spec.flags[o.name],
+			Required:  spec.required[o.name],
+			Implicit:  o.implicit,
+			Metavar:   o.metavar,
+			Type:      o.vtype,
+			Choices:   o.choices,
+			Commands:  o.cmds,
+			Group:     o.group,
+			Secret:    spec.isSecret(o.name),
+			MinCount:  o.minCount,
+			MaxCount:  o.maxCount,
+		}
+		if o.constraint != nil {
+			jo.Constraint = "{" + o.constraint.text + "}"
+		}
+		if v, ok := spec.defaults[o.name]; ok {
+			v = spec.redact(o.name, v)
+			jo.Default = &v
+		}
+
+		if o.isenv {
+			js.Environment = append(js.Environment, jo)
+		} else {
+			js.Options = append(js.Options, jo)
+		}
+	}
+
+	for _, c := range spec.cmdlist {
+		js.Commands = append(js.Commands, jsonCommand{
+			Name:    c.name,
+			Aliases: c.aliases,
+			Help:    c.help,
+			Default: c.name == spec.default_cmd,
+		})
+	}
+	return json.Marshal(js)
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,41 @@
+//go:build !tinygo
+
+package options
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSpecJSON(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] command
+    @version 1.2
+    --
+    verbose       -v,--verbose             Verbose
+    !root=/var    -r,--root=DIR,TOOL_ROOT  Data root
+    fmt=          --format=                Output {json,text}
+    --
+    --
+    exec          exec,x                   Run a program
+    *default=exec
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"prog":"tool","usage":["usage: tool [options] command"],"meta":{"version":"1.2"},` +
+		`"options":[{"name":"verbose","flags":["-v","--verbose"],"help":"Verbose","flag":true},` +
+		`{"name":"root","flags":["-r","--root"],"env":["TOOL_ROOT"],"help":"Data root","flag":false,"required":true,"default":"/var","metavar":"DIR"},` +
+		`{"name":"fmt","flags":["--format"],"help":"Output {json,text}","flag":false,"choices":["json","text"]}],` +
+		`"environment":[],"commands":[{"name":"exec","aliases":["exec","x"],"help":"Run a program","default":true}],"args":false}`
+	if string(b) != want {
+		t.Errorf("bad json:\n%s\n--- expected ---\n%s", b, want)
+	}
+}
@@ -0,0 +1,209 @@
+// spectext.go - Canonical spec text
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Return the spec as normalized spec text: the sections in order with
+// the columns aligned, one line per paragraph of each description and
+// the profiles, rules, deprecations and presets sorted by name.
+// Comments and blank lines of the original are not kept. Parsing the
+// text yields an equivalent spec; SetCommandSpec() and the other
+// settings made from code are not part of it.
+func (spec *Spec) String() string {
+	// an indented usage line can only follow a metadata line
+	b := NewSpec()
+	indented := len(spec.about) > 0 && strings.TrimLeft(spec.about[0], " \t") != spec.about[0]
+	if !indented {
+		b.Usage(spec.about...)
+	}
+	for _, m := range spec.meta {
+		b.Usage(metaLine(m))
+	}
+	if indented {
+		b.Usage(spec.about...)
+	}
+
+	spec.addEntries(b)
+	b.Appendix(spec.appendix...)
+	spec.addEpilogs(b)
+	return b.String()
+}
+
+// Add the options, environment variables and commands of the spec to
+// 'b' along with the lines that declare their groups, profiles, rules,
+// deprecations and presets.
+func (spec *Spec) addEntries(b *Builder) {
+	for _, o := range spec.optlist {
+		if !o.isenv && o.group != b.group {
+			b.group = o.group
+			b.opts = append(b.opts, specLine{raw: strings.TrimSpace("## " + o.group)})
+		}
+
+		args := make([]string, 0, len(o.flags)+len(o.env)+1)
+		if o.isenv {
+			args = append(args, o.env...)
+			b.envs = b.entry(b.envs, spec.specName(o), append(args, o.help))
+			continue
+		}
+
+		// the placeholder goes on the long spellings, or on the first
+		// one if there are none
+		long := slices.ContainsFunc(o.flags, func(f string) bool {
+			return strings.HasPrefix(f, "--")
+		})
+		for i, f := range o.flags {
+			named := strings.HasPrefix(f, "--") || (i == 0 && !long)
+			switch {
+			case f == o.primary:
+				f = "*" + f
+			case slices.Contains(o.secondary, f):
+				f = "~" + f
+			}
+			if !spec.flags[o.name] {
+				f += "="
+				if named {
+					f += o.metavar
+				}
+			}
+			args = append(args, f)
+		}
+		args = append(args, o.env...)
+		b.opts = b.entry(b.opts, spec.specName(o), append(args, o.help))
+	}
+
+	raw := func(s string) {
+		b.opts = append(b.opts, specLine{raw: s})
+	}
+	for _, nm := range sortedKeys(spec.profiles) {
+		p := spec.profiles[nm]
+		w := make([]string, 0, len(p))
+		for _, k := range sortedKeys(p) {
+			w = append(w, specWord(k+"="+p[k]))
+		}
+		raw("[profile " + nm + "] " + strings.Join(w, " "))
+	}
+	for _, kind := range sortedKeys(spec.rules) {
+		r := spec.rules[kind]
+		for _, nm := range sortedKeys(r) {
+			raw("[" + kind + " " + nm + "] " + strings.Join(r[nm], " "))
+		}
+	}
+	for _, nm := range sortedKeys(spec.deprecated) {
+		d := spec.deprecated[nm]
+		head := nm
+		if len(d.removed) > 0 {
+			head += " " + d.removed
+		}
+		raw("[deprecated " + head + "] " + d.msg)
+	}
+	for _, nm := range sortedKeys(spec.migrations) {
+		m := spec.migrations[nm]
+		w := make([]string, 0, len(m))
+		for _, old := range sortedKeys(m) {
+			w = append(w, specWord(old+"="+m[old]))
+		}
+		raw("[migrate " + nm + "] " + strings.Join(w, " "))
+	}
+	for _, nm := range sortedKeys(spec.presets) {
+		w := make([]string, len(spec.presets[nm]))
+		for i, s := range spec.presets[nm] {
+			w[i] = specWord(s)
+		}
+		raw("[preset " + nm + "] " + strings.Join(w, " "))
+	}
+
+	for _, c := range spec.cmdlist {
+		b.cmds = b.entry(b.cmds, c.name, append(append([]string{}, c.aliases...), c.help))
+	}
+	if spec.allow_unknown_args && len(spec.positional) == 0 {
+		b.cmds = append(b.cmds, specLine{raw: "*"})
+	}
+	if len(spec.default_cmd) > 0 {
+		b.cmds = append(b.cmds, specLine{raw: "*default=" + spec.default_cmd})
+	}
+	if spec.cmd_required {
+		b.cmds = append(b.cmds, specLine{raw: "*required"})
+	}
+}
+
+// Add the "[epilog CMD]" blocks of the spec to the appendix of 'b'
+func (spec *Spec) addEpilogs(b *Builder) {
+	for _, c := range spec.cmdlist {
+		if e := trimBlank(c.epilog); len(e) > 0 {
+			b.Appendix("[epilog " + c.name + "]")
+			b.Appendix(e...)
+		}
+	}
+}
+
+// Return the "@key value" line of metadata 'm'
+func metaLine(m metaField) string {
+	return strings.TrimSpace("@" + m.key + " " + m.value)
+}
+
+// Return the name column of option 'o' with its markers, scope,
+// default and value type
+func (spec *Spec) specName(o *optspec) string {
+	s := o.name
+	if spec.required[o.name] {
+		s = "!" + s
+	}
+	if o.brief {
+		s = "+" + s
+	}
+	if o.secret {
+		s = "^" + s
+	}
+	s += o.arity()
+	if len(o.implicit) > 0 {
+		s += "[=" + o.implicit + "]"
+	}
+	if len(o.cmds) > 0 {
+		s += "@" + strings.Join(o.cmds, ",")
+	}
+
+	def, ok := spec.defaults[o.name]
+	if spec.flags[o.name] && !ok {
+		return s
+	}
+	s += "=" + def
+	if spec.flags[o.name] {
+		s += ":bool"
+	} else if len(o.vtype) > 0 {
+		s += ":" + o.vtype
+	}
+	if o.constraint != nil {
+		s += "{" + o.constraint.text + "}"
+	}
+	return s
+}
+
+// Quote 's' if SplitPOSIX() wouldn't return it as one word
+func specWord(s string) string {
+	if len(s) > 0 && !strings.ContainsAny(s, " \t\n'\"\\$`") {
+		return s
+	}
+	return shellQuote(s)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,89 @@
+package options
+
+import (
+	"testing"
+)
+
+func TestSpecString(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] command
+    A tool.
+    @version 1.2
+
+    --
+    # general options
+    +verbose            -v,--verbose              Verbose
+    !root=/var          -r,--root=DIR,TOOL_ROOT   Data root
+                                                  for everything
+
+                                                  Second paragraph.
+    color[=auto]=never  --color=                  Colors {auto,always,never}
+    zone=UTC:tz         --zone=                   Time zone
+    force@exec          -f,--force                Force it
+    hidden              --hidden                  -
+    tag=                -t,--tag=                 Tag "one"
+    [profile ci] root=/ci tag='a b'
+    [requires force] verbose
+    [deprecated --hidden 2.0]
+    [preset --fast] --color=always -v
+    --
+    home=               TOOL_HOME                 Home directory
+    --
+    exec                exec,x                    Run a program
+    list                list                      List things
+    *
+    *default=list
+    --
+    See the manual.
+    [epilog exec]
+    Example: tool exec ls
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `usage: tool [options] command
+A tool.
+@version 1.2
+--
++verbose            -v,--verbose              Verbose
+!root=/var          -r=,--root=DIR,TOOL_ROOT  Data root for everything
+
+                                              Second paragraph.
+color[=auto]=never  --color=                  Colors {auto,always,never}
+zone=UTC:tz         --zone=                   Time zone
+force@exec          -f,--force                Force it
+hidden              --hidden                  -
+tag=                -t=,--tag=                Tag "one"
+[profile ci] root=/ci 'tag=a b'
+[requires force] verbose
+[deprecated --hidden 2.0] it will be removed in version 2.0
+[preset --fast] --color=always -v
+--
+home=               TOOL_HOME                 Home directory
+--
+exec                exec,x                    Run a program
+list                list                      List things
+*
+*default=list
+--
+See the manual.
+[epilog exec]
+Example: tool exec ls
+`
+	text := spec.String()
+	if text != want {
+		t.Fatalf("bad spec text:\n%s\n--- expected ---\n%s", text, want)
+	}
+
+	again, err := Parse(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := again.String(); s != text {
+		t.Errorf("spec text changed on reparse:\n%s", s)
+	}
+	if d := again.Diff(spec); len(d) > 0 {
+		t.Errorf("reparsed spec differs: %v", d)
+	}
+}
@@ -0,0 +1,88 @@
+// spelling.go - Primary and secondary option spellings
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"slices"
+	"strings"
+)
+
+// Split the mark off a command line spelling: '*' in "*-v" makes it the
+// primary spelling of the option, '~' in "~--verbosity" a secondary one
+// that is accepted but not shown in the help. Anything else is
+// returned unchanged with a zero mark.
+func splitMark(part string) (byte, string) {
+	if len(part) > 1 && (part[0] == '*' || part[0] == '~') && part[1] == '-' {
+		return part[0], part[1:]
+	}
+	return 0, part
+}
+
+// Return the spellings column of an option line as shown in the usage:
+// the primary spelling first and without the secondary spellings or
+// the marks. The description stays in its column.
+func respell(line, spellings string) string {
+	if !strings.ContainsAny(spellings, "*~") {
+		return line
+	}
+
+	var w []string
+	for part := range strings.SplitSeq(spellings, ",") {
+		switch mark, part := splitMark(part); mark {
+		case '*':
+			w = slices.Insert(w, 0, part)
+		case '~':
+		default:
+			w = append(w, part)
+		}
+	}
+
+	s := strings.Join(w, ",")
+	if n := len(spellings) - len(s); n > 0 {
+		s += strings.Repeat(" ", n)
+	}
+	return s + line[len(spellings):]
+}
+
+// Return the command line spellings of 'o' shown in the help: all but
+// the secondary ones, the primary first.
+func (o *optspec) visibleFlags() []string {
+	if len(o.secondary) == 0 {
+		return o.flags
+	}
+
+	w := make([]string, 0, len(o.flags))
+	for _, f := range o.flags {
+		if !slices.Contains(o.secondary, f) {
+			w = append(w, f)
+		}
+	}
+	return w
+}
+
+// Return the command line spellings of option 'nm' (its name or any
+// of its spellings): the primary one first, followed by the others in
+// the order they are declared, including the secondary ones hidden
+// from the help. A spelling is made the primary by prefixing it with
+// '*' in the spec and secondary with '~':
+//
+//	verbose  *-v,--verbose,~--verbosity,~--debug-level   Verbose output
+//
+// is shown in the help as "-v, --verbose" but accepts all four. Return
+// nil if there is no such option. These are unrelated to the command
+// aliases of SetAliases().
+func (spec *Spec) Aliases(nm string) []string {
+	o := spec.lookupOpt(nm)
+	if o == nil {
+		return nil
+	}
+	return slices.Clone(o.flags)
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
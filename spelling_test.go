@@ -0,0 +1,81 @@
+package options
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOptionAliases(t *testing.T) {
+	spec, err := Parse(`
+    Usage: tool [options]
+
+    --
+    verbose  --verbose,*-v,~--verbosity,~--debug-level   Verbose output
+    root     -r,--root=DIR                               Data root
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"-v", "--verbose", "--verbosity", "--debug-level"}
+	for _, nm := range []string{"verbose", "--verbosity", "-v"} {
+		if w := spec.Aliases(nm); !slices.Equal(w, want) {
+			t.Errorf("%s: expected %q, saw %q", nm, want, w)
+		}
+	}
+	if w := spec.Aliases("nope"); w != nil {
+		t.Errorf("expected no aliases, saw %q", w)
+	}
+
+	// the secondary spellings are accepted but not shown
+	for _, a := range []string{"-v", "--verbose", "--verbosity", "--debug-level"} {
+		opts, err := spec.Interpret([]string{"tool", a}, nil)
+		if err != nil || !opts.GetBool("verbose") {
+			t.Errorf("%s: not accepted: %v", a, err)
+		}
+	}
+
+	// the descriptions stay aligned
+	lines := strings.Split(spec.usage, "\n")
+	v, r := lines[len(lines)-2], lines[len(lines)-1]
+	if !strings.HasPrefix(v, "  -v,--verbose ") || strings.Index(v, "Verbose") != strings.Index(r, "Data") {
+		t.Errorf("bad usage:\n%s", spec.usage)
+	}
+	for _, s := range []string{spec.FormatUsage(), spec.Synopsis()} {
+		if strings.Contains(s, "--verbosity") || strings.Contains(s, "--debug-level") || strings.Contains(s, "~") {
+			t.Errorf("secondary spelling shown:\n%s", s)
+		}
+	}
+	if s := spec.Synopsis(); !strings.Contains(s, "[-v]") {
+		t.Errorf("primary spelling not in the synopsis: %s", s)
+	}
+
+	h, _ := spec.OptionHelp("verbose")
+	if !strings.Contains(h, "Flags:       -v, --verbose\n") || !strings.Contains(h, "Aliases:     --verbosity, --debug-level\n") {
+		t.Errorf("bad option help:\n%s", h)
+	}
+
+	// the marks survive the canonical form
+	s2, err := Parse(spec.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2.String() != spec.String() || !slices.Equal(s2.Aliases("verbose"), want) {
+		t.Errorf("marks lost:\n%s", spec.String())
+	}
+}
+
+func TestOptionAliasErrors(t *testing.T) {
+	bad := []string{
+		"usage\n--\nv  *-v,*--verbose  Verbose\n--\n--\n--\n",
+		"usage\n--\nv  ~-v,~--verbose  Verbose\n--\n--\n--\n",
+	}
+	for _, desc := range bad {
+		if _, err := Parse(desc); err == nil {
+			t.Errorf("%q: expected an error", desc)
+		}
+	}
+}
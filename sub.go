@@ -0,0 +1,53 @@
+// sub.go - Command specific option specs
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+)
+
+// Attach 'sub' as the spec for the arguments of command 'cmd'. When
+// Interpret() recognizes 'cmd' it parses the command's arguments
+// (opts.Args) with 'sub' and attaches the result as opts.Sub; errors
+// from the sub-spec are reported with the command name prepended.
+// This is the only way to nest commands: 'sub' can attach specs to its
+// own commands in turn, for command lines like "tool remote add ...",
+// and opts.CommandPath lists the commands found at each level.
+func (spec *Spec) SetCommandSpec(cmd string, sub *Spec) error {
+	if !spec.hasCommand(cmd) {
+		return fmt.Errorf("Unknown command: %s", cmd)
+	}
+
+	if spec.subspecs == nil {
+		spec.subspecs = make(map[string]*Spec)
+	}
+	spec.subspecs[cmd] = sub
+	return nil
+}
+
+// Parse the spec text 'desc' and attach it to command 'cmd' like
+// SetCommandSpec(). This keeps the spec of a command next to the spec
+// of the program, e.g. for "tool remote add -v --url=...".
+func (spec *Spec) SetCommandSpecText(cmd, desc string) (*Spec, error) {
+	sub, err := Parse(desc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", cmd, err)
+	}
+	if err = spec.SetCommandSpec(cmd, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Return the spec attached to command 'cmd' or nil
+func (spec *Spec) CommandSpec(cmd string) *Spec {
+	return spec.subspecs[cmd]
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,197 @@
+package options
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSubSpec(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command> [args...]
+    --
+    verbose   -v,--verbose                Show more info
+    --
+    --
+    remote    remote                      Manage remotes
+    status    status                      Show status
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := Parse(`
+    usage: remote [options] <name> <url>
+    --
+    fetch     -f,--fetch                  Fetch after adding
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := spec.SetCommandSpec("remote", sub); err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.SetCommandSpec("bogus", sub); err == nil {
+		t.Error("expected error for unknown command")
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-v", "remote", "-f", "origin", "url"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if opts.Sub == nil {
+		t.Fatal("sub options not parsed")
+	}
+	if !opts.Sub.GetBool("fetch") {
+		t.Error("expected fetch")
+	}
+	if strings.Join(opts.Sub.Args, " ") != "origin url" {
+		t.Errorf("bad sub args: %q", opts.Sub.Args)
+	}
+
+	opts, err = spec.Interpret([]string{"tool", "status"}, []string{})
+	if err != nil || opts.Sub != nil {
+		t.Errorf("status has no sub-spec: %v %v", err, opts.Sub)
+	}
+
+	_, err = spec.Interpret([]string{"tool", "remote", "--bogus"}, []string{})
+	if err == nil || !strings.HasPrefix(err.Error(), "remote: ") {
+		t.Errorf("expected sub-spec error, saw %v", err)
+	}
+
+	pages := spec.MarkdownPages()
+	if p := pages["tool_remote.md"]; !strings.Contains(p, "`--fetch`") {
+		t.Errorf("command page is missing the sub-spec options:\n%s", p)
+	}
+}
+
+func TestSubSpecComplete(t *testing.T) {
+	spec := MustParse(`
+    usage: tool [options] <command> [args...]
+    --
+    verbose   -v,--verbose                Show more info
+    --
+    --
+    remote    remote                      Manage remotes
+    --
+    `)
+	sub := MustParse(`
+    usage: remote [options] <name> <url>
+    --
+    fetch     -f,--fetch                  Fetch after adding
+    --
+    --
+    add       add                         Add a remote
+    --
+    `)
+	spec.SetCommandSpec("remote", sub)
+
+	if c := spec.Complete([]string{"remote", "--f"}); len(c) != 1 || c[0] != "--fetch" {
+		t.Errorf("expected --fetch, saw %q", c)
+	}
+	if c := spec.Complete([]string{"-v", "remote", "a"}); len(c) != 1 || c[0] != "add" {
+		t.Errorf("expected add, saw %q", c)
+	}
+}
+
+func TestSubSpecText(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command> [args...]
+    --
+    verbose   -v,--verbose                Show more
+    --
+    --
+    remote    remote                      Manage remotes
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	remote, err := spec.SetCommandSpecText("remote", `
+    usage: remote <command> [args...]
+    --
+    --
+    --
+    add       add                         Add a remote
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	add, err := remote.SetCommandSpecText("add", `
+    usage: add [options] <name>
+    --
+    verbose   -v                          Show more
+    !url=     --url=                      Remote URL
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	add.SetSetenv(false)
+	remote.SetSetenv(false)
+
+	opts, err := spec.Interpret([]string{"tool", "remote", "add", "-v", "--url=u", "origin"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Sub == nil || opts.Sub.Command != "add" || opts.Sub.Sub == nil {
+		t.Fatalf("bad nesting: %+v", opts)
+	}
+	leaf := opts.Sub.Sub
+	if url, _ := leaf.Get("url"); url != "u" || !leaf.GetBool("verbose") || opts.GetBool("verbose") {
+		t.Errorf("bad options: %q %v %v", url, leaf.GetBool("verbose"), opts.GetBool("verbose"))
+	}
+	if len(leaf.Args) != 1 || leaf.Args[0] != "origin" {
+		t.Errorf("bad args: %v", leaf.Args)
+	}
+	if p := strings.Join(opts.CommandPath, " "); p != "remote add" {
+		t.Errorf("bad command path: %q", p)
+	}
+	if p := strings.Join(opts.Sub.CommandPath, " "); p != "add" || leaf.CommandPath != nil {
+		t.Errorf("bad sub command paths: %q %q", p, leaf.CommandPath)
+	}
+	if c := opts.Clone(); strings.Join(c.CommandPath, " ") != "remote add" {
+		t.Errorf("bad cloned command path: %q", c.CommandPath)
+	}
+
+	if _, err = spec.Interpret([]string{"tool", "remote", "add", "origin"}, nil); err == nil || !strings.HasPrefix(err.Error(), "remote: add: ") {
+		t.Errorf("expected a nested error, saw %v", err)
+	}
+
+	// the errors of the sub-specs point into the original args
+	args := []string{"tool", "-v", "remote", "add", "--url=u", "--nope", "origin"}
+	_, err = spec.Interpret(args, nil)
+	var e *Error
+	if !errors.As(err, &e) || e.Index != 5 || args[e.Index] != e.Token {
+		t.Errorf("bad nested error: %v %+v", err, e)
+	}
+
+	spec.SetOptionsAfterCommand(true)
+	args = []string{"tool", "remote", "-v", "add", "--url=u", "--nope", "origin"}
+	_, err = spec.Interpret(args, nil)
+	if !errors.As(err, &e) || e.Index != 5 || args[e.Index] != e.Token {
+		t.Errorf("bad nested error: %v %+v", err, e)
+	}
+	spec.SetOptionsAfterCommand(false)
+
+	if _, err = spec.SetCommandSpecText("remote", "usage\n--\n[requires x] y\n--\n"); err == nil || !strings.HasPrefix(err.Error(), "remote: ") {
+		t.Errorf("expected a spec error, saw %v", err)
+	}
+	if _, err = spec.SetCommandSpecText("nope", "usage: x\n"); err == nil {
+		t.Error("expected an error for an unknown command")
+	}
+}
@@ -0,0 +1,189 @@
+// suggest.go - "Did you mean" suggestions
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"sort"
+	"strings"
+)
+
+// Return the candidates closest to 'word' by edit distance, sorted;
+// candidates that are too far off to be typos aren't returned.
+func suggest(word string, candidates []string) []string {
+	var rv []string
+
+	// allow one edit for every three characters, but at least one
+	limit := max(len(word)/3, 1)
+	best := limit + 1
+	for _, c := range candidates {
+		d := editDistance(word, c)
+		if d > limit {
+			continue
+		}
+
+		switch {
+		case d < best:
+			best = d
+			rv = []string{c}
+		case d == best:
+			rv = append(rv, c)
+		}
+	}
+	sort.Strings(rv)
+	return rv
+}
+
+// Return the command names (including aliases) closest to 'word'
+func (spec *Spec) suggestCommand(word string) []string {
+	names := make([]string, 0, len(spec.commands))
+	for c := range spec.commands {
+		names = append(names, c)
+	}
+	return suggest(word, names)
+}
+
+// Return the option spellings closest to the unknown option 'word';
+// single letter options are too short to tell typos apart.
+func (spec *Spec) suggestOption(word string) []string {
+	if !strings.HasPrefix(word, "--") {
+		return nil
+	}
+
+	// the dashes don't count towards the edits allowed
+	names := make([]string, 0, len(spec.options))
+	for f := range spec.options {
+		if strings.HasPrefix(f, "--") {
+			names = append(names, f[2:])
+		}
+	}
+
+	rv := suggest(word[2:], names)
+	for i := range rv {
+		rv[i] = "--" + rv[i]
+	}
+	return rv
+}
+
+// Accept unambiguous prefixes of command names in place of the full
+// name, e.g. "tool sta" for "tool status". An ambiguous prefix fails
+// Interpret() with an error listing the candidates. This is off by
+// default.
+func (spec *Spec) SetCommandPrefixes(on bool) {
+	spec.cmd_prefix = on
+}
+
+// Return the command that 'word' is a unique prefix of, or an empty
+// string if it isn't a prefix of any command.
+func (spec *Spec) matchCommand(word string) (string, error) {
+	var names []string
+
+	seen := make(map[string]bool)
+	for nm, c := range spec.commands {
+		if strings.HasPrefix(nm, word) && !seen[c] {
+			seen[c] = true
+			names = append(names, c)
+		}
+	}
+
+	switch len(names) {
+	case 0:
+		return "", nil
+	case 1:
+		return names[0], nil
+	}
+
+	sort.Strings(names)
+	err := spec.optError(MsgAmbiguousCmd, "", word, word, orList(names))
+	err.(*Error).Suggestions = names
+	return "", err
+}
+
+// Accept unambiguous prefixes of long options in place of the full
+// spelling, e.g. "--verb" for "--verbose"; a value can be attached as
+// usual ("--ro=/x"). An ambiguous prefix fails Interpret() with an
+// error listing the candidates. This is off by default.
+func (spec *Spec) SetOptionPrefixes(on bool) {
+	spec.opt_prefix = on
+}
+
+// Return the option whose long spelling 'word' ("--verb") is a unique
+// prefix of, or an empty string if it isn't a prefix of any. Prefixes
+// of several spellings of the same option are unique.
+func (spec *Spec) matchOption(word string) (string, error) {
+	if !strings.HasPrefix(word, "--") || len(word) < 3 {
+		return "", nil
+	}
+
+	var names, spellings []string
+	seen := make(map[string]bool)
+	for _, o := range spec.optlist {
+		for _, f := range o.flags {
+			if !strings.HasPrefix(f, "--") || !strings.HasPrefix(f, word) {
+				continue
+			}
+			if !seen[o.name] {
+				seen[o.name] = true
+				names = append(names, o.name)
+				spellings = append(spellings, f)
+			}
+		}
+	}
+
+	switch len(names) {
+	case 0:
+		return "", nil
+	case 1:
+		return names[0], nil
+	}
+
+	sort.Strings(spellings)
+	err := spec.optError(MsgAmbiguousOpt, "", word, word, orList(spellings))
+	err.(*Error).Suggestions = spellings
+	return "", err
+}
+
+// Return the Damerau-Levenshtein (optimal string alignment) distance
+// between 'a' and 'b'; a transposition of adjacent characters counts
+// as one edit.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+// Return the suggestions as "a, b or c"
+func orList(v []string) string {
+	if len(v) < 2 {
+		return strings.Join(v, "")
+	}
+	return strings.Join(v[:len(v)-1], ", ") + " or " + v[len(v)-1]
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,214 @@
+package options
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSuggestCommand(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command>
+    --
+    --
+    --
+    commit    ci,commit                   Commit
+    config    config                      Configure
+    clone     clone                       Clone
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmds := "; the commands are:\n  ci, commit  Commit\n  config      Configure\n  clone       Clone"
+	tests := map[string]string{
+		"comit":  "Invalid argument: comit was not recognized (did you mean commit?)",
+		"ocmmit": "Invalid argument: ocmmit was not recognized (did you mean commit?)",
+		"clon":   "Invalid argument: clon was not recognized (did you mean clone?)",
+		"conf":   "Invalid argument: conf was not recognized" + cmds,
+		"cx":     "Invalid argument: cx was not recognized (did you mean ci?)",
+		"zzzzz":  "Invalid argument: zzzzz was not recognized" + cmds,
+	}
+
+	for arg, want := range tests {
+		_, err := spec.Interpret([]string{"tool", arg}, []string{})
+		if err == nil || err.Error() != want {
+			t.Errorf("%s: expected %q, saw %v", arg, want, err)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		d    int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"commit", "comit", 1},
+		{"commit", "ocmmit", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tc := range tests {
+		if d := editDistance(tc.a, tc.b); d != tc.d {
+			t.Errorf("%s, %s: expected %d, saw %d", tc.a, tc.b, tc.d, d)
+		}
+	}
+}
+
+func TestCommandPrefixes(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command>
+    --
+    --
+    --
+    status    st,status                   Show status
+    stash     stash                       Stash changes
+    show      show                        Show things
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = spec.Interpret([]string{"tool", "stat"}, []string{}); err == nil {
+		t.Error("prefixes accepted without opting in")
+	}
+
+	spec.SetCommandPrefixes(true)
+
+	tests := map[string]string{
+		"stat": "status",
+		"st":   "status",
+		"stas": "stash",
+		"sh":   "show",
+	}
+	for arg, want := range tests {
+		opts, err := spec.Interpret([]string{"tool", arg, "x"}, []string{})
+		if err != nil {
+			t.Fatalf("%s: %s", arg, err)
+		}
+		if opts.Command != want || opts.Args[0] != want {
+			t.Errorf("%s: expected %s, saw %s", arg, want, opts.Command)
+		}
+	}
+
+	_, err = spec.Interpret([]string{"tool", "s"}, []string{})
+	want := "Invalid argument: s is ambiguous (could be show, stash or status)"
+	if err == nil || err.Error() != want {
+		t.Errorf("expected %q, saw %v", want, err)
+	}
+}
+
+func TestOptionPrefixes(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    verbose   -v,--verbose,--verb-level   Verbose
+    version   --version                   Show the version
+    root=     -r,--root=                  Data root
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = spec.Interpret([]string{"tool", "--verb"}, []string{}); err == nil {
+		t.Fatal("prefixes are accepted by default")
+	}
+
+	spec.SetOptionPrefixes(true)
+
+	opts, err := spec.Interpret([]string{"tool", "--verb", "--ro=/x", "--vers"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.GetBool("verbose") || !opts.GetBool("version") {
+		t.Error("flags not set by their prefixes")
+	}
+	if v, _ := opts.Get("root"); v != "/x" {
+		t.Errorf("bad root %q", v)
+	}
+
+	_, err = spec.Interpret([]string{"tool", "--ver"}, []string{})
+	var e *Error
+	if !errors.As(err, &e) || e.Key != MsgAmbiguousOpt || len(e.Suggestions) != 2 {
+		t.Fatalf("expected an ambiguous option error, saw %v", err)
+	}
+	if want := "Invalid option: --ver is ambiguous (could be --verbose or --version)"; err.Error() != want {
+		t.Errorf("expected %q, saw %q", want, err)
+	}
+
+	if _, err = spec.Interpret([]string{"tool", "--x"}, []string{}); err == nil {
+		t.Error("--x: expected an error")
+	}
+}
+
+func TestSuggestOption(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    verbose   -v,--verbose                Verbose
+    version   --version                   Show the version
+    root=     -r,--root=                  Data root
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"--verbsoe":  "Invalid option: --verbsoe was not recognized (did you mean --verbose?)",
+		"--versoin=": "Invalid option: --versoin= was not recognized (did you mean --version?)",
+		"--rot=/x":   "Invalid option: --rot=/x was not recognized (did you mean --root?)",
+		"--nope":     "Invalid option: --nope was not recognized",
+		"-x":         "Invalid option: -x was not recognized",
+	}
+
+	for arg, want := range tests {
+		_, err := spec.Interpret([]string{"tool", arg}, []string{})
+		if err == nil || err.Error() != want {
+			t.Errorf("%s: expected %q, saw %v", arg, want, err)
+		}
+	}
+
+	_, err = spec.Interpret([]string{"tool", "--verbos"}, []string{})
+	var e *Error
+	if !errors.As(err, &e) || e.Key != MsgUnknownOptHint || len(e.Suggestions) != 1 || e.Suggestions[0] != "--verbose" {
+		t.Errorf("bad error %#v", err)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] <command>
+    --
+    --
+    --
+    shell     sh,shell                    Open a shell
+    secret    secret                      -
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := spec.Commands()
+	if len(c) != 2 || c[0].Name != "shell" || len(c[0].Aliases) != 2 || c[0].Aliases[0] != "sh" || c[0].Help != "Open a shell" || c[1].Help != "" {
+		t.Errorf("bad commands: %+v", c)
+	}
+
+	_, err = spec.Interpret([]string{"tool", "zzzzz"}, []string{})
+	if !errors.Is(err, ErrUnknownCommand) {
+		t.Errorf("expected an unknown command error, saw %v", err)
+	}
+	want := "Invalid argument: zzzzz was not recognized; the commands are:\n  sh, shell  Open a shell"
+	if err == nil || err.Error() != want {
+		t.Errorf("bad error: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+// timeval.go - Time valued options
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"strings"
+	"time"
+)
+
+// The layouts GetTime() tries when none are given; layouts without a
+// zone are in local time.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// the current time; replaced by the tests
+var timeNow = time.Now
+
+// Interpret the option corresponding to the key 'nm' as a point in
+// time. The value is parsed with each of 'layouts' in turn, or else
+// RFC 3339 and the common "2006-01-02 15:04[:05]" and "2006-01-02"
+// forms. A value can also be relative to the current time: "now",
+// "today", "yesterday" and "tomorrow" (the latter three at midnight),
+// a duration in the past ("2h", "90m ago") or in the future ("+2h"),
+// as in "--since 2h" or "--until +30m". The second retval will be
+// false if the parse fails or the key is not found.
+func (opts *Options) GetTime(nm string, layouts ...string) (time.Time, bool) {
+	v, ok := opts.Get(nm)
+	if !ok {
+		return time.Time{}, false
+	}
+	return parseTime(strings.TrimSpace(v), layouts)
+}
+
+// Parse 's' as GetTime() does
+func parseTime(s string, layouts []string) (time.Time, bool) {
+	if len(layouts) == 0 {
+		layouts = timeLayouts
+	}
+	for _, l := range layouts {
+		if t, err := time.ParseInLocation(l, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+
+	now := timeNow()
+	y, m, d := now.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+
+	switch strings.ToLower(s) {
+	case "now":
+		return now, true
+	case "today":
+		return midnight, true
+	case "yesterday":
+		return midnight.AddDate(0, 0, -1), true
+	case "tomorrow":
+		return midnight.AddDate(0, 0, 1), true
+	}
+
+	if rest, ok := strings.CutPrefix(s, "+"); ok {
+		if d, err := time.ParseDuration(rest); err == nil && d >= 0 {
+			return now.Add(d), true
+		}
+		return time.Time{}, false
+	}
+
+	s = strings.TrimSpace(strings.TrimSuffix(s, " ago"))
+	s = strings.TrimPrefix(s, "-")
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), true
+	}
+	return time.Time{}, false
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
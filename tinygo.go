@@ -0,0 +1,25 @@
+// tinygo.go - Stand-ins for the parts left out of TinyGo builds
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+//go:build tinygo
+
+package options
+
+// There is no debug logging without log/slog
+type debugLogger struct{}
+
+func (spec *Spec) debug(msg string, args ...any) {}
+
+// There are no help templates without text/template
+type helpTemplate struct{}
+
+func (spec *Spec) templateUsage() (string, bool) {
+	return "", false
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
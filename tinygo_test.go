@@ -0,0 +1,50 @@
+package options
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// The packages that a TinyGo build may import directly
+var tinygoImports = []string{
+	"bufio", "context", "encoding", "errors", "fmt", "io", "maps",
+	"math", "net/netip", "os", "path/filepath", "reflect", "regexp",
+	"runtime", "runtime/debug", "slices", "sort", "strconv", "strings",
+	"sync", "time", "unicode",
+}
+
+// The packages that a TinyGo build must not link, even indirectly
+var tinygoBanned = []string{
+	"encoding/json", "log/slog", "text/template", "net", "net/url",
+}
+
+func TestTinyGoDeps(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping go list in short mode")
+	}
+	gocmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go not found")
+	}
+
+	list := func(format string) []string {
+		out, err := exec.Command(gocmd, "list", "-tags", "tinygo", "-deps", "-f", format, ".").Output()
+		if err != nil {
+			t.Fatalf("go list: %s", err)
+		}
+		return strings.Fields(string(out))
+	}
+
+	for _, p := range list(`{{if not .Standard}}{{join .Imports " "}}{{end}}`) {
+		if !slices.Contains(tinygoImports, p) {
+			t.Errorf("the tinygo build imports %s", p)
+		}
+	}
+	for _, p := range list(`{{.ImportPath}}`) {
+		if slices.Contains(tinygoBanned, p) {
+			t.Errorf("the tinygo build links %s", p)
+		}
+	}
+}
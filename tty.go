@@ -0,0 +1,79 @@
+// tty.go - Terminal detection
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// Override the detection of terminals: 'stdin' and 'stdout' tell
+// whether the input and the output (see SetOutput()) are terminals.
+// This is meant for tests and for programs that know better, e.g.
+// when they run under a pseudo terminal they don't want to treat as
+// interactive.
+func (spec *Spec) SetTerminal(stdin, stdout bool) {
+	spec.tty_set = true
+	spec.tty_in = stdin
+	spec.tty_out = stdout
+}
+
+// Return true if the output of the program is a terminal, e.g. to
+// decide whether to use colors or show progress bars.
+func (spec *Spec) IsTerminal() bool {
+	if spec.tty_set {
+		return spec.tty_out
+	}
+	return isTerminal(spec.outw())
+}
+
+// Return true if both the input and output of the program are
+// terminals, i.e. if there is a user to prompt for input.
+func (spec *Spec) IsInteractive() bool {
+	if spec.tty_set {
+		return spec.tty_in && spec.tty_out
+	}
+	return isTerminal(os.Stdin) && isTerminal(spec.outw())
+}
+
+// Interpret the option corresponding to the key 'nm' as a "when"
+// value, as in "--color=auto": "auto" is true if the output is a
+// terminal (see IsTerminal()), "always" and "never" are true and false
+// and so are the values accepted by GetBool(). The second retval will
+// be false if the parse fails or the key is not found.
+func (opts *Options) GetAuto(nm string) (bool, bool) {
+	v, ok := opts.Get(nm)
+	if !ok {
+		return false, false
+	}
+
+	switch strings.ToLower(v) {
+	case "auto":
+		return opts.spec.IsTerminal(), true
+	case "always":
+		return true, true
+	case "never":
+		return false, true
+	}
+	return parseBool(v)
+}
+
+// Return true if 'w' is a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,76 @@
+package options
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTerminal(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    color=auto    --color=WHEN            Colorize the output
+    progress=     --progress=WHEN         Show progress
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	// a pipe is never a terminal
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	spec.SetOutput(w, nil)
+	if spec.IsTerminal() || spec.IsInteractive() {
+		t.Error("a pipe is not a terminal")
+	}
+	spec.SetOutput(&strings.Builder{}, nil)
+	if spec.IsTerminal() {
+		t.Error("a buffer is not a terminal")
+	}
+
+	tests := []struct {
+		args []string
+		tty  bool
+		val  bool
+		ok   bool
+	}{
+		{[]string{"tool"}, true, true, true},
+		{[]string{"tool"}, false, false, true},
+		{[]string{"tool", "--color=always"}, false, true, true},
+		{[]string{"tool", "--color=NEVER"}, true, false, true},
+		{[]string{"tool", "--color=yes"}, false, true, true},
+		{[]string{"tool", "--color=sometimes"}, true, false, false},
+	}
+	for _, tc := range tests {
+		spec.SetTerminal(false, tc.tty)
+		opts, err := spec.Interpret(tc.args, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, ok := opts.GetAuto("color"); v != tc.val || ok != tc.ok {
+			t.Errorf("%v (tty %v): expected %v %v, saw %v %v", tc.args, tc.tty, tc.val, tc.ok, v, ok)
+		}
+		if _, ok := opts.GetAuto("progress"); ok {
+			t.Errorf("%v: expected no value for progress", tc.args)
+		}
+	}
+
+	spec.SetTerminal(false, true)
+	if spec.IsInteractive() {
+		t.Error("expected no interaction without a terminal on stdin")
+	}
+	spec.SetTerminal(true, true)
+	if !spec.IsInteractive() {
+		t.Error("expected interaction with terminals")
+	}
+}
@@ -0,0 +1,126 @@
+// typereg.go - Application defined value types
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// Register the value type 'name' for the options of the spec: 'fn'
+// parses a value into whatever the application needs (e.g. a
+// netip.Prefix or a *url.URL) or returns an error. Options declared
+// with the type, as in "listen=:ipaddr" or "listen=[::1]:80:ipaddr",
+// then have their values (including the default) validated by
+// Interpret() and returned parsed by GetTyped():
+//
+//	spec.RegisterType("ipaddr", func(s string) (any, error) {
+//	    return netip.ParseAddrPort(s)
+//	})
+//
+// Since the spec is parsed before the types are registered, the type
+// suffix of such options is only recognized by this call. The built-in
+// types can't be replaced.
+func (spec *Spec) RegisterType(name string, fn func(string) (any, error)) error {
+	if _, ok := valueTypes[name]; ok || len(name) == 0 || fn == nil {
+		return fmt.Errorf("Invalid type: %s", name)
+	}
+
+	// the defaults of the options of this type still carry the suffix
+	var typed []*optspec
+	for _, o := range spec.optlist {
+		def, ok := spec.defaults[o.name]
+		if !ok || len(o.vtype) > 0 || !strings.HasSuffix(def, ":"+name) {
+			continue
+		}
+
+		if def = strings.TrimSuffix(def, ":"+name); len(def) > 0 {
+			if _, err := fn(def); err != nil {
+				return fmt.Errorf("Invalid option spec: default %s of %s is not a valid %s", def, o.name, name)
+			}
+		}
+		typed = append(typed, o)
+	}
+
+	for _, o := range typed {
+		if def := strings.TrimSuffix(spec.defaults[o.name], ":"+name); len(def) > 0 {
+			spec.defaults[o.name] = def
+		} else {
+			delete(spec.defaults, o.name)
+		}
+		o.vtype = name
+	}
+
+	if spec.types == nil {
+		spec.types = make(map[string]func(string) (any, error))
+	}
+	spec.types[name] = fn
+	return nil
+}
+
+// Verify that 's' is a valid value of type 'typ'
+func (spec *Spec) validType(typ, s string) error {
+	if fn, ok := spec.types[typ]; ok {
+		_, err := fn(s)
+		return err
+	}
+	return valueTypes[typ](s)
+}
+
+// Converters of the built-in value types that GetTyped() returns as
+// something other than a string and that aren't built for TinyGo
+var typedValues = map[string]func(string) (any, error){}
+
+// Return the value of option 'nm' parsed according to its type: the
+// result of the function registered with RegisterType(), a bool, a
+// *time.Location or a slog.Level for the "bool", "tz" and "loglevel"
+// types, a netip.Addr, netip.Prefix or *url.URL for the "ip", "cidr"
+// and "url" types, and the value as a string for other options. The second
+// retval will be false if the value doesn't parse or the key is not
+// found.
+func (opts *Options) GetTyped(nm string) (any, bool) {
+	return memo(opts, convTyped, nm, func(v string) (any, bool) {
+		var typ string
+		var fn func(string) (any, error)
+		if opts.spec != nil {
+			if o := opts.spec.optinfo[nm]; o != nil {
+				typ, fn = o.vtype, opts.spec.types[o.vtype]
+			}
+		}
+
+		switch typ {
+		case "bool":
+			b, ok := parseBool(v)
+			return b, ok
+		case "tz":
+			loc, err := time.LoadLocation(v)
+			return loc, err == nil
+		case "ip":
+			a, err := netip.ParseAddr(v)
+			return a, err == nil
+		case "cidr":
+			p, err := netip.ParsePrefix(v)
+			return p, err == nil
+		}
+		if conv, ok := typedValues[typ]; ok {
+			x, err := conv(v)
+			return x, err == nil
+		}
+
+		if fn != nil {
+			x, err := fn(v)
+			return x, err == nil
+		}
+		return v, true
+	})
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
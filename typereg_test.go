@@ -0,0 +1,76 @@
+package options
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestRegisterType(t *testing.T) {
+	spec, err := Parse(`
+    usage: server [options]
+    --
+    listen=127.0.0.1:80:addrport  -l,--listen=   Listen address
+    peer=:addrport                --peer=        Peer address
+    zone=UTC:tz                   --zone=        Time zone
+    name=srv                      --name=        Server name
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	err = spec.RegisterType("addrport", func(s string) (any, error) {
+		return netip.ParseAddrPort(s)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.RegisterType("tz", func(s string) (any, error) { return s, nil }); err == nil {
+		t.Error("expected an error for a built-in type")
+	}
+
+	opts, err := spec.Interpret([]string{"server", "--peer=10.0.0.1:443"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, ok := opts.GetTyped("listen"); !ok || v != netip.MustParseAddrPort("127.0.0.1:80") {
+		t.Errorf("bad listen: %v", v)
+	}
+	if v, ok := opts.GetTyped("peer"); !ok || v != netip.MustParseAddrPort("10.0.0.1:443") {
+		t.Errorf("bad peer: %v", v)
+	}
+	if v, ok := opts.GetTyped("zone"); !ok || v != time.UTC {
+		t.Errorf("bad zone: %v", v)
+	}
+	if v, ok := opts.GetTyped("name"); !ok || v != "srv" {
+		t.Errorf("bad name: %v", v)
+	}
+
+	_, err = spec.Interpret([]string{"server", "--peer=nowhere"}, []string{})
+	if err == nil || err.Error() != "Invalid option: --peer=nowhere: nowhere is not a valid addrport" {
+		t.Errorf("bad error: %v", err)
+	}
+
+	spec, err = Parse(`
+    usage: server [options]
+    --
+    peer=bogus:addrport    --peer=        Peer address
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = spec.RegisterType("addrport", func(s string) (any, error) {
+		return netip.ParseAddrPort(s)
+	})
+	if err == nil {
+		t.Error("expected an error for an invalid default")
+	}
+}
@@ -0,0 +1,195 @@
+// types.go - Option value types
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validators of the built-in value types that can be attached to an
+// option with "name=default:type" in the spec; the "loglevel", "url"
+// and "hostport" types are added by the files that aren't built for
+// TinyGo
+var valueTypes = map[string]func(string) error{
+	"bool": func(s string) error {
+		if _, ok := parseBool(s); !ok {
+			return strconv.ErrSyntax
+		}
+		return nil
+	},
+
+	// expanded rather than validated; see expand()
+	"path":   func(s string) error { return nil },
+	"secret": func(s string) error { return nil },
+
+	// checked once all the options are read; see checkPaths()
+	"file": func(s string) error { return nil },
+	"dir":  func(s string) error { return nil },
+	"dir!": func(s string) error { return nil },
+
+	"tz": func(s string) error {
+		_, err := time.LoadLocation(s)
+		return err
+	},
+
+	"ip": func(s string) error {
+		_, err := netip.ParseAddr(s)
+		return err
+	},
+
+	"cidr": func(s string) error {
+		_, err := netip.ParsePrefix(s)
+		return err
+	},
+}
+
+// Split the value type off the default 'def' of an option
+// ("UTC:tz"); the suffix is only a type if it names a known one.
+func splitType(def string) (string, string) {
+	for i := len(def) - 1; i >= 0; i-- {
+		if def[i] == ':' {
+			if _, ok := valueTypes[def[i+1:]]; ok {
+				return def[:i], def[i+1:]
+			}
+			break
+		}
+	}
+	return def, ""
+}
+
+// Make Interpret() reject flag values from the environment and config
+// files that aren't booleans (see GetBoolErr()), e.g. "VERBOSE=tru",
+// rather than take them as false.
+func (spec *Spec) SetStrictBool(on bool) {
+	spec.strict_bool = on
+}
+
+// Verify that 'value' is valid for the type of option 'nm'; 'arg' is
+// the argument or env var that supplied it.
+func (spec *Spec) checkType(nm, arg, value string) error {
+	// an empty value clears the option
+	o := spec.optinfo[nm]
+	if o == nil || len(value) == 0 {
+		return nil
+	}
+
+	if spec.strict_bool && spec.flags[nm] {
+		if _, ok := parseBool(value); !ok {
+			return spec.optError(MsgBadValue, nm, arg, arg, spec.redact(nm, value), "bool")
+		}
+	}
+
+	if len(o.vtype) > 0 {
+		if err := spec.validType(o.vtype, value); err != nil {
+			return spec.optError(MsgBadValue, nm, arg, arg, spec.redact(nm, value), o.vtype)
+		}
+	}
+	if len(o.choices) > 0 {
+		if err := spec.checkChoice(o, arg, value); err != nil {
+			return err
+		}
+	}
+	return spec.checkConstraint(o, arg, value)
+}
+
+// Interpret the option corresponding to the key 'nm' as a time zone
+// name (e.g. "Europe/Berlin", "UTC" or "Local") and return its
+// location. The second retval will be false if the zone is unknown or
+// the key is not found.
+func (opts *Options) GetLocation(nm string) (*time.Location, bool) {
+	return memo(opts, convLocation, nm, func(v string) (*time.Location, bool) {
+		loc, err := time.LoadLocation(v)
+		return loc, err == nil
+	})
+}
+
+// Feed the value of option 'nm' to the UnmarshalText() method of 'v'.
+// This reads any type that implements encoding.TextUnmarshaler, e.g.
+// netip.Addr, big.Int or an application defined ID:
+//
+//	var addr netip.Addr
+//	err := opts.GetText("listen", &addr)
+//
+// If the option isn't set 'v' is left as is; use IsSet() to tell the
+// cases apart.
+func (opts *Options) GetText(nm string, v encoding.TextUnmarshaler) error {
+	s, ok := opts.Get(nm)
+	if !ok {
+		return nil
+	}
+
+	if err := v.UnmarshalText([]byte(s)); err != nil {
+		return fmt.Errorf("Invalid option: %s: %w", nm, err)
+	}
+	return nil
+}
+
+// Binary multipliers of the size suffixes understood by parseSize()
+var sizeUnits = map[byte]uint64{
+	'k': 1 << 10,
+	'm': 1 << 20,
+	'g': 1 << 30,
+	't': 1 << 40,
+	'p': 1 << 50,
+	'e': 1 << 60,
+}
+
+// Parse a size like "512", "10k", "4M" or "1.5G" into bytes. The
+// suffixes K, M, G, T, P and E (in any case) are powers of 1024 and
+// may be followed by "B" or "iB" ("4MiB"); a bare "B" is allowed too.
+// A fractional size must come out to a whole number of bytes.
+func parseSize(s string) (uint64, error) {
+	num := strings.TrimSpace(s)
+	lower := strings.ToLower(num)
+	mult := uint64(1)
+
+	// "iB" only follows a unit; "B" may stand alone
+	suffix := 0
+	switch {
+	case strings.HasSuffix(lower, "ib"):
+		suffix = 2
+	case strings.HasSuffix(lower, "b"):
+		suffix = 1
+	}
+
+	num = num[:len(num)-suffix]
+	if n := len(num); n > 0 {
+		if m, ok := sizeUnits[lower[n-1]]; ok {
+			num, mult = num[:n-1], m
+		} else if suffix == 2 {
+			return 0, fmt.Errorf("invalid size %q", s)
+		}
+	}
+
+	if u, err := strconv.ParseUint(num, 0, 64); err == nil {
+		if u > math.MaxUint64/mult {
+			return 0, fmt.Errorf("size %q out of range", s)
+		}
+		return u * mult, nil
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	f *= float64(mult)
+	if f >= math.MaxUint64 || f != math.Trunc(f) {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return uint64(f), nil
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
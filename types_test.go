@@ -0,0 +1,341 @@
+package options
+
+import (
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocation(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    zone=UTC:tz   -z,--timezone=,TOOL_TZ   Time zone
+    addr=host:80  -a,--addr=               Not a typed default
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if spec.defaults["addr"] != "host:80" || spec.defaults["zone"] != "UTC" {
+		t.Errorf("bad defaults: %v", spec.defaults)
+	}
+
+	opts, err := spec.Interpret([]string{"tool"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc, ok := opts.GetLocation("zone"); !ok || loc.String() != "UTC" {
+		t.Errorf("bad default location: %v", loc)
+	}
+
+	opts, err = spec.Interpret([]string{"tool", "--timezone", "Europe/Berlin"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc, ok := opts.GetLocation("zone"); !ok || loc.String() != "Europe/Berlin" {
+		t.Errorf("bad location: %v", loc)
+	}
+	if _, ok := opts.GetLocation("addr"); ok {
+		t.Error("expected addr to not be a location")
+	}
+
+	_, err = spec.Interpret([]string{"tool", "-z", "Mars/Olympus"}, []string{})
+	if err == nil || !strings.Contains(err.Error(), "not a valid tz") {
+		t.Errorf("expected an invalid zone error, saw %v", err)
+	}
+
+	_, err = spec.Interpret([]string{"tool"}, []string{"TOOL_TZ=Nowhere"})
+	if err == nil || !strings.Contains(err.Error(), "TOOL_TZ") {
+		t.Errorf("expected an invalid env zone error, saw %v", err)
+	}
+
+	if err = opts.Set("zone", "Nowhere"); err == nil {
+		t.Error("Set() accepted an invalid zone")
+	}
+
+	_, err = Parse(`
+    usage: tool [options]
+    --
+    zone=Nowhere:tz  -z,--timezone=        Time zone
+    --
+    --
+    --
+    `)
+	if err == nil {
+		t.Error("expected an error for an invalid default")
+	}
+}
+
+func TestCount(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    verbose   -v,--verbose,TOOL_VERBOSE   More output
+    quiet     -q                          Less output
+    tag=      -t,--tag=                   Tags
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	tests := []struct {
+		args []string
+		env  []string
+		n    int
+	}{
+		{[]string{"-vvv"}, nil, 3},
+		{[]string{"-vqv", "--verbose"}, nil, 3},
+		{[]string{"-vv", "--no-verbose", "-v"}, nil, 1},
+		{[]string{"-v", "--no-verbose"}, nil, 0},
+		{nil, []string{"TOOL_VERBOSE=1"}, 1},
+		{nil, []string{"TOOL_VERBOSE=off"}, 0},
+	}
+
+	for _, tc := range tests {
+		opts, err := spec.Interpret(append([]string{"tool"}, tc.args...), tc.env)
+		if err != nil {
+			t.Fatalf("%v: %s", tc.args, err)
+		}
+		if n := opts.GetCount("verbose"); n != tc.n {
+			t.Errorf("%v %v: expected %d, saw %d", tc.args, tc.env, tc.n, n)
+		}
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "-t", "a", "--tag=", "-t", "b"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := opts.GetCount("tag"); n != 3 {
+		t.Errorf("expected 3 tags, saw %d", n)
+	}
+}
+
+func TestGetText(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    listen=   -l,--listen=ADDR            Listen address
+    peer=     --peer=ADDR                 Peer address
+    none=     --none=ADDR                 Not given
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	opts, err := spec.Interpret([]string{"tool", "-l", "::1", "--peer=1.2.3"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var addr netip.Addr
+	if err = opts.GetText("listen", &addr); err != nil || addr != netip.IPv6Loopback() {
+		t.Errorf("listen: %v %v", addr, err)
+	}
+	if err = opts.GetText("peer", &addr); err == nil || !strings.Contains(err.Error(), "peer") {
+		t.Errorf("peer: expected an error, saw %v", err)
+	}
+
+	addr = netip.IPv4Unspecified()
+	if err = opts.GetText("none", &addr); err != nil || addr != netip.IPv4Unspecified() {
+		t.Errorf("none: %v %v", addr, err)
+	}
+}
+
+func TestNumericGetters(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    ratio=0.5     -r,--ratio=             Ratio
+    timeout=30s   -t,--timeout=           Timeout
+    size=         -s,--size=              Size
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := spec.Interpret([]string{"tool", "--timeout=1m30s", "-s", "1.5G"}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f, ok := opts.GetFloat("ratio"); !ok || f != 0.5 {
+		t.Errorf("ratio: expected 0.5, saw %v", f)
+	}
+	if d, ok := opts.GetDuration("timeout"); !ok || d != 90*time.Second {
+		t.Errorf("timeout: expected 1m30s, saw %v", d)
+	}
+	if n, ok := opts.GetSize("size"); !ok || n != 3<<29 {
+		t.Errorf("size: expected %d, saw %d", uint64(3<<29), n)
+	}
+	if _, ok := opts.GetDuration("ratio"); ok {
+		t.Error("expected ratio to not be a duration")
+	}
+	if _, ok := opts.GetFloat("missing"); ok {
+		t.Error("expected missing to not be found")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	good := map[string]uint64{
+		"0":    0,
+		"512":  512,
+		"0x10": 16,
+		"100B": 100,
+		"10k":  10 << 10,
+		"10K":  10 << 10,
+		"4M":   4 << 20,
+		"4MiB": 4 << 20,
+		"4mb":  4 << 20,
+		"1.5G": 3 << 29,
+		"2T":   2 << 40,
+		"1P":   1 << 50,
+		"15E":  15 << 60,
+		"0.5k": 512,
+		" 8k ": 8 << 10,
+	}
+
+	for in, want := range good {
+		n, err := parseSize(in)
+		if err != nil || n != want {
+			t.Errorf("%q: expected %d, saw %d %v", in, want, n, err)
+		}
+	}
+
+	for _, in := range []string{"", "k", "-1", "-1k", "1x", "4iB", "1.1B", "16E", "0.1k", "NaN", "Infk"} {
+		if n, err := parseSize(in); err == nil {
+			t.Errorf("%q: expected an error, saw %d", in, n)
+		}
+	}
+}
+
+func TestStrictBool(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    verbose       -v,--verbose,TOOL_VERBOSE   Verbose
+    cache=on:bool --cache=                    Use the cache
+    color=        --color=                    Color
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	opts, err := spec.Interpret([]string{"tool", "--cache=OFF", "--color=tru"}, []string{"TOOL_VERBOSE=Yes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := opts.GetBoolErr("cache"); err != nil || b {
+		t.Errorf("bad cache: %v, %v", b, err)
+	}
+	if b, err := opts.GetBoolErr("verbose"); err != nil || !b {
+		t.Errorf("bad verbose: %v, %v", b, err)
+	}
+	if _, err := opts.GetBoolErr("color"); err == nil || err.Error() != "Invalid option: color: tru is not a valid bool" {
+		t.Errorf("bad color error: %v", err)
+	}
+	if opts.GetBool("color") {
+		t.Errorf("expected GetBool to be false")
+	}
+
+	_, err = spec.Interpret([]string{"tool", "--cache=maybe"}, []string{})
+	if err == nil || err.Error() != "Invalid option: --cache=maybe: maybe is not a valid bool" {
+		t.Errorf("bad cache error: %v", err)
+	}
+
+	// flag values are only checked when asked to
+	opts, err = spec.Interpret([]string{"tool"}, []string{"TOOL_VERBOSE=tru"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opts.GetBoolErr("verbose"); err == nil {
+		t.Errorf("expected an error for verbose")
+	}
+
+	spec.SetStrictBool(true)
+	_, err = spec.Interpret([]string{"tool"}, []string{"TOOL_VERBOSE=tru"})
+	if !errors.Is(err, ErrBadValue) {
+		t.Errorf("expected a bad value error, saw %v", err)
+	}
+	if _, err = spec.Interpret([]string{"tool", "-v"}, []string{"TOOL_VERBOSE=off"}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetTime(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    since=      --since=     Start time
+    until=      --until=     End time
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.Local)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	midnight := time.Date(2024, 3, 10, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		val     string
+		layouts []string
+		want    time.Time
+		ok      bool
+	}{
+		{"2024-01-02T03:04:05Z", nil, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), true},
+		{"2024-01-02 03:04", nil, time.Date(2024, 1, 2, 3, 4, 0, 0, time.Local), true},
+		{"2024-01-02", nil, time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local), true},
+		{"02/01/2024", []string{"02/01/2006"}, time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local), true},
+		{"2024-01-02", []string{"02/01/2006"}, time.Time{}, false},
+		{"now", nil, now, true},
+		{"Yesterday", nil, midnight.AddDate(0, 0, -1), true},
+		{"today", nil, midnight, true},
+		{"tomorrow", nil, midnight.AddDate(0, 0, 1), true},
+		{"2h", nil, now.Add(-2 * time.Hour), true},
+		{"90m ago", nil, now.Add(-90 * time.Minute), true},
+		{"-1h", nil, now.Add(-time.Hour), true},
+		{"+30m", nil, now.Add(30 * time.Minute), true},
+		{"+-30m", nil, time.Time{}, false},
+		{"soon", nil, time.Time{}, false},
+	}
+
+	for _, tc := range tests {
+		opts, err := spec.Interpret([]string{"tool", "--since", tc.val}, []string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, ok := opts.GetTime("since", tc.layouts...)
+		if ok != tc.ok || !v.Equal(tc.want) {
+			t.Errorf("%s: expected %v %v, saw %v %v", tc.val, tc.want, tc.ok, v, ok)
+		}
+		if _, ok := opts.GetTime("until"); ok {
+			t.Errorf("%s: expected until to be unset", tc.val)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// walk.go - Streaming interpretation
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"context"
+)
+
+// The kind of an Event
+type EventKind int
+
+const (
+	EventOption EventKind = iota
+	EventCommand
+	EventArg
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventOption:
+		return "option"
+	case EventCommand:
+		return "command"
+	case EventArg:
+		return "arg"
+	}
+	return "unknown"
+}
+
+// An option, command or positional argument reported by Walk()
+type Event struct {
+	Kind EventKind
+
+	// the canonical name of the option or command; empty for an
+	// argument
+	Name string
+
+	// the value of the option ("true" for flags) or the argument; the
+	// spelling of a command
+	Value string
+
+	// the index in the args given to Walk(); -1 for options that came
+	// from the flags env var (see SetFlagsEnv())
+	Index int
+}
+
+type walkKey struct{}
+
+// Interpret 'args' and 'environ' like Interpret() and call 'fn' for
+// each option, command and positional argument of the command line as
+// it is parsed, in order. This serves tools whose semantics depend on
+// the order, e.g. find(1) style expressions or per-input options ("-f
+// a.txt -x b.txt"), which the Options maps can't express. The
+// arguments of a command are reported as positional arguments; options
+// from the environment and defaults are not reported. An error from
+// 'fn' stops the parse and is returned as is.
+func (spec *Spec) Walk(args []string, environ []string, fn func(ev Event) error) error {
+	ctx := context.WithValue(context.Background(), walkKey{}, fn)
+	_, err := spec.interpret(ctx, nil, args, environ, nil, nil)
+	return err
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
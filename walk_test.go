@@ -0,0 +1,94 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] files...
+    --
+    format=     -f,--format=        Input format
+    verbose     -v,--verbose        Verbose
+    --
+    --
+    *
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+	spec.SetFlagsEnv("TOOL_FLAGS")
+
+	var evs []string
+	fn := func(ev Event) error {
+		evs = append(evs, fmt.Sprintf("%s:%s=%s@%d", ev.Kind, ev.Name, ev.Value, ev.Index))
+		return nil
+	}
+
+	args := []string{"tool", "-f", "csv", "a.txt", "--format=json", "b.txt", "--", "-c"}
+	if err = spec.Walk(args, []string{"TOOL_FLAGS=-v"}, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "option:verbose=true@-1 option:format=csv@1 arg:=a.txt@3 option:format=json@4 arg:=b.txt@5 arg:=-c@7"
+	if s := strings.Join(evs, " "); s != want {
+		t.Errorf("bad events:\n%s\nexpected:\n%s", s, want)
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err = spec.Walk(args, []string{}, func(ev Event) error {
+		if n++; ev.Kind == EventArg {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || n != 2 {
+		t.Errorf("expected to stop at the first argument, saw %v after %d events", err, n)
+	}
+}
+
+func TestWalkCommand(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options] command
+    --
+    verbose     -v,--verbose        Verbose
+    --
+    --
+    exec        exec,x              Run a program
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetSetenv(false)
+
+	var evs []string
+	fn := func(ev Event) error {
+		evs = append(evs, fmt.Sprintf("%s:%s=%s@%d", ev.Kind, ev.Name, ev.Value, ev.Index))
+		return nil
+	}
+
+	if err = spec.Walk([]string{"tool", "-v", "x", "ls", "-l"}, []string{}, fn); err != nil {
+		t.Fatal(err)
+	}
+	want := "option:verbose=true@1 command:exec=x@2 arg:=ls@3 arg:=-l@4"
+	if s := strings.Join(evs, " "); s != want {
+		t.Errorf("bad events:\n%s\nexpected:\n%s", s, want)
+	}
+
+	evs = nil
+	spec.SetOptionsAfterCommand(true)
+	if err = spec.Walk([]string{"tool", "x", "ls", "-v", "-l"}, []string{}, fn); err != nil {
+		t.Fatal(err)
+	}
+	want = "command:exec=x@1 arg:=ls@2 option:verbose=true@3 arg:=-l@4"
+	if s := strings.Join(evs, " "); s != want {
+		t.Errorf("bad events:\n%s\nexpected:\n%s", s, want)
+	}
+}
@@ -0,0 +1,83 @@
+// width.go - Display width of text
+//
+// Copyright (c) 2015-2016 Sudhi Herle <sudhi@herle.net>
+//
+// This software does not come with any express or implied
+// warranty; it is provided "as is". No claim  is made to its
+// suitability for any purpose.
+
+package options
+
+import (
+	"strings"
+	"unicode"
+)
+
+// The code points that take two columns on a terminal: the East Asian
+// wide and fullwidth characters and the emoji.
+var wideRunes = [][2]rune{
+	{0x1100, 0x115f},   // Hangul Jamo
+	{0x2e80, 0x303e},   // CJK radicals, punctuation
+	{0x3041, 0x33ff},   // Kana, CJK compatibility
+	{0x3400, 0x4dbf},   // CJK extension A
+	{0x4e00, 0x9fff},   // CJK unified ideographs
+	{0xa000, 0xa4cf},   // Yi
+	{0xac00, 0xd7a3},   // Hangul syllables
+	{0xf900, 0xfaff},   // CJK compatibility ideographs
+	{0xfe30, 0xfe4f},   // CJK compatibility forms
+	{0xff00, 0xff60},   // Fullwidth forms
+	{0xffe0, 0xffe6},   // Fullwidth signs
+	{0x1f300, 0x1f64f}, // Pictographs, emoticons
+	{0x1f680, 0x1f6ff}, // Transport and map symbols
+	{0x1f900, 0x1f9ff}, // Supplemental pictographs
+	{0x20000, 0x2fffd}, // CJK extensions
+	{0x30000, 0x3fffd},
+}
+
+// Return the number of terminal columns that 'r' takes
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x300:
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	}
+
+	for _, w := range wideRunes {
+		if r < w[0] {
+			break
+		}
+		if r <= w[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+// Return the number of terminal columns that 's' takes; the color
+// escape sequences (see SetColor()) take none.
+func displayWidth(s string) int {
+	n := 0
+	esc := false
+	for _, r := range s {
+		switch {
+		case esc:
+			esc = r != 'm'
+		case r == '\x1b':
+			esc = true
+		default:
+			n += runeWidth(r)
+		}
+	}
+	return n
+}
+
+// Pad 's' with blanks to 'w' columns
+func padRight(s string, w int) string {
+	if n := displayWidth(s); n < w {
+		return s + strings.Repeat(" ", w-n)
+	}
+	return s
+}
+
+// vim: ft=go:sw=4:ts=4:tw=78:expandtab:
@@ -0,0 +1,76 @@
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		s string
+		n int
+	}{
+		{"", 0},
+		{"root", 4},
+		{"größe", 5},
+		{"データ", 6},
+		{"출력 형식", 9},
+		{"ok 🚀", 5},
+		{"é", 1},
+	}
+
+	for _, tc := range tests {
+		if n := displayWidth(tc.s); n != tc.n {
+			t.Errorf("%q: expected %d, saw %d", tc.s, tc.n, n)
+		}
+	}
+}
+
+func TestUnicodeUsage(t *testing.T) {
+	spec, err := Parse(`
+    usage: tool [options]
+    --
+    root=/var    -r,--root=ディレクトリ  データのルート ディレクトリ
+                                      続きの説明
+    größe=10     --größe=N          Größe in Blöcken 📦
+    verbose      -v,--verbose       詳細な 出力 を 表示 します 🚀
+    --
+    --
+    --
+    `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.SetUsageWidth(40)
+
+	want := `usage: tool [options]
+
+Options:
+  -r ディレクトリ, --root=ディレクトリ
+                 データのルート
+                 ディレクトリ 続きの説明
+                 (default: /var)
+  --größe=N      Größe in Blöcken 📦
+                 (default: 10)
+  -v, --verbose  詳細な 出力 を 表示
+                 します 🚀`
+
+	if got := spec.FormatUsage(); got != want {
+		t.Errorf("expected\n%s\nsaw\n%s", want, got)
+	}
+
+	// the columns of the canonical spec line up on screen
+	lines := strings.Split(spec.String(), "\n")
+	col := -1
+	for _, l := range lines[2:5] {
+		i := strings.LastIndex(l, "  ")
+		if i < 0 {
+			t.Fatalf("bad spec line %q", l)
+		}
+		n := displayWidth(l[:i+2])
+		if col >= 0 && n != col {
+			t.Errorf("misaligned spec text:\n%s", spec.String())
+		}
+		col = n
+	}
+}